@@ -28,5 +28,12 @@ func NewAutoscalingCmd(p *pkg.AdminParams) *cobra.Command {
 	}
 	AutoscalingCmd.AddCommand(NewAutoscalingUpdateCommand(p))
 	AutoscalingCmd.AddCommand(NewAutoscalingListCommand(p))
+	AutoscalingCmd.AddCommand(NewAutoscalingResetCommand(p))
+	AutoscalingCmd.AddCommand(NewAutoscalingPresetCommand(p))
+	AutoscalingCmd.AddCommand(NewAutoscalingExplainCommand(p))
+	AutoscalingCmd.AddCommand(NewAutoscalingSimulateCommand(p))
+	AutoscalingCmd.AddCommand(NewAutoscalingPACommand(p))
+	AutoscalingCmd.AddCommand(NewAutoscalingHPACommand(p))
+	AutoscalingCmd.AddCommand(NewAutoscalingScheduleCommand(p))
 	return AutoscalingCmd
 }
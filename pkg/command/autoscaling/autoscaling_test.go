@@ -23,11 +23,34 @@ import (
 func TestNewAutoscalingCmd(t *testing.T) {
 	cmd := NewAutoscalingCmd(nil)
 	assert.Check(t, cmd.HasSubCommands(), "cmd autoscaling should have subcommands")
-	assert.Equal(t, 2, len(cmd.Commands()), "autoscaling command should have 2 subcommands")
+	assert.Equal(t, 9, len(cmd.Commands()), "autoscaling command should have 9 subcommands")
 
 	_, _, err := cmd.Find([]string{"update"})
 	assert.NilError(t, err, "autoscaling command should have update subcommand")
 
 	_, _, err = cmd.Find([]string{"list"})
 	assert.NilError(t, err, "autoscaling command should have list subcommand")
+
+	_, _, err = cmd.Find([]string{"reset"})
+	assert.NilError(t, err, "autoscaling command should have reset subcommand")
+
+	_, _, err = cmd.Find([]string{"preset", "apply"})
+	assert.NilError(t, err, "autoscaling command should have preset apply subcommand")
+
+	_, _, err = cmd.Find([]string{"explain"})
+	assert.NilError(t, err, "autoscaling command should have explain subcommand")
+
+	_, _, err = cmd.Find([]string{"simulate"})
+	assert.NilError(t, err, "autoscaling command should have simulate subcommand")
+
+	_, _, err = cmd.Find([]string{"pa", "list"})
+	assert.NilError(t, err, "autoscaling command should have pa list subcommand")
+
+	_, _, err = cmd.Find([]string{"hpa", "list"})
+	assert.NilError(t, err, "autoscaling command should have hpa list subcommand")
+
+	for _, sub := range []string{"add", "list", "remove"} {
+		_, _, err = cmd.Find([]string{"schedule", sub})
+		assert.NilError(t, err, "autoscaling command should have schedule %s subcommand", sub)
+	}
 }
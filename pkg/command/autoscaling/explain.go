@@ -0,0 +1,291 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"knative.dev/client/pkg/commands"
+	"knative.dev/client/pkg/printers"
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/pkg/kmap"
+	"knative.dev/serving/pkg/apis/autoscaling"
+	autoscalingv1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	asconfig "knative.dev/serving/pkg/autoscaler/config"
+	"knative.dev/serving/pkg/autoscaler/config/autoscalerconfig"
+)
+
+// explainedValue is an effective autoscaling setting of a revision and where it comes from
+type explainedValue struct {
+	name   string
+	value  string
+	source string
+}
+
+// NewAutoscalingExplainCommand represents autoscaling explain command
+func NewAutoscalingExplainCommand(p *pkg.AdminParams) *cobra.Command {
+	explainCmd := &cobra.Command{
+		Use:   "explain SERVICE",
+		Short: "Explain the effective autoscaling of a service",
+		Long: `Explain the effective autoscaling of the latest ready revision of a Knative Service, the global config in config-autoscaler
+is merged with the 'autoscaling.knative.dev/*' annotations and the container concurrency of the revision, and where each value comes
+from is shown. The current scale of the PodAutoscaler and the mode of the ServerlessService (SKS) are shown as well`,
+		Example: `
+  # To explain the effective autoscaling of service 'hello' in namespace 'default'
+  kn admin autoscaling explain hello -n default`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace := cmd.Flag("namespace").Value.String()
+			if namespace == "" {
+				namespace = "default"
+			}
+
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+			servingClient, err := p.NewServingClient()
+			if err != nil {
+				return err
+			}
+
+			service, err := servingClient.Services(namespace).Get(context.TODO(), args[0], metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get service '%s' in namespace '%s': %v", args[0], namespace, err)
+			}
+			revisionName := service.Status.LatestReadyRevisionName
+			if revisionName == "" {
+				revisionName = service.Status.LatestCreatedRevisionName
+			}
+			if revisionName == "" {
+				return fmt.Errorf("no revision is created for service '%s' in namespace '%s'", service.Name, namespace)
+			}
+			revision, err := servingClient.Revisions(namespace).Get(context.TODO(), revisionName, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get revision '%s' in namespace '%s': %v", revisionName, namespace, err)
+			}
+
+			cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get ConfigMaps: %+v", err)
+			}
+			config, err := asconfig.NewConfigFromMap(cm.Data)
+			if err != nil {
+				return fmt.Errorf("failed to get autoscaling config: %+v", err)
+			}
+
+			dw := printers.NewPrefixWriter(cmd.OutOrStdout())
+			dw.WriteAttribute("Service", service.Name)
+			dw.WriteAttribute("Namespace", namespace)
+			dw.WriteAttribute("Revision", revision.Name)
+			dw.WriteLine()
+			if err := dw.Flush(); err != nil {
+				return err
+			}
+
+			tw := printers.NewPrefixWriter(cmd.OutOrStdout())
+			tw.WriteColsLn("SETTING", "VALUE", "SOURCE")
+			for _, v := range explainRevision(revision, cm, config) {
+				tw.WriteColsLn(v.name, v.value, v.source)
+			}
+			tw.WriteLine()
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+
+			return printRuntimeScale(cmd, p, revision)
+		},
+	}
+	commands.AddNamespaceFlags(explainCmd.Flags(), false)
+	return explainCmd
+}
+
+// explainRevision returns the effective autoscaling settings of the revision, the logic follows
+// how the PodAutoscaler of the revision is created and reconciled by Knative Serving
+func explainRevision(revision *servingv1.Revision, cm *corev1.ConfigMap, config *autoscalerconfig.Config) []explainedValue {
+	annotations := revision.Annotations
+	values := []explainedValue{}
+	class := resolveAs("class", "pod-autoscaler-class", autoscaling.ClassAnnotation, annotations, cm, config)
+	values = append(values, class)
+
+	metric := explainedValue{"metric", "", ""}
+	if key, value, ok := autoscaling.MetricAnnotation.Get(annotations); ok {
+		metric.value, metric.source = value, "annotation "+key
+	} else {
+		metric.value, metric.source = autoscaling.Concurrency, "default of class "+class.value
+		if class.value == autoscaling.HPA {
+			metric.value = autoscaling.CPU
+		}
+	}
+	values = append(values, metric)
+
+	var cc int64
+	if revision.Spec.ContainerConcurrency != nil {
+		cc = *revision.Spec.ContainerConcurrency
+	}
+	values = append(values, explainedValue{"container-concurrency", strconv.FormatInt(cc, 10), "spec of revision"})
+
+	if metric.value == autoscaling.Concurrency || metric.value == autoscaling.RPS {
+		values = append(values, explainTarget(annotations, metric.value, cc, cm, config)...)
+	} else if key, value, ok := autoscaling.TargetAnnotation.Get(annotations); ok {
+		values = append(values, explainedValue{"target", value, "annotation " + key})
+	} else {
+		values = append(values, explainedValue{"target", "<unset>", "default of the HorizontalPodAutoscaler"})
+	}
+
+	values = append(values,
+		resolveAs("window", "stable-window", autoscaling.WindowAnnotation, annotations, cm, config),
+		resolveAs("panic-window-percentage", "panic-window-percentage", autoscaling.PanicWindowPercentageAnnotation, annotations, cm, config),
+		resolveAs("panic-threshold-percentage", "panic-threshold-percentage", autoscaling.PanicThresholdPercentageAnnotation, annotations, cm, config),
+		resolveAs("target-burst-capacity", "target-burst-capacity", autoscaling.TargetBurstCapacityAnnotation, annotations, cm, config),
+		resolveAs("min-scale", "min-scale", autoscaling.MinScaleAnnotation, annotations, cm, config),
+		resolveAs("max-scale", "max-scale", autoscaling.MaxScaleAnnotation, annotations, cm, config),
+		resolveAs("initial-scale", "initial-scale", autoscaling.InitialScaleAnnotation, annotations, cm, config),
+		resolveAs("scale-down-delay", "scale-down-delay", autoscaling.ScaleDownDelayAnnotation, annotations, cm, config),
+		resolveAs("scale-to-zero-pod-retention-period", "scale-to-zero-pod-retention-period", autoscaling.ScaleToZeroPodRetentionPeriodAnnotation, annotations, cm, config),
+		globalValue("scale-to-zero", "enable-scale-to-zero", cm, config),
+	)
+	return values
+}
+
+// explainTarget returns the target and the target utilization of the concurrency or rps metric
+func explainTarget(annotations map[string]string, metric string, cc int64, cm *corev1.ConfigMap, config *autoscalerconfig.Config) []explainedValue {
+	pa := &autoscalingv1alpha1.PodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+	}
+
+	var target, utilization explainedValue
+	var total, tu float64
+	if metric == autoscaling.RPS {
+		target = globalValue("target", "requests-per-second-target-default", cm, config)
+		total = config.RPSTargetDefault
+		utilization = explainedValue{"target-utilization", "", "default of Knative Serving"}
+		tu = config.TargetUtilization
+	} else {
+		if cc > 0 {
+			target = explainedValue{"target", "", "container-concurrency of revision"}
+			total = float64(cc)
+		} else {
+			target = globalValue("target", "container-concurrency-target-default", cm, config)
+			total = config.ContainerConcurrencyTargetDefault
+		}
+		utilization = globalValue("target-utilization", "container-concurrency-target-percentage", cm, config)
+		tu = config.ContainerConcurrencyTargetFraction
+	}
+	if v, ok := pa.Target(); ok {
+		key, _, _ := autoscaling.TargetAnnotation.Get(annotations)
+		target.source = "annotation " + key
+		total = v
+		// the concurrency target is capped by the container concurrency, the rps target is not
+		if metric == autoscaling.Concurrency && cc > 0 && float64(cc) < v {
+			target.source += ", capped by container-concurrency of revision"
+			total = float64(cc)
+		}
+	}
+	if v, ok := pa.TargetUtilization(); ok {
+		key, _, _ := autoscaling.TargetUtilizationPercentageAnnotation.Get(annotations)
+		utilization.source = "annotation " + key
+		tu = v
+	}
+	target.value = fmt.Sprintf("%.1f", total)
+	utilization.value = fmt.Sprintf("%.1f%%", tu*100)
+	effective := explainedValue{"effective-target", fmt.Sprintf("%.2f", math.Max(autoscaling.TargetMin, total*tu)), "target * target-utilization"}
+	return []explainedValue{target, utilization, effective}
+}
+
+// resolveAs returns the value of the annotation on the revision as the setting, the global value
+// of the key is used if the annotation is not set
+func resolveAs(name, key string, k kmap.KeyPriority, annotations map[string]string, cm *corev1.ConfigMap, config *autoscalerconfig.Config) explainedValue {
+	if annotation, value, ok := k.Get(annotations); ok {
+		return explainedValue{name, value, "annotation " + annotation}
+	}
+	return globalValue(name, key, cm, config)
+}
+
+// globalValue returns the value of the key in config-autoscaler as the setting
+func globalValue(name, key string, cm *corev1.ConfigMap, config *autoscalerconfig.Config) explainedValue {
+	v := explainedValue{name: name, source: "default of Knative Serving"}
+	if ck, ok := lookupConfigKey(key); ok {
+		v.value = ck.value(config)
+	}
+	if _, ok := cm.Data[key]; ok {
+		v.source = configAutoscaler + " " + key
+	}
+	return v
+}
+
+// printRuntimeScale prints the current scale of the PodAutoscaler and the mode of the ServerlessService of the revision
+func printRuntimeScale(cmd *cobra.Command, p *pkg.AdminParams, revision *servingv1.Revision) error {
+	dynamicClient, err := p.NewDynamicClient()
+	if err != nil {
+		return err
+	}
+	networkingClient, err := p.NewNetworkingClient()
+	if err != nil {
+		return err
+	}
+
+	dw := printers.NewPrefixWriter(cmd.OutOrStdout())
+	u, err := dynamicClient.Resource(pkg.PodAutoscalerResource).Namespace(revision.Namespace).Get(context.TODO(), revision.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		dw.WriteAttribute("PodAutoscaler", "<not found>")
+	case err != nil:
+		return fmt.Errorf("failed to get podautoscaler '%s' in namespace '%s': %v", revision.Name, revision.Namespace, err)
+	default:
+		pa := &autoscalingv1alpha1.PodAutoscaler{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, pa); err != nil {
+			return fmt.Errorf("failed to parse podautoscaler '%s' in namespace '%s': %v", revision.Name, revision.Namespace, err)
+		}
+		paw := dw.WriteAttribute("PodAutoscaler", pa.Name)
+		paw.WriteAttribute("Desired Scale", describeScale(pa.Status.GetDesiredScale()))
+		paw.WriteAttribute("Actual Scale", describeScale(pa.Status.GetActualScale()))
+		active := "Unknown"
+		if cond := pa.Status.GetCondition(autoscalingv1alpha1.PodAutoscalerConditionActive); cond != nil {
+			active = string(cond.Status)
+		}
+		paw.WriteAttribute("Active", active)
+	}
+
+	sks, err := networkingClient.NetworkingV1alpha1().ServerlessServices(revision.Namespace).Get(context.TODO(), revision.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		dw.WriteAttribute("ServerlessService", "<not found>")
+	case err != nil:
+		return fmt.Errorf("failed to get serverlessservice '%s' in namespace '%s': %v", revision.Name, revision.Namespace, err)
+	default:
+		sksw := dw.WriteAttribute("ServerlessService", sks.Name)
+		sksw.WriteAttribute("Mode", string(sks.Spec.Mode))
+	}
+	return dw.Flush()
+}
+
+// describeScale describes the scale of the PodAutoscaler, -1 means the scale is not set yet
+func describeScale(scale int32) string {
+	if scale < 0 {
+		return "<unknown>"
+	}
+	return strconv.Itoa(int(scale))
+}
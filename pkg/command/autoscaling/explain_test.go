@@ -0,0 +1,211 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"knative.dev/client/pkg/util"
+	networkingv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/networking/pkg/client/clientset/versioned"
+	nwfake "knative.dev/networking/pkg/client/clientset/versioned/fake"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	autoscalingv1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	asconfig "knative.dev/serving/pkg/autoscaler/config"
+	servingv1client "knative.dev/serving/pkg/client/clientset/versioned/typed/serving/v1"
+
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+// newTestRevision returns a Revision with the annotations and container concurrency
+func newTestRevision(name string, annotations map[string]string, cc int64) *servingv1.Revision {
+	revision := &servingv1.Revision{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+	}
+	revision.Spec.ContainerConcurrency = &cc
+	return revision
+}
+
+// findExplained returns the explained value of the setting
+func findExplained(t *testing.T, values []explainedValue, name string) explainedValue {
+	for _, v := range values {
+		if v.name == name {
+			return v
+		}
+	}
+	t.Fatalf("setting %s is not explained", name)
+	return explainedValue{}
+}
+
+func TestExplainRevision(t *testing.T) {
+	cm := newAutoscalerConfigMap(map[string]string{
+		"container-concurrency-target-percentage": "80",
+		"stable-window": "2m",
+	})
+	config, err := asconfig.NewConfigFromMap(cm.Data)
+	assert.NilError(t, err)
+
+	t.Run("global values", func(t *testing.T) {
+		values := explainRevision(newTestRevision("hello-00001", nil, 0), cm, config)
+		assert.Equal(t, explainedValue{"class", "kpa.autoscaling.knative.dev", "default of Knative Serving"}, findExplained(t, values, "class"))
+		assert.Equal(t, explainedValue{"metric", "concurrency", "default of class kpa.autoscaling.knative.dev"}, findExplained(t, values, "metric"))
+		assert.Equal(t, explainedValue{"target", "100.0", "default of Knative Serving"}, findExplained(t, values, "target"))
+		assert.Equal(t, explainedValue{"target-utilization", "80.0%", "config-autoscaler container-concurrency-target-percentage"}, findExplained(t, values, "target-utilization"))
+		assert.Equal(t, explainedValue{"effective-target", "80.00", "target * target-utilization"}, findExplained(t, values, "effective-target"))
+		assert.Equal(t, explainedValue{"window", "2m", "config-autoscaler stable-window"}, findExplained(t, values, "window"))
+		assert.Equal(t, explainedValue{"min-scale", "0", "default of Knative Serving"}, findExplained(t, values, "min-scale"))
+	})
+
+	t.Run("annotations and container concurrency", func(t *testing.T) {
+		values := explainRevision(newTestRevision("hello-00002", map[string]string{
+			"autoscaling.knative.dev/target":                        "50",
+			"autoscaling.knative.dev/target-utilization-percentage": "50",
+			"autoscaling.knative.dev/window":                        "30s",
+			"autoscaling.knative.dev/minScale":                      "2",
+			"autoscaling.knative.dev/max-scale":                     "10",
+		}, 20), cm, config)
+		assert.Equal(t, explainedValue{"container-concurrency", "20", "spec of revision"}, findExplained(t, values, "container-concurrency"))
+		assert.Equal(t, explainedValue{"target", "20.0", "annotation autoscaling.knative.dev/target, capped by container-concurrency of revision"}, findExplained(t, values, "target"))
+		assert.Equal(t, explainedValue{"target-utilization", "50.0%", "annotation autoscaling.knative.dev/target-utilization-percentage"}, findExplained(t, values, "target-utilization"))
+		assert.Equal(t, explainedValue{"effective-target", "10.00", "target * target-utilization"}, findExplained(t, values, "effective-target"))
+		assert.Equal(t, explainedValue{"window", "30s", "annotation autoscaling.knative.dev/window"}, findExplained(t, values, "window"))
+		assert.Equal(t, explainedValue{"min-scale", "2", "annotation autoscaling.knative.dev/minScale"}, findExplained(t, values, "min-scale"))
+		assert.Equal(t, explainedValue{"max-scale", "10", "annotation autoscaling.knative.dev/max-scale"}, findExplained(t, values, "max-scale"))
+	})
+
+	t.Run("container concurrency as target", func(t *testing.T) {
+		values := explainRevision(newTestRevision("hello-00003", nil, 10), cm, config)
+		assert.Equal(t, explainedValue{"target", "10.0", "container-concurrency of revision"}, findExplained(t, values, "target"))
+		assert.Equal(t, explainedValue{"effective-target", "8.00", "target * target-utilization"}, findExplained(t, values, "effective-target"))
+	})
+
+	t.Run("rps target is not capped by container concurrency", func(t *testing.T) {
+		values := explainRevision(newTestRevision("hello-00006", map[string]string{
+			"autoscaling.knative.dev/metric": "rps",
+			"autoscaling.knative.dev/target": "200",
+		}, 10), cm, config)
+		assert.Equal(t, explainedValue{"target", "200.0", "annotation autoscaling.knative.dev/target"}, findExplained(t, values, "target"))
+		assert.Equal(t, explainedValue{"effective-target", "140.00", "target * target-utilization"}, findExplained(t, values, "effective-target"))
+	})
+
+	t.Run("rps metric and hpa class", func(t *testing.T) {
+		values := explainRevision(newTestRevision("hello-00004", map[string]string{
+			"autoscaling.knative.dev/metric": "rps",
+		}, 0), cm, config)
+		assert.Equal(t, explainedValue{"target", "200.0", "default of Knative Serving"}, findExplained(t, values, "target"))
+		assert.Equal(t, explainedValue{"target-utilization", "70.0%", "default of Knative Serving"}, findExplained(t, values, "target-utilization"))
+
+		values = explainRevision(newTestRevision("hello-00005", map[string]string{
+			"autoscaling.knative.dev/class": "hpa.autoscaling.knative.dev",
+		}, 0), cm, config)
+		assert.Equal(t, explainedValue{"class", "hpa.autoscaling.knative.dev", "annotation autoscaling.knative.dev/class"}, findExplained(t, values, "class"))
+		assert.Equal(t, explainedValue{"metric", "cpu", "default of class hpa.autoscaling.knative.dev"}, findExplained(t, values, "metric"))
+		assert.Equal(t, explainedValue{"target", "<unset>", "default of the HorizontalPodAutoscaler"}, findExplained(t, values, "target"))
+	})
+}
+
+func TestAutoscalingExplainCommand(t *testing.T) {
+	service := &servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"}}
+	service.Status.LatestCreatedRevisionName = "hello-00002"
+	service.Status.LatestReadyRevisionName = "hello-00001"
+	revision := newTestRevision("hello-00001", map[string]string{"autoscaling.knative.dev/min-scale": "1"}, 0)
+
+	t.Run("service not found", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newAutoscalerConfigMap(nil))
+		_, err := testutil.ExecuteCommand(NewAutoscalingExplainCommand(p), "hello")
+		assert.ErrorContains(t, err, "failed to get service 'hello' in namespace 'default'")
+	})
+
+	t.Run("service without revision", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newAutoscalerConfigMap(nil))
+		servingClient := testutil.NewTestServingClient(&servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"}})
+		p.NewServingClient = func() (servingv1client.ServingV1Interface, error) {
+			return servingClient, nil
+		}
+		_, err := testutil.ExecuteCommand(NewAutoscalingExplainCommand(p), "hello")
+		assert.ErrorContains(t, err, "no revision is created for service 'hello' in namespace 'default'")
+	})
+
+	t.Run("explain with podautoscaler and serverlessservice", func(t *testing.T) {
+		desired, actual := int32(2), int32(1)
+		pa := &autoscalingv1alpha1.PodAutoscaler{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "autoscaling.internal.knative.dev/v1alpha1", Kind: "PodAutoscaler"},
+			ObjectMeta: metav1.ObjectMeta{Name: "hello-00001", Namespace: "default"},
+		}
+		pa.Status.DesiredScale, pa.Status.ActualScale = &desired, &actual
+		pa.Status.Conditions = duckv1.Conditions{{Type: autoscalingv1alpha1.PodAutoscalerConditionActive, Status: corev1.ConditionTrue}}
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pa)
+		assert.NilError(t, err)
+		dynamicClient := testutil.NewTestDynamicClient(&unstructured.Unstructured{Object: u})
+		sks := &networkingv1alpha1.ServerlessService{ObjectMeta: metav1.ObjectMeta{Name: "hello-00001", Namespace: "default"}}
+		sks.Spec.Mode = networkingv1alpha1.SKSOperationModeProxy
+		networkingClient := nwfake.NewSimpleClientset(sks)
+		servingClient := testutil.NewTestServingClient(service, revision)
+
+		p, _ := testutil.NewTestAdminParams(newAutoscalerConfigMap(map[string]string{"enable-scale-to-zero": "false"}))
+		p.NewServingClient = func() (servingv1client.ServingV1Interface, error) {
+			return servingClient, nil
+		}
+		p.NewDynamicClient = func() (dynamic.Interface, error) {
+			return dynamicClient, nil
+		}
+		p.NewNetworkingClient = func() (versioned.Interface, error) {
+			return networkingClient, nil
+		}
+
+		output, err := testutil.ExecuteCommand(NewAutoscalingExplainCommand(p), "hello", "-n", "default")
+		assert.NilError(t, err)
+		assert.Check(t, util.ContainsAll(output, "Service:", "hello", "Revision:", "hello-00001"), "unexpected output: %s", output)
+		assert.Check(t, util.ContainsAll(output, "SETTING", "VALUE", "SOURCE"), "unexpected output: %s", output)
+		lines := strings.Split(output, "\n")
+		assert.Check(t, containsLine(lines, "min-scale", "1", "annotation autoscaling.knative.dev/min-scale"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines, "scale-to-zero", "false", "config-autoscaler enable-scale-to-zero"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines, "Desired Scale:", "2"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines, "Actual Scale:", "1"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines, "Active:", "True"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines, "Mode:", "Proxy"), "unexpected output: %s", output)
+	})
+
+	t.Run("explain without podautoscaler and serverlessservice", func(t *testing.T) {
+		servingClient := testutil.NewTestServingClient(service, revision)
+		p, _ := testutil.NewTestAdminParams(newAutoscalerConfigMap(nil))
+		p.NewServingClient = func() (servingv1client.ServingV1Interface, error) {
+			return servingClient, nil
+		}
+		output, err := testutil.ExecuteCommand(NewAutoscalingExplainCommand(p), "hello")
+		assert.NilError(t, err)
+		lines := strings.Split(output, "\n")
+		assert.Check(t, containsLine(lines, "PodAutoscaler:", "<not found>"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines, "ServerlessService:", "<not found>"), "unexpected output: %s", output)
+	})
+}
+
+// containsLine returns true if any line contains all the strings
+func containsLine(lines []string, s ...string) bool {
+	for _, line := range lines {
+		if util.ContainsAll(line, s...)().Success() {
+			return true
+		}
+	}
+	return false
+}
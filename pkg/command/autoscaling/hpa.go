@@ -0,0 +1,222 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1beta1 "k8s.io/apimachinery/pkg/apis/meta/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"knative.dev/client/pkg/commands"
+	"knative.dev/client/pkg/commands/flags"
+	hprinters "knative.dev/client/pkg/printers"
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/serving/pkg/apis/serving"
+)
+
+// hpaColumnDefinitions are the columns of autoscaling hpa list command
+var hpaColumnDefinitions = []metav1beta1.TableColumnDefinition{
+	{Name: "Namespace", Type: "string", Description: "Namespace of the HorizontalPodAutoscaler.", Priority: 0},
+	{Name: "Name", Type: "string", Description: "Name of the HorizontalPodAutoscaler.", Priority: 1},
+	{Name: "Metric", Type: "string", Description: "Metric the HorizontalPodAutoscaler scales on.", Priority: 1},
+	{Name: "Target", Type: "string", Description: "Target of the metric.", Priority: 1},
+	{Name: "Current", Type: "string", Description: "Current value or utilization of the metric.", Priority: 1},
+	{Name: "Min", Type: "string", Description: "Minimum number of replicas.", Priority: 1},
+	{Name: "Max", Type: "string", Description: "Maximum number of replicas.", Priority: 1},
+	{Name: "Replicas", Type: "string", Description: "Current number of replicas.", Priority: 1},
+	{Name: "Desired", Type: "string", Description: "Desired number of replicas.", Priority: 1},
+}
+
+// NewAutoscalingHPACommand represents autoscaling hpa command
+func NewAutoscalingHPACommand(p *pkg.AdminParams) *cobra.Command {
+	hpaCmd := &cobra.Command{
+		Use:     "hpa",
+		Aliases: []string{"horizontalpodautoscaler", "horizontalpodautoscalers"},
+		Short:   "Inspect HorizontalPodAutoscalers",
+		Long:    `Inspect the HorizontalPodAutoscalers created by Knative Serving for the revisions of class hpa.autoscaling.knative.dev`,
+	}
+	hpaCmd.AddCommand(NewAutoscalingHPAListCommand(p))
+	return hpaCmd
+}
+
+// NewAutoscalingHPAListCommand represents autoscaling hpa list command
+func NewAutoscalingHPAListCommand(p *pkg.AdminParams) *cobra.Command {
+	hpaListFlags := flags.NewListPrintFlags(hpaListHandlers)
+	hpaListCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List HorizontalPodAutoscalers",
+		Long: `List the HorizontalPodAutoscalers owned by Knative revisions with the metrics, targets, current utilization and
+the number of replicas, the HorizontalPodAutoscalers not created by Knative Serving are skipped`,
+		Example: `
+  # To list HorizontalPodAutoscalers in namespace 'default'
+  kn admin autoscaling hpa list -n default
+
+  # To list HorizontalPodAutoscalers in all namespaces
+  kn admin autoscaling hpa list --all-namespaces`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace := cmd.Flag("namespace").Value.String()
+			if namespace == "" {
+				namespace = "default"
+			}
+			if all, _ := cmd.Flags().GetBool("all-namespaces"); all {
+				namespace = ""
+			}
+
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+			// the HorizontalPodAutoscalers created by Knative Serving carry the labels of the revision
+			hpaList, err := client.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(context.TODO(), metav1.ListOptions{
+				LabelSelector: serving.RevisionLabelKey,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to list horizontalpodautoscalers: %v", err)
+			}
+			if len(hpaList.Items) == 0 {
+				cmd.Println("No HorizontalPodAutoscaler found")
+				return nil
+			}
+			sort.SliceStable(hpaList.Items, func(i, j int) bool {
+				a, b := &hpaList.Items[i], &hpaList.Items[j]
+				if a.Namespace != b.Namespace {
+					return a.Namespace < b.Namespace
+				}
+				return a.Name < b.Name
+			})
+
+			// empty namespace indicates all-namespaces flag is specified
+			if namespace == "" {
+				hpaListFlags.EnsureWithNamespace()
+			}
+			return hpaListFlags.Print(hpaList, cmd.OutOrStdout())
+		},
+	}
+	commands.AddNamespaceFlags(hpaListCmd.Flags(), true)
+	hpaListFlags.HumanReadableFlags.AddFlags(hpaListCmd)
+	hpaListFlags.GenericPrintFlags.OutputFlagSpecified = func() bool {
+		return false
+	}
+	return hpaListCmd
+}
+
+// hpaListHandlers adds the handlers for `kn admin autoscaling hpa list` command's output
+func hpaListHandlers(h hprinters.PrintHandler) {
+	h.TableHandler(hpaColumnDefinitions, func(hpaList *autoscalingv2.HorizontalPodAutoscalerList, options hprinters.PrintOptions) ([]metav1beta1.TableRow, error) {
+		rows := make([]metav1beta1.TableRow, 0, len(hpaList.Items))
+		for i := range hpaList.Items {
+			hpa := &hpaList.Items[i]
+			row := metav1beta1.TableRow{Object: runtime.RawExtension{Object: hpa}}
+			if options.AllNamespaces {
+				row.Cells = append(row.Cells, hpa.Namespace)
+			}
+			names, targets, currents := []string{}, []string{}, []string{}
+			for _, spec := range hpa.Spec.Metrics {
+				name, target := describeHPAMetricTarget(spec)
+				names = append(names, name)
+				targets = append(targets, target)
+				currents = append(currents, describeHPAMetricCurrent(hpa.Status.CurrentMetrics, spec))
+			}
+			minReplicas := "<unset>"
+			if hpa.Spec.MinReplicas != nil {
+				minReplicas = fmt.Sprint(*hpa.Spec.MinReplicas)
+			}
+			row.Cells = append(row.Cells,
+				hpa.Name,
+				describeList(names),
+				describeList(targets),
+				describeList(currents),
+				minReplicas,
+				fmt.Sprint(hpa.Spec.MaxReplicas),
+				fmt.Sprint(hpa.Status.CurrentReplicas),
+				fmt.Sprint(hpa.Status.DesiredReplicas))
+			rows = append(rows, row)
+		}
+		return rows, nil
+	})
+}
+
+// describeHPAMetricTarget returns the name and the target of the metric, the resource metrics are
+// named by the resource and the custom metrics by the metric name
+func describeHPAMetricTarget(spec autoscalingv2.MetricSpec) (string, string) {
+	switch {
+	case spec.Resource != nil:
+		return spec.Resource.Name.String(), describeHPATarget(spec.Resource.Target)
+	case spec.ContainerResource != nil:
+		return spec.ContainerResource.Name.String(), describeHPATarget(spec.ContainerResource.Target)
+	case spec.Pods != nil:
+		return spec.Pods.Metric.Name, describeHPATarget(spec.Pods.Target)
+	case spec.Object != nil:
+		return spec.Object.Metric.Name, describeHPATarget(spec.Object.Target)
+	case spec.External != nil:
+		return spec.External.Metric.Name, describeHPATarget(spec.External.Target)
+	}
+	return string(spec.Type), "<unknown>"
+}
+
+// describeHPATarget describes the target of a metric, the utilization is shown in percentage
+func describeHPATarget(target autoscalingv2.MetricTarget) string {
+	switch {
+	case target.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *target.AverageUtilization)
+	case target.AverageValue != nil:
+		return target.AverageValue.String()
+	case target.Value != nil:
+		return target.Value.String()
+	}
+	return "<unset>"
+}
+
+// describeHPAMetricCurrent returns the current value of the metric from the status of the HorizontalPodAutoscaler
+func describeHPAMetricCurrent(statuses []autoscalingv2.MetricStatus, spec autoscalingv2.MetricSpec) string {
+	name, _ := describeHPAMetricTarget(spec)
+	for _, status := range statuses {
+		if status.Type != spec.Type {
+			continue
+		}
+		var current *autoscalingv2.MetricValueStatus
+		switch {
+		case status.Resource != nil && status.Resource.Name.String() == name:
+			current = &status.Resource.Current
+		case status.ContainerResource != nil && status.ContainerResource.Name.String() == name:
+			current = &status.ContainerResource.Current
+		case status.Pods != nil && status.Pods.Metric.Name == name:
+			current = &status.Pods.Current
+		case status.Object != nil && status.Object.Metric.Name == name:
+			current = &status.Object.Current
+		case status.External != nil && status.External.Metric.Name == name:
+			current = &status.External.Current
+		}
+		if current == nil {
+			continue
+		}
+		switch {
+		case current.AverageUtilization != nil:
+			return fmt.Sprintf("%d%%", *current.AverageUtilization)
+		case current.AverageValue != nil:
+			return current.AverageValue.String()
+		case current.Value != nil:
+			return current.Value.String()
+		}
+	}
+	return "<unknown>"
+}
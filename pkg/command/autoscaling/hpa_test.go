@@ -0,0 +1,124 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+// newTestHPA returns a HorizontalPodAutoscaler of the revision scaling on the resource utilization,
+// the HorizontalPodAutoscaler is not owned by Knative if revision is empty
+func newTestHPA(namespace, name, revision string, resourceName corev1.ResourceName, target, current, replicas int32) *autoscalingv2.HorizontalPodAutoscaler {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			MaxReplicas: 10,
+			Metrics: []autoscalingv2.MetricSpec{{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{
+					Name:   resourceName,
+					Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &target},
+				},
+			}},
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+			CurrentReplicas: replicas,
+			DesiredReplicas: replicas,
+			CurrentMetrics: []autoscalingv2.MetricStatus{{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricStatus{
+					Name:    resourceName,
+					Current: autoscalingv2.MetricValueStatus{AverageUtilization: &current},
+				},
+			}},
+		},
+	}
+	if revision != "" {
+		hpa.Labels = map[string]string{"serving.knative.dev/revision": revision}
+	}
+	return hpa
+}
+
+func TestAutoscalingHPAListCommand(t *testing.T) {
+	minReplicas := int32(1)
+	hello := newTestHPA("default", "hello-00001", "hello-00001", corev1.ResourceCPU, 80, 45, 2)
+	hello.Spec.MinReplicas = &minReplicas
+	report := newTestHPA("batch", "report-00002", "report-00002", corev1.ResourceMemory, 70, 90, 3)
+	report.Status.DesiredReplicas = 4
+	custom := newTestHPA("default", "custom", "", corev1.ResourceCPU, 50, 10, 1)
+
+	t.Run("no HorizontalPodAutoscaler", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(custom)
+		output, err := testutil.ExecuteCommand(NewAutoscalingHPAListCommand(p))
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "No HorizontalPodAutoscaler found"), "unexpected output: %s", output)
+	})
+
+	t.Run("list HorizontalPodAutoscalers in namespace", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(hello, report, custom)
+		output, err := testutil.ExecuteCommand(NewAutoscalingHPAListCommand(p), "-n", "default")
+		assert.NilError(t, err)
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		assert.Equal(t, 2, len(lines), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[:1], "NAME", "METRIC", "TARGET", "CURRENT", "MIN", "MAX", "REPLICAS", "DESIRED"), "unexpected output: %s", output)
+		assert.Check(t, !strings.Contains(lines[0], "NAMESPACE"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[1:], "hello-00001", "cpu", "80%", "45%", "1", "10", "2", "2"), "unexpected output: %s", output)
+	})
+
+	t.Run("list HorizontalPodAutoscalers in all namespaces", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(hello, report, custom)
+		output, err := testutil.ExecuteCommand(NewAutoscalingHPAListCommand(p), "--all-namespaces")
+		assert.NilError(t, err)
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		assert.Equal(t, 3, len(lines), "unexpected output: %s", output)
+		assert.Check(t, strings.HasPrefix(lines[0], "NAMESPACE"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[1:2], "batch", "report-00002", "memory", "70%", "90%", "<unset>", "10", "3", "4"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[2:3], "default", "hello-00001", "cpu"), "unexpected output: %s", output)
+	})
+}
+
+func TestDescribeHPAMetrics(t *testing.T) {
+	value := resource.MustParse("100")
+	spec := autoscalingv2.MetricSpec{
+		Type: autoscalingv2.PodsMetricSourceType,
+		Pods: &autoscalingv2.PodsMetricSource{
+			Metric: autoscalingv2.MetricIdentifier{Name: "rps"},
+			Target: autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: &value},
+		},
+	}
+	name, target := describeHPAMetricTarget(spec)
+	assert.Equal(t, "rps", name)
+	assert.Equal(t, "100", target)
+	assert.Equal(t, "<unknown>", describeHPAMetricCurrent(nil, spec))
+
+	current := resource.MustParse("42")
+	statuses := []autoscalingv2.MetricStatus{{
+		Type: autoscalingv2.PodsMetricSourceType,
+		Pods: &autoscalingv2.PodsMetricStatus{
+			Metric:  autoscalingv2.MetricIdentifier{Name: "rps"},
+			Current: autoscalingv2.MetricValueStatus{AverageValue: &current},
+		},
+	}}
+	assert.Equal(t, "42", describeHPAMetricCurrent(statuses, spec))
+}
@@ -0,0 +1,241 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"knative.dev/serving/pkg/autoscaler/config/autoscalerconfig"
+)
+
+// configKeyKind is the kind of the value of a config-autoscaler key
+type configKeyKind string
+
+const (
+	boolKey     configKeyKind = "bool"
+	intKey      configKeyKind = "int"
+	floatKey    configKeyKind = "float"
+	durationKey configKeyKind = "duration"
+	stringKey   configKeyKind = "string"
+)
+
+// A function for getting specific field value from autoscaler config
+type valueOfConfig func(*autoscalerconfig.Config) string
+
+// configKey describes a key of config-autoscaler, both the flags of 'autoscaling update'
+// and the rows of 'autoscaling list' are generated from it
+type configKey struct {
+	// name is the key in config-autoscaler
+	name string
+	// field is the field of autoscalerconfig.Config which the key is parsed into
+	field string
+	kind  configKeyKind
+	// flag is the flag name of 'autoscaling update', the key name is used if it's empty
+	flag  string
+	usage string
+	// valueOf overrides how the value is shown in 'autoscaling list'
+	valueOf valueOfConfig
+	// kpaOnly is set if the key is ignored when the revisions are scaled by the HPA class
+	kpaOnly bool
+}
+
+// configKeys are all keys of config-autoscaler sorted by name, keep it in step with the keys
+// parsed by config.NewConfigFromMap when Knative Serving is bumped
+var configKeys = []configKey{
+	{
+		name:  "activator-capacity",
+		field: "ActivatorCapacity",
+		kind:  floatKey,
+		usage: "number of the concurrent requests an activator task can accept, it decides the number of activators in the request path of a revision for both KPA and HPA classes",
+	},
+	{
+		name:  "allow-zero-initial-scale",
+		field: "AllowZeroInitialScale",
+		kind:  boolKey,
+		usage: "Allow revisions to be created with initial scale 0 if set.",
+	},
+	{
+		name:    "container-concurrency-target-default",
+		field:   "ContainerConcurrencyTargetDefault",
+		kind:    floatKey,
+		usage:   "the default value of container concurrency target",
+		kpaOnly: true,
+	},
+	{
+		name:  "container-concurrency-target-percentage",
+		field: "ContainerConcurrencyTargetFraction",
+		kind:  floatKey,
+		usage: "percentage of the specified target should actually be targeted by the Autoscaler",
+		valueOf: func(config *autoscalerconfig.Config) string {
+			return fmt.Sprintf("%.1f", config.ContainerConcurrencyTargetFraction*100)
+		},
+		kpaOnly: true,
+	},
+	{
+		name:    "enable-scale-to-zero",
+		field:   "EnableScaleToZero",
+		kind:    boolKey,
+		flag:    "scale-to-zero",
+		usage:   "Enable scale-to-zero if set.",
+		kpaOnly: true,
+	},
+	{
+		name:  "initial-scale",
+		field: "InitialScale",
+		kind:  intKey,
+		usage: "the initial number of pods of a new revision, 0 requires allow-zero-initial-scale",
+	},
+	{
+		name:  "max-scale",
+		field: "MaxScale",
+		kind:  intKey,
+		usage: "the default maximum number of pods of a revision, 0 means unlimited",
+	},
+	{
+		name:    "max-scale-down-rate",
+		field:   "MaxScaleDownRate",
+		kind:    floatKey,
+		usage:   "Maximum ratio of observed vs. desired pods",
+		kpaOnly: true,
+	},
+	{
+		name:  "max-scale-limit",
+		field: "MaxScaleLimit",
+		kind:  intKey,
+		usage: "the upper bound of the maximum number of pods a revision can set, 0 means unlimited",
+	},
+	{
+		name:    "max-scale-up-rate",
+		field:   "MaxScaleUpRate",
+		kind:    floatKey,
+		usage:   "Maximum ratio of desired vs. observed pods",
+		kpaOnly: true,
+	},
+	{
+		name:  "min-scale",
+		field: "MinScale",
+		kind:  intKey,
+		usage: "the default minimum number of pods of a revision",
+	},
+	{
+		name:    "panic-threshold-percentage",
+		field:   "PanicThresholdPercentage",
+		kind:    floatKey,
+		usage:   "This threshold defines when the autoscaler will move from stable mode into panic mode",
+		kpaOnly: true,
+	},
+	{
+		name:    "panic-window-percentage",
+		field:   "PanicWindowPercentage",
+		kind:    floatKey,
+		usage:   "The panic window is defined as a percentage of the stable window",
+		kpaOnly: true,
+	},
+	{
+		name:  "pod-autoscaler-class",
+		field: "PodAutoscalerClass",
+		kind:  stringKey,
+		usage: "the config of Knative autoscaling to work with either the default KPA or a CPU based metric, i.e. Horizontal Pod Autoscaler (HPA)",
+	},
+	{
+		name:    "requests-per-second-target-default",
+		field:   "RPSTargetDefault",
+		kind:    floatKey,
+		usage:   "the default target value for requests per second",
+		kpaOnly: true,
+	},
+	{
+		name:    "scale-down-delay",
+		field:   "ScaleDownDelay",
+		kind:    durationKey,
+		usage:   "the amount of time that must pass at reduced concurrency before a scale down decision is applied",
+		kpaOnly: true,
+	},
+	{
+		name:    "scale-to-zero-grace-period",
+		field:   "ScaleToZeroGracePeriod",
+		kind:    durationKey,
+		usage:   "the maximum seconds of time that the last pod will remain active after the Autoscaler has decided to scale pods to zero",
+		kpaOnly: true,
+	},
+	{
+		name:    "scale-to-zero-pod-retention-period",
+		field:   "ScaleToZeroPodRetentionPeriod",
+		kind:    durationKey,
+		usage:   "the minimum seconds of time that the last pod will remain active after the Autoscaler has decided to scale pods to zero",
+		kpaOnly: true,
+	},
+	{
+		name:    "stable-window",
+		field:   "StableWindow",
+		kind:    durationKey,
+		usage:   "when operating in a stable mode, the autoscaler operates on the average concurrency over the x seconds of stable window",
+		kpaOnly: true,
+	},
+	{
+		name:  "target-burst-capacity",
+		field: "TargetBurstCapacity",
+		kind:  floatKey,
+		usage: "the desired burst capacity for the revision",
+	},
+}
+
+// derivedConfigFields are the fields of autoscalerconfig.Config which are not parsed from any key
+var derivedConfigFields = []string{"TargetUtilization"}
+
+// flagName returns the flag name of the key in 'autoscaling update'
+func (k configKey) flagName() string {
+	if k.flag != "" {
+		return k.flag
+	}
+	return k.name
+}
+
+// value returns the value of the key in the autoscaler config for 'autoscaling list'
+func (k configKey) value(config *autoscalerconfig.Config) string {
+	if k.valueOf != nil {
+		return k.valueOf(config)
+	}
+	v := reflect.ValueOf(config).Elem().FieldByName(k.field)
+	switch k.kind {
+	case boolKey:
+		return fmt.Sprintf("%t", v.Bool())
+	case intKey:
+		return fmt.Sprintf("%d", v.Int())
+	case floatKey:
+		return fmt.Sprintf("%.1f", v.Float())
+	case durationKey:
+		return describeDuration(time.Duration(v.Int()))
+	default:
+		return v.String()
+	}
+}
+
+// duration returns the duration value of the key in the autoscaler config
+func (k configKey) duration(config *autoscalerconfig.Config) time.Duration {
+	return time.Duration(reflect.ValueOf(config).Elem().FieldByName(k.field).Int())
+}
+
+// lookupConfigKey returns the config key with the name
+func lookupConfigKey(name string) (configKey, bool) {
+	for _, key := range configKeys {
+		if key.name == name {
+			return key, true
+		}
+	}
+	return configKey{}, false
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/serving/pkg/autoscaler/config"
+	"knative.dev/serving/pkg/autoscaler/config/autoscalerconfig"
+)
+
+func TestConfigKeysInStepWithAutoscalerConfig(t *testing.T) {
+	configType := reflect.TypeOf(autoscalerconfig.Config{})
+	kinds := map[configKeyKind]reflect.Kind{
+		boolKey:     reflect.Bool,
+		intKey:      reflect.Int32,
+		floatKey:    reflect.Float64,
+		durationKey: reflect.Int64,
+		stringKey:   reflect.String,
+	}
+
+	names := make([]string, 0, len(configKeys))
+	fields := sets.New[string](derivedConfigFields...)
+	for _, key := range configKeys {
+		names = append(names, key.name)
+		field, ok := configType.FieldByName(key.field)
+		assert.Check(t, ok, "field %s of key %s is not found in autoscalerconfig.Config", key.field, key.name)
+		assert.Equal(t, kinds[key.kind], field.Type.Kind(), "unexpected kind of key %s", key.name)
+		assert.Check(t, !fields.Has(key.field), "field %s is covered by more than one key", key.field)
+		fields.Insert(key.field)
+
+		// every key except strings must be parsed by the autoscaler, so an invalid value is rejected
+		if key.kind != stringKey {
+			_, err := config.NewConfigFromMap(map[string]string{key.name: "invalid"})
+			assert.ErrorContains(t, err, key.name)
+		}
+	}
+	assert.Check(t, sort.StringsAreSorted(names), "config keys should be sorted by name")
+
+	for i := 0; i < configType.NumField(); i++ {
+		assert.Check(t, fields.Has(configType.Field(i).Name), "field %s of autoscalerconfig.Config is not covered by any key", configType.Field(i).Name)
+	}
+}
+
+func TestConfigKeyValue(t *testing.T) {
+	c, err := config.NewConfigFromMap(map[string]string{
+		"allow-zero-initial-scale":                "true",
+		"container-concurrency-target-percentage": "0.85",
+		"max-scale":             "10",
+		"pod-autoscaler-class":  "hpa.autoscaling.knative.dev",
+		"scale-down-delay":      "2m",
+		"target-burst-capacity": "-1",
+	})
+	assert.NilError(t, err)
+
+	expected := map[string]string{
+		"allow-zero-initial-scale":                "true",
+		"container-concurrency-target-percentage": "85.0",
+		"max-scale":             "10",
+		"pod-autoscaler-class":  "hpa.autoscaling.knative.dev",
+		"scale-down-delay":      "2m",
+		"target-burst-capacity": "-1.0",
+	}
+	for name, value := range expected {
+		key, ok := lookupConfigKey(name)
+		assert.Check(t, ok, "key %s should be found", name)
+		assert.Equal(t, value, key.value(c), "unexpected value of key %s", name)
+	}
+
+	key, _ := lookupConfigKey("scale-down-delay")
+	assert.Equal(t, 2*time.Minute, key.duration(c))
+	key, _ = lookupConfigKey("enable-scale-to-zero")
+	assert.Equal(t, "scale-to-zero", key.flagName())
+	_, ok := lookupConfigKey("unknown")
+	assert.Check(t, !ok, "unknown key should not be found")
+}
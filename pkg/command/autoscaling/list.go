@@ -17,6 +17,7 @@ package autoscaling
 import (
 	"context"
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 	"time"
@@ -25,60 +26,22 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metav1beta1 "k8s.io/apimachinery/pkg/apis/meta/v1beta1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 	"knative.dev/client/pkg/commands/flags"
 	hprinters "knative.dev/client/pkg/printers"
 	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/command/utils"
+	"knative.dev/serving/pkg/apis/autoscaling"
 	"knative.dev/serving/pkg/autoscaler/config"
-	"knative.dev/serving/pkg/autoscaler/config/autoscalerconfig"
 )
 
-// A function for getting specific field value from autoscaler config
-type valueOfConfig func(*autoscalerconfig.Config) string
+// exampleKey is the key of the documented example in ConfigMap config-autoscaler
+const exampleKey = "_example"
 
-var (
-	ConfigNameValueOfMap = map[string]valueOfConfig{
-		"activator-capacity": func(config *autoscalerconfig.Config) string {
-			return fmt.Sprintf("%.1f", config.ActivatorCapacity)
-		},
-		"container-concurrency-target-default": func(config *autoscalerconfig.Config) string {
-			return fmt.Sprintf("%.1f", config.ContainerConcurrencyTargetDefault)
-		},
-		"enable-scale-to-zero": func(config *autoscalerconfig.Config) string {
-			return fmt.Sprintf("%+v", config.EnableScaleToZero)
-		},
-		"max-scale-up-rate": func(config *autoscalerconfig.Config) string {
-			return fmt.Sprintf("%.1f", config.MaxScaleUpRate)
-		},
-		"max-scale-down-rate": func(config *autoscalerconfig.Config) string {
-			return fmt.Sprintf("%.1f", config.MaxScaleDownRate)
-		},
-		"panic-window-percentage": func(config *autoscalerconfig.Config) string {
-			return fmt.Sprintf("%.1f", config.PanicWindowPercentage)
-		},
-		"panic-threshold-percentage": func(config *autoscalerconfig.Config) string {
-			return fmt.Sprintf("%.1f", config.PanicThresholdPercentage)
-		},
-		"pod-autoscaler-class": func(config *autoscalerconfig.Config) string {
-			return config.PodAutoscalerClass
-		},
-		"requests-per-second-target-default": func(config *autoscalerconfig.Config) string {
-			return fmt.Sprintf("%.1f", config.RPSTargetDefault)
-		},
-		"stable-window": func(config *autoscalerconfig.Config) string {
-			return describeDuration(config.StableWindow)
-		},
-		"scale-to-zero-grace-period": func(config *autoscalerconfig.Config) string {
-			return describeDuration(config.ScaleToZeroGracePeriod)
-		},
-		"scale-to-zero-pod-retention-period": func(config *autoscalerconfig.Config) string {
-			return describeDuration(config.ScaleToZeroPodRetentionPeriod)
-		},
-		"target-burst-capacity": func(config *autoscalerconfig.Config) string {
-			return fmt.Sprintf("%.1f", config.TargetBurstCapacity)
-		},
-	}
-)
+// kpaOnlyMark marks the description of the keys ignored by the HPA class
+const kpaOnlyMark = "[KPA only] "
 
 // describeDuration describes time.duration without 'm0s' and 'h0m'
 func describeDuration(d time.Duration) string {
@@ -92,70 +55,163 @@ func describeDuration(d time.Duration) string {
 	return s
 }
 
-// autoscalingListHandlers handles for `kn autoscaling list` command's output
-func autoscalingListHandlers(h hprinters.PrintHandler) {
-	autoscalingColumnDefinitions := []metav1beta1.TableColumnDefinition{
-		{Name: "Name", Type: "string", Description: "Name of the Autoscaling config", Priority: 1},
-		{Name: "Value", Type: "string", Description: "Value of the Autoscaling config", Priority: 1},
+// autoscalingListHandlers returns the handlers for `kn autoscaling list` command's output, only the keys
+// overridden in ConfigMap config-autoscaler are printed if overriddenOnly is true
+func autoscalingListHandlers(overriddenOnly bool) func(h hprinters.PrintHandler) {
+	return func(h hprinters.PrintHandler) {
+		autoscalingColumnDefinitions := []metav1beta1.TableColumnDefinition{
+			{Name: "Name", Type: "string", Description: "Name of the Autoscaling config", Priority: 1},
+			{Name: "Value", Type: "string", Description: "Value of the Autoscaling config", Priority: 1},
+			{Name: "Default", Type: "string", Description: "Default value of the Autoscaling config in Knative Serving", Priority: 1},
+			{Name: "Overridden", Type: "boolean", Description: "Whether the Autoscaling config is overridden in config-autoscaler", Priority: 1},
+			{Name: "Description", Type: "string", Description: "Description of the Autoscaling config", Priority: 1},
+		}
+		h.TableHandler(autoscalingColumnDefinitions, func(cm *corev1.ConfigMap, options hprinters.PrintOptions) ([]metav1beta1.TableRow, error) {
+			return printAutoscalingConfigs(cm, overriddenOnly)
+		})
 	}
-	h.TableHandler(autoscalingColumnDefinitions, printAutoscalingConfigs)
 }
 
-// printAutoscalingConfigs builds autoscaling config list table rows
-func printAutoscalingConfigs(cm *corev1.ConfigMap, options hprinters.PrintOptions) ([]metav1beta1.TableRow, error) {
-	rows := make([]metav1beta1.TableRow, 0, len(ConfigNameValueOfMap))
-	config, err := config.NewConfigFromMap(cm.Data)
+// printAutoscalingConfigs builds autoscaling config list table rows, the keys unknown to kn admin
+// are printed with the raw values if they are overridden, and the KPA-only keys are marked if the
+// default class is HPA
+func printAutoscalingConfigs(cm *corev1.ConfigMap, overriddenOnly bool) ([]metav1beta1.TableRow, error) {
+	rows := make([]metav1beta1.TableRow, 0, len(configKeys))
+	current, err := config.NewConfigFromMap(cm.Data)
 	if err != nil {
 		return rows, fmt.Errorf("failed to get autoscaling config: %+v", err)
 	}
+	defaults, _ := config.NewConfigFromMap(map[string]string{})
+	descriptions := exampleDescriptions(cm.Data[exampleKey])
 
-	// sort config names
-	names := make([]string, 0, len(ConfigNameValueOfMap))
-	for key := range ConfigNameValueOfMap {
-		names = append(names, key)
+	names := overriddenKeys(cm)
+	if !overriddenOnly {
+		for _, key := range configKeys {
+			if _, ok := cm.Data[key.name]; !ok {
+				names = append(names, key.name)
+			}
+		}
+		sort.Strings(names)
 	}
-	sort.Strings(names)
 
 	for _, name := range names {
+		_, overridden := cm.Data[name]
+		value, defaultValue, description := cm.Data[name], "", descriptions[name]
+		if key, ok := lookupConfigKey(name); ok {
+			value, defaultValue = key.value(current), key.value(defaults)
+			if description == "" {
+				description = key.usage
+			}
+			if key.kpaOnly && current.PodAutoscalerClass == autoscaling.HPA {
+				description = kpaOnlyMark + description
+			}
+		}
 		row := metav1beta1.TableRow{}
-		row.Cells = append(row.Cells, name, ConfigNameValueOfMap[name](config))
+		row.Cells = append(row.Cells, name, value, defaultValue, overridden, description)
 		rows = append(rows, []metav1beta1.TableRow{row}...)
 	}
 	return rows, nil
 }
 
+// exampleDescriptions returns the first sentence of the comments above each key in the '_example' block of
+// ConfigMap config-autoscaler, the comments separated from the key by an empty line are skipped
+func exampleDescriptions(example string) map[string]string {
+	descriptions := map[string]string{}
+	comments := []string{}
+	for _, line := range strings.Split(example, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			comments = comments[:0]
+		case strings.HasPrefix(line, "#"):
+			comments = append(comments, strings.TrimSpace(strings.TrimLeft(line, "#")))
+		default:
+			name, _, ok := strings.Cut(line, ":")
+			if ok && len(comments) > 0 {
+				descriptions[strings.TrimSpace(name)] = firstSentence(strings.TrimSpace(strings.Join(comments, " ")))
+			}
+			comments = comments[:0]
+		}
+	}
+	return descriptions
+}
+
+// firstSentence returns the first sentence of the text
+func firstSentence(text string) string {
+	if i := strings.Index(text, ". "); i >= 0 {
+		return text[:i+1]
+	}
+	return text
+}
+
 // NewAutoscalingListCommand represents autoscaling list command
 func NewAutoscalingListCommand(p *pkg.AdminParams) *cobra.Command {
-	autoscalingListFlags := flags.NewListPrintFlags(autoscalingListHandlers)
+	var overriddenOnly bool
+	autoscalingListFlags := flags.NewListPrintFlags(autoscalingListHandlers(false))
+	watchFlags := &utils.WatchFlags{}
 	autoscalingListCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List autoscaling config",
-		Long:  `List autoscaling config provided by Knative Pod Autoscaler (KPA)`,
+		Long: `List autoscaling config provided by Knative Pod Autoscaler (KPA)
+
+If pod-autoscaler-class is hpa.autoscaling.knative.dev, the keys ignored by the HPA class are marked with [KPA only]`,
 		Example: `
   # To list all autoscaling configs
-  kn admin autoscaling list`,
+  kn admin autoscaling list
+
+  # To list the autoscaling configs customized in config-autoscaler
+  kn admin autoscaling list --overridden-only
 
+  # To watch config-autoscaler and emit the changes as JSON events
+  kn admin autoscaling list --watch --watch-output json`,
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return watchFlags.Validate()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := p.NewKubeClient()
 			if err != nil {
 				return err
 			}
 
-			currentCm := &corev1.ConfigMap{}
-			currentCm, err = client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to get ConfigMaps: %+v", err)
-			}
+			list := func(out io.Writer) (string, error) {
+				currentCm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+				if err != nil {
+					return "", fmt.Errorf("failed to get ConfigMaps: %+v", err)
+				}
 
-			err = autoscalingListFlags.Print(currentCm, cmd.OutOrStdout())
-			if err != nil {
+				autoscalingListFlags.PrinterHandler = autoscalingListHandlers(false)
+				if overriddenOnly {
+					if len(overriddenKeys(currentCm)) == 0 {
+						fmt.Fprintln(out, "No overridden autoscaling config found")
+						return currentCm.ResourceVersion, nil
+					}
+					autoscalingListFlags.PrinterHandler = autoscalingListHandlers(true)
+				}
+				return currentCm.ResourceVersion, autoscalingListFlags.Print(currentCm, out)
+			}
+			if !watchFlags.Watch {
+				_, err = list(cmd.OutOrStdout())
 				return err
 			}
-			return nil
+
+			lw := utils.ListWatch{
+				Kind: "ConfigMap",
+				List: list,
+				Watch: func(ctx context.Context, resourceVersion string) (watch.Interface, error) {
+					return client.CoreV1().ConfigMaps(knativeServing).Watch(ctx, metav1.ListOptions{
+						FieldSelector:   fields.OneTermEqualSelector("metadata.name", configAutoscaler).String(),
+						ResourceVersion: resourceVersion,
+					})
+				},
+			}
+			return lw.Run(cmd.Context(), cmd.OutOrStdout(), watchFlags.Output)
 		},
 	}
 
 	autoscalingListFlags.HumanReadableFlags.AddFlags(autoscalingListCmd)
+	watchFlags.AddFlags(autoscalingListCmd)
+	autoscalingListCmd.Flags().BoolVar(&overriddenOnly, "overridden-only", false, "only list the autoscaling configs overridden in config-autoscaler")
 	autoscalingListFlags.GenericPrintFlags.OutputFlagSpecified = func() bool {
 		return false
 	}
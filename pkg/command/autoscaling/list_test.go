@@ -15,7 +15,7 @@
 package autoscaling
 
 import (
-	"sort"
+	"context"
 	"strings"
 	"testing"
 	"time"
@@ -23,6 +23,7 @@ import (
 	"gotest.tools/v3/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 	"knative.dev/client/pkg/util"
 	"knative.dev/kn-plugin-admin/pkg/testutil"
 	"knative.dev/serving/pkg/autoscaler/config"
@@ -32,7 +33,7 @@ func checkListOutput(t *testing.T, data map[string]string, output string, noHead
 	config, err := config.NewConfigFromMap(data)
 	assert.NilError(t, err)
 
-	count := len(ConfigNameValueOfMap)
+	count := len(configKeys)
 	lines := strings.Split(strings.Trim(output, "\n"), "\n")
 	if !noHeaders {
 		assert.Check(t, util.ContainsAll(lines[0], "NAME", "VALUE"))
@@ -41,19 +42,12 @@ func checkListOutput(t *testing.T, data map[string]string, output string, noHead
 		assert.Equal(t, len(lines), count)
 	}
 
-	names := make([]string, 0, count)
-	for key := range ConfigNameValueOfMap {
-		names = append(names, key)
-	}
-	sort.Strings(names)
-
 	start := 1
 	if noHeaders {
 		start = 0
 	}
-	for i, name := range names {
-		value := ConfigNameValueOfMap[name](config)
-		assert.Check(t, util.ContainsAll(lines[i+start], name, value))
+	for i, key := range configKeys {
+		assert.Check(t, util.ContainsAll(lines[i+start], key.name, key.value(config)))
 	}
 }
 
@@ -110,6 +104,8 @@ func TestAutoscalingListCommand(t *testing.T) {
 				"enable-scale-to-zero":    "true",
 				"panic-window-percentage": "10",
 				"max-scale-up-rate":       "100",
+				"max-scale":               "10",
+				"scale-down-delay":        "15m",
 			},
 		}
 		p, client := testutil.NewTestAdminParams(cm)
@@ -140,3 +136,123 @@ func TestAutoscalingListCommandNoHeader(t *testing.T) {
 		checkListOutput(t, cm.Data, output, true)
 	})
 }
+
+func TestAutoscalingListDefaultsAndOverrides(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configAutoscaler,
+			Namespace: knativeServing,
+		},
+		Data: map[string]string{
+			"_example": `
+    ################################
+    #    EXAMPLE CONFIGURATION     #
+    ################################
+
+    # This block is not actually functional configuration.
+    #
+    # These sample configuration options may be copied out.
+
+    # The Revision ContainerConcurrency field specifies the maximum number
+    # of requests the Container can handle at once. Container concurrency
+    # target percentage is how much of that maximum to use.
+    container-concurrency-target-percentage: "70"
+
+    # Scale to zero feature flag.
+    enable-scale-to-zero: "true"`,
+			"enable-scale-to-zero": "false",
+			"new-upstream-key":     "on",
+		},
+	}
+
+	t.Run("list with defaults and descriptions", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(cm)
+		output, err := testutil.ExecuteCommand(NewAutoscalingListCommand(p))
+		assert.NilError(t, err)
+		lines := strings.Split(strings.Trim(output, "\n"), "\n")
+		assert.Equal(t, len(configKeys)+2, len(lines))
+		assert.Check(t, util.ContainsAll(lines[0], "NAME", "VALUE", "DEFAULT", "OVERRIDDEN", "DESCRIPTION"))
+		assert.Check(t, util.ContainsAll(output, "container-concurrency-target-percentage", "70.0", "false",
+			"The Revision ContainerConcurrency field specifies the maximum number of requests the Container can handle at once."))
+		assert.Check(t, !strings.Contains(output, "Container concurrency target"), "only the first sentence should be shown: %s", output)
+		assert.Check(t, util.ContainsAll(output, "stable-window", "1m", "false", "when operating in a stable mode"))
+		for _, line := range lines {
+			if strings.HasPrefix(line, "enable-scale-to-zero") {
+				assert.Check(t, util.ContainsAll(line, "false", "true", "Scale to zero feature flag."), "unexpected line: %s", line)
+			}
+			assert.Check(t, !strings.HasPrefix(line, "_example"), "_example should not be listed")
+		}
+	})
+
+	t.Run("list overridden only", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(cm)
+		output, err := testutil.ExecuteCommand(NewAutoscalingListCommand(p), "--overridden-only", "--no-headers")
+		assert.NilError(t, err)
+		lines := strings.Split(strings.Trim(output, "\n"), "\n")
+		assert.Equal(t, 2, len(lines))
+		assert.Check(t, util.ContainsAll(lines[0], "enable-scale-to-zero", "false", "true", "true"))
+		assert.Check(t, util.ContainsAll(lines[1], "new-upstream-key", "on", "true"))
+	})
+
+	t.Run("list overridden only without overrides", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      configAutoscaler,
+				Namespace: knativeServing,
+			},
+			Data: map[string]string{"_example": "# Scale to zero feature flag.\nenable-scale-to-zero: \"true\""},
+		})
+		output, err := testutil.ExecuteCommand(NewAutoscalingListCommand(p), "--overridden-only")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "No overridden autoscaling config found"), "unexpected output: %s", output)
+	})
+}
+
+func TestAutoscalingListKPAOnlyKeys(t *testing.T) {
+	t.Run("kpa class", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newAutoscalerConfigMap(nil))
+		output, err := testutil.ExecuteCommand(NewAutoscalingListCommand(p))
+		assert.NilError(t, err)
+		assert.Check(t, !strings.Contains(output, kpaOnlyMark), "unexpected output: %s", output)
+	})
+
+	t.Run("hpa class", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newAutoscalerConfigMap(map[string]string{"pod-autoscaler-class": "hpa.autoscaling.knative.dev"}))
+		output, err := testutil.ExecuteCommand(NewAutoscalingListCommand(p), "--no-headers")
+		assert.NilError(t, err)
+		for _, line := range strings.Split(strings.Trim(output, "\n"), "\n") {
+			name := strings.Fields(line)[0]
+			key, ok := lookupConfigKey(name)
+			assert.Assert(t, ok, "unexpected key: %s", name)
+			assert.Equal(t, key.kpaOnly, strings.Contains(line, kpaOnlyMark), "unexpected line: %s", line)
+		}
+	})
+}
+
+func TestAutoscalingListWatch(t *testing.T) {
+	cm := newAutoscalerConfigMap(map[string]string{"stable-window": "2m"})
+	p, client := testutil.NewTestAdminParams(cm)
+	output, err := testutil.ExecuteWatchCommand(NewAutoscalingListCommand(p), &client.Fake, "configmaps", func(w *watch.FakeWatcher) {
+		updated := cm.DeepCopy()
+		updated.Data["max-scale"] = "10"
+		_, err := client.CoreV1().ConfigMaps(knativeServing).Update(context.TODO(), updated, metav1.UpdateOptions{})
+		assert.NilError(t, err)
+		w.Modify(updated)
+	}, "--overridden-only", "--no-headers", "--watch")
+	assert.NilError(t, err)
+	sections := strings.Split(output, "MODIFIED ConfigMap knative-serving/config-autoscaler\n")
+	assert.Equal(t, 2, len(sections), "unexpected output: %s", output)
+	assert.Check(t, strings.HasPrefix(sections[0], "stable-window") && !strings.Contains(sections[0], "max-scale"), "unexpected output: %s", output)
+	lines := strings.Split(strings.TrimSpace(sections[1]), "\n")
+	assert.Equal(t, 2, len(lines), "unexpected output: %s", output)
+	assert.Check(t, util.ContainsAll(lines[0], "max-scale", "10"), "unexpected output: %s", output)
+	assert.Check(t, util.ContainsAll(lines[1], "stable-window", "2m"), "unexpected output: %s", output)
+
+	_, err = testutil.ExecuteCommand(NewAutoscalingListCommand(p), "--watch-output", "json")
+	assert.ErrorContains(t, err, "--watch-output requires --watch")
+}
+
+func TestExampleDescriptions(t *testing.T) {
+	descriptions := exampleDescriptions("# Header line.\n\n# First sentence. Second sentence.\n# More details\nstable-window: \"60s\"\n# no-colon\nscale-down-delay \"0s\"\nmax-scale: \"0\"")
+	assert.DeepEqual(t, map[string]string{"stable-window": "First sentence."}, descriptions)
+}
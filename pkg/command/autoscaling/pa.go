@@ -0,0 +1,239 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1beta1 "k8s.io/apimachinery/pkg/apis/meta/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"knative.dev/client/pkg/commands"
+	"knative.dev/client/pkg/commands/flags"
+	hprinters "knative.dev/client/pkg/printers"
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/serving/pkg/apis/autoscaling"
+	autoscalingv1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+	asconfig "knative.dev/serving/pkg/autoscaler/config"
+	"knative.dev/serving/pkg/autoscaler/config/autoscalerconfig"
+)
+
+// paColumnDefinitions are the columns of autoscaling pa list command
+var paColumnDefinitions = []metav1beta1.TableColumnDefinition{
+	{Name: "Namespace", Type: "string", Description: "Namespace of the PodAutoscaler.", Priority: 0},
+	{Name: "Name", Type: "string", Description: "Name of the PodAutoscaler.", Priority: 1},
+	{Name: "Class", Type: "string", Description: "Autoscaler class of the PodAutoscaler.", Priority: 1},
+	{Name: "Metric", Type: "string", Description: "Metric the PodAutoscaler scales on.", Priority: 1},
+	{Name: "Target", Type: "string", Description: "Effective target per pod of the metric.", Priority: 1},
+	{Name: "Desired", Type: "string", Description: "Desired scale of the PodAutoscaler.", Priority: 1},
+	{Name: "Actual", Type: "string", Description: "Actual scale of the PodAutoscaler.", Priority: 1},
+	{Name: "Delta", Type: "string", Description: "Actual scale minus desired scale, positive if over-provisioned.", Priority: 1},
+	{Name: "Ready", Type: "string", Description: "Ready condition of the PodAutoscaler.", Priority: 1},
+	{Name: "SKS Mode", Type: "string", Description: "Mode of the ServerlessService of the PodAutoscaler.", Priority: 1},
+}
+
+// NewAutoscalingPACommand represents autoscaling pa command
+func NewAutoscalingPACommand(p *pkg.AdminParams) *cobra.Command {
+	paCmd := &cobra.Command{
+		Use:     "pa",
+		Aliases: []string{"podautoscaler", "podautoscalers"},
+		Short:   "Inspect PodAutoscalers",
+		Long:    `Inspect the PodAutoscalers created by Knative Serving for revisions`,
+	}
+	paCmd.AddCommand(NewAutoscalingPAListCommand(p))
+	return paCmd
+}
+
+// NewAutoscalingPAListCommand represents autoscaling pa list command
+func NewAutoscalingPAListCommand(p *pkg.AdminParams) *cobra.Command {
+	paListFlags := flags.NewListPrintFlags(nil)
+	paListCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List PodAutoscalers",
+		Long: `List PodAutoscalers with the class, metric, effective target, desired and actual scale, ready status and the mode of
+the ServerlessService (SKS). The PodAutoscalers are sorted by the difference between the actual and desired scale, so the
+most over- or under-provisioned ones come first`,
+		Example: `
+  # To list PodAutoscalers in namespace 'default'
+  kn admin autoscaling pa list -n default
+
+  # To list PodAutoscalers in all namespaces
+  kn admin autoscaling pa list --all-namespaces`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace := cmd.Flag("namespace").Value.String()
+			if namespace == "" {
+				namespace = "default"
+			}
+			if all, _ := cmd.Flags().GetBool("all-namespaces"); all {
+				namespace = ""
+			}
+
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+			dynamicClient, err := p.NewDynamicClient()
+			if err != nil {
+				return err
+			}
+			networkingClient, err := p.NewNetworkingClient()
+			if err != nil {
+				return err
+			}
+
+			cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get ConfigMaps: %+v", err)
+			}
+			config, err := asconfig.NewConfigFromMap(cm.Data)
+			if err != nil {
+				return fmt.Errorf("failed to get autoscaling config: %+v", err)
+			}
+
+			ul, err := dynamicClient.Resource(pkg.PodAutoscalerResource).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to list podautoscalers: %v", err)
+			}
+			paList := &autoscalingv1alpha1.PodAutoscalerList{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(ul.UnstructuredContent(), paList); err != nil {
+				return fmt.Errorf("failed to parse podautoscalers: %v", err)
+			}
+			if len(paList.Items) == 0 {
+				cmd.Println("No PodAutoscaler found")
+				return nil
+			}
+			sortPodAutoscalers(paList.Items)
+
+			sksList, err := networkingClient.NetworkingV1alpha1().ServerlessServices(namespace).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to list serverlessservices: %v", err)
+			}
+			modes := make(map[string]string, len(sksList.Items))
+			for _, sks := range sksList.Items {
+				modes[sks.Namespace+"/"+sks.Name] = string(sks.Spec.Mode)
+			}
+
+			// empty namespace indicates all-namespaces flag is specified
+			if namespace == "" {
+				paListFlags.EnsureWithNamespace()
+			}
+			paListFlags.PrinterHandler = paListHandlers(cm, config, modes)
+			return paListFlags.Print(paList, cmd.OutOrStdout())
+		},
+	}
+	commands.AddNamespaceFlags(paListCmd.Flags(), true)
+	paListFlags.HumanReadableFlags.AddFlags(paListCmd)
+	paListFlags.GenericPrintFlags.OutputFlagSpecified = func() bool {
+		return false
+	}
+	return paListCmd
+}
+
+// paListHandlers returns the handlers for `kn admin autoscaling pa list` command's output, modes contains
+// the mode of the ServerlessServices indexed by 'namespace/name'
+func paListHandlers(cm *corev1.ConfigMap, config *autoscalerconfig.Config, modes map[string]string) func(h hprinters.PrintHandler) {
+	return func(h hprinters.PrintHandler) {
+		h.TableHandler(paColumnDefinitions, func(paList *autoscalingv1alpha1.PodAutoscalerList, options hprinters.PrintOptions) ([]metav1beta1.TableRow, error) {
+			rows := make([]metav1beta1.TableRow, 0, len(paList.Items))
+			for i := range paList.Items {
+				pa := &paList.Items[i]
+				row := metav1beta1.TableRow{Object: runtime.RawExtension{Object: pa}}
+				if options.AllNamespaces {
+					row.Cells = append(row.Cells, pa.Namespace)
+				}
+				mode, ok := modes[pa.Namespace+"/"+pa.Name]
+				if !ok {
+					mode = "<none>"
+				}
+				ready := "Unknown"
+				if cond := pa.Status.GetCondition(autoscalingv1alpha1.PodAutoscalerConditionReady); cond != nil {
+					ready = string(cond.Status)
+				}
+				delta := "<unknown>"
+				if d, ok := scaleDelta(pa); ok {
+					delta = fmt.Sprintf("%+d", d)
+				}
+				row.Cells = append(row.Cells,
+					pa.Name,
+					pa.Class(),
+					pa.Metric(),
+					effectiveTarget(pa, cm, config),
+					describeScale(pa.Status.GetDesiredScale()),
+					describeScale(pa.Status.GetActualScale()),
+					delta,
+					ready,
+					mode)
+				rows = append(rows, row)
+			}
+			return rows, nil
+		})
+	}
+}
+
+// effectiveTarget returns the effective target per pod of the PodAutoscaler, the target of the metrics
+// other than concurrency and rps is only known from the annotation
+func effectiveTarget(pa *autoscalingv1alpha1.PodAutoscaler, cm *corev1.ConfigMap, config *autoscalerconfig.Config) string {
+	metric := pa.Metric()
+	if metric == autoscaling.Concurrency || metric == autoscaling.RPS {
+		values := explainTarget(pa.Annotations, metric, pa.Spec.ContainerConcurrency, cm, config)
+		return values[len(values)-1].value
+	}
+	if target, ok := pa.Target(); ok {
+		return strconv.FormatFloat(target, 'f', -1, 64)
+	}
+	return "<unset>"
+}
+
+// scaleDelta returns the actual scale minus the desired scale of the PodAutoscaler, false is returned
+// if either scale is not known yet
+func scaleDelta(pa *autoscalingv1alpha1.PodAutoscaler) (int32, bool) {
+	desired, actual := pa.Status.GetDesiredScale(), pa.Status.GetActualScale()
+	if desired < 0 || actual < 0 {
+		return 0, false
+	}
+	return actual - desired, true
+}
+
+// sortPodAutoscalers sorts the PodAutoscalers by the absolute scale delta in descending order, the ones with
+// unknown scale come last, and the ties are sorted by namespace and name
+func sortPodAutoscalers(items []autoscalingv1alpha1.PodAutoscaler) {
+	gap := func(pa *autoscalingv1alpha1.PodAutoscaler) int32 {
+		d, ok := scaleDelta(pa)
+		if !ok {
+			return -1
+		}
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := &items[i], &items[j]
+		if ga, gb := gap(a), gap(b); ga != gb {
+			return ga > gb
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+}
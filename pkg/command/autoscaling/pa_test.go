@@ -0,0 +1,146 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	networkingv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/networking/pkg/client/clientset/versioned"
+	nwfake "knative.dev/networking/pkg/client/clientset/versioned/fake"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	autoscalingv1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+// newTestPodAutoscaler returns a PodAutoscaler with the scales, a negative scale means the scale is not set
+func newTestPodAutoscaler(namespace, name string, annotations map[string]string, desired, actual int32) *autoscalingv1alpha1.PodAutoscaler {
+	pa := &autoscalingv1alpha1.PodAutoscaler{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "autoscaling.internal.knative.dev/v1alpha1", Kind: "PodAutoscaler"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Annotations: annotations},
+	}
+	if desired >= 0 {
+		pa.Status.DesiredScale = &desired
+	}
+	if actual >= 0 {
+		pa.Status.ActualScale = &actual
+	}
+	pa.Status.Conditions = duckv1.Conditions{{Type: autoscalingv1alpha1.PodAutoscalerConditionReady, Status: corev1.ConditionTrue}}
+	return pa
+}
+
+// newPAListTestParams returns the admin params with the PodAutoscalers and ServerlessServices
+func newPAListTestParams(t *testing.T, pas []*autoscalingv1alpha1.PodAutoscaler, sks ...runtime.Object) *pkg.AdminParams {
+	objects := make([]runtime.Object, 0, len(pas))
+	for _, pa := range pas {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pa)
+		assert.NilError(t, err)
+		objects = append(objects, &unstructured.Unstructured{Object: u})
+	}
+	dynamicClient := testutil.NewTestDynamicClient(objects...)
+	networkingClient := nwfake.NewSimpleClientset(sks...)
+
+	p, _ := testutil.NewTestAdminParams(newAutoscalerConfigMap(map[string]string{"container-concurrency-target-default": "10"}))
+	p.NewDynamicClient = func() (dynamic.Interface, error) {
+		return dynamicClient, nil
+	}
+	p.NewNetworkingClient = func() (versioned.Interface, error) {
+		return networkingClient, nil
+	}
+	return p
+}
+
+func TestSortPodAutoscalers(t *testing.T) {
+	items := []autoscalingv1alpha1.PodAutoscaler{
+		*newTestPodAutoscaler("default", "balanced", nil, 2, 2),
+		*newTestPodAutoscaler("default", "unknown", nil, -1, 1),
+		*newTestPodAutoscaler("default", "under", nil, 5, 1),
+		*newTestPodAutoscaler("default", "over", nil, 1, 3),
+		*newTestPodAutoscaler("apps", "over", nil, 0, 2),
+	}
+	sortPodAutoscalers(items)
+	names := []string{}
+	for _, pa := range items {
+		names = append(names, pa.Namespace+"/"+pa.Name)
+	}
+	assert.DeepEqual(t, []string{"default/under", "apps/over", "default/over", "default/balanced", "default/unknown"}, names)
+}
+
+func TestAutoscalingPAListCommand(t *testing.T) {
+	t.Run("no podautoscaler", func(t *testing.T) {
+		p := newPAListTestParams(t, nil)
+		output, err := testutil.ExecuteCommand(NewAutoscalingPAListCommand(p))
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "No PodAutoscaler found"), "unexpected output: %s", output)
+	})
+
+	t.Run("missing config-autoscaler", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams()
+		_, err := testutil.ExecuteCommand(NewAutoscalingPAListCommand(p))
+		assert.ErrorContains(t, err, "failed to get ConfigMaps")
+	})
+
+	pas := []*autoscalingv1alpha1.PodAutoscaler{
+		newTestPodAutoscaler("default", "hello-00001", nil, 2, 2),
+		newTestPodAutoscaler("default", "slow-00001", map[string]string{"autoscaling.knative.dev/metric": "rps", "autoscaling.knative.dev/target": "50"}, 5, 1),
+		newTestPodAutoscaler("apps", "cpu-00001", map[string]string{"autoscaling.knative.dev/class": "hpa.autoscaling.knative.dev", "autoscaling.knative.dev/target": "80"}, 1, 3),
+	}
+	sks := &networkingv1alpha1.ServerlessService{ObjectMeta: metav1.ObjectMeta{Name: "slow-00001", Namespace: "default"}}
+	sks.Spec.Mode = networkingv1alpha1.SKSOperationModeProxy
+
+	t.Run("list in namespace", func(t *testing.T) {
+		p := newPAListTestParams(t, pas, sks)
+		output, err := testutil.ExecuteCommand(NewAutoscalingPAListCommand(p), "-n", "default")
+		assert.NilError(t, err)
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		assert.Equal(t, 3, len(lines), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[:1], "NAME", "CLASS", "METRIC", "TARGET", "DESIRED", "ACTUAL", "DELTA", "READY", "SKS MODE"), "unexpected output: %s", output)
+		assert.Check(t, !strings.Contains(lines[0], "NAMESPACE"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[1:2], "slow-00001", "kpa.autoscaling.knative.dev", "rps", "35.00", "5", "1", "-4", "True", "Proxy"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[2:3], "hello-00001", "concurrency", "7.00", "+0", "<none>"), "unexpected output: %s", output)
+	})
+
+	t.Run("list in all namespaces", func(t *testing.T) {
+		p := newPAListTestParams(t, pas, sks)
+		output, err := testutil.ExecuteCommand(NewAutoscalingPAListCommand(p), "--all-namespaces")
+		assert.NilError(t, err)
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		assert.Equal(t, 4, len(lines), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[:1], "NAMESPACE", "NAME"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[1:2], "default", "slow-00001", "-4"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[2:3], "apps", "cpu-00001", "hpa.autoscaling.knative.dev", "cpu", "80", "+2"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[3:4], "default", "hello-00001"), "unexpected output: %s", output)
+	})
+
+	t.Run("rps target is not capped by container concurrency", func(t *testing.T) {
+		rps := newTestPodAutoscaler("default", "rps-00001", map[string]string{"autoscaling.knative.dev/metric": "rps", "autoscaling.knative.dev/target": "200"}, 1, 1)
+		rps.Spec.ContainerConcurrency = 10
+		p := newPAListTestParams(t, []*autoscalingv1alpha1.PodAutoscaler{rps})
+		output, err := testutil.ExecuteCommand(NewAutoscalingPAListCommand(p), "-n", "default")
+		assert.NilError(t, err)
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		assert.Equal(t, 2, len(lines), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[1:2], "rps-00001", "rps", "140.00"), "unexpected output: %s", output)
+	})
+}
@@ -0,0 +1,221 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/command/utils"
+	asconfig "knative.dev/serving/pkg/autoscaler/config"
+)
+
+var (
+	// presetAnnotation is the annotation on ConfigMap config-autoscaler recording the last applied preset
+	presetAnnotation = "kn-admin.knative.dev/autoscaling-preset"
+	// presetsConfigKey is the key of user-defined presets in the admin config file
+	presetsConfigKey = "autoscaling.presets"
+)
+
+// builtinPresets are the tuned autoscaling configs shipped with kn admin
+var builtinPresets = map[string]map[string]string{
+	// keep pods warm and absorb traffic bursts with the activator
+	"latency-sensitive": {
+		"enable-scale-to-zero":       "false",
+		"min-scale":                  "1",
+		"target-burst-capacity":      "1000",
+		"panic-threshold-percentage": "150",
+		"scale-down-delay":           "300s",
+	},
+	// scale to zero quickly and follow the traffic closely
+	"cost-saving": {
+		"enable-scale-to-zero":                    "true",
+		"min-scale":                               "0",
+		"stable-window":                           "30s",
+		"scale-to-zero-grace-period":              "10s",
+		"scale-to-zero-pod-retention-period":      "0s",
+		"scale-down-delay":                        "0s",
+		"container-concurrency-target-percentage": "90",
+	},
+	// smooth out scaling decisions of long-running requests and avoid panic mode, the activator stays in the
+	// request path to buffer the requests and send them to the pods with free capacity
+	"batch": {
+		"enable-scale-to-zero":                    "true",
+		"stable-window":                           "300s",
+		"panic-threshold-percentage":              "1000",
+		"max-scale-up-rate":                       "10",
+		"scale-down-delay":                        "600s",
+		"container-concurrency-target-percentage": "100",
+		"target-burst-capacity":                   "-1",
+	},
+}
+
+// NewAutoscalingPresetCommand represents autoscaling preset command
+func NewAutoscalingPresetCommand(p *pkg.AdminParams) *cobra.Command {
+	presetCmd := &cobra.Command{
+		Use:   "preset",
+		Short: "Manage autoscaling presets",
+		Long: `Manage the named sets of autoscaling config, the built-in presets are 'latency-sensitive', 'cost-saving' and 'batch'.
+User-defined presets are loaded from the key 'autoscaling.presets' in the admin config file, e.g:
+
+autoscaling:
+  presets:
+    my-preset:
+      min-scale: 2
+      stable-window: 2m
+
+A user-defined preset overrides the built-in preset with the same name`,
+	}
+	presetCmd.AddCommand(NewAutoscalingPresetApplyCommand(p))
+	return presetCmd
+}
+
+// NewAutoscalingPresetApplyCommand represents autoscaling preset apply command
+func NewAutoscalingPresetApplyCommand(p *pkg.AdminParams) *cobra.Command {
+	var dryRun, force bool
+	presetApplyCmd := &cobra.Command{
+		Use:   "apply NAME",
+		Short: "Apply an autoscaling preset",
+		Long: `Apply an autoscaling preset to ConfigMap config-autoscaler, the keys not in the preset are kept.
+The changes are shown before applying and the preset name is recorded in the annotation '` + presetAnnotation + `'.
+The preset is checked by the same rules as 'autoscaling update', the blocking rules can be overridden with --force`,
+		Example: `
+  # To show the changes of preset 'cost-saving' without applying it
+  kn admin autoscaling preset apply cost-saving --dry-run
+
+  # To apply preset 'latency-sensitive'
+  kn admin autoscaling preset apply latency-sensitive`,
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return p.EnsureInstallMethodStandalone()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			presets, err := loadPresets()
+			if err != nil {
+				return err
+			}
+			preset, ok := presets[name]
+			if !ok {
+				return fmt.Errorf("unknown autoscaling preset '%s', available presets: %s", name, strings.Join(sortedKeys(presets), ", "))
+			}
+
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+			currentCm := &corev1.ConfigMap{}
+			currentCm, err = client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get ConfigMaps: %+v", err)
+			}
+
+			desiredCm := currentCm.DeepCopy()
+			if desiredCm.Data == nil {
+				desiredCm.Data = map[string]string{}
+			}
+			for key, value := range preset {
+				desiredCm.Data[key] = value
+			}
+			config, err := asconfig.NewConfigFromMap(desiredCm.Data)
+			if err != nil {
+				return fmt.Errorf("invalid autoscaling config of preset '%s': %v", name, err)
+			}
+			if desiredCm.Annotations == nil {
+				desiredCm.Annotations = map[string]string{}
+			}
+			desiredCm.Annotations[presetAnnotation] = name
+
+			if !printPresetDiff(cmd, name, currentCm, preset) {
+				cmd.Printf("Autoscaling config is already up to date with preset '%s'\n", name)
+			}
+			if err = checkRules(cmd, p, config, force); err != nil {
+				return err
+			}
+			if dryRun {
+				return nil
+			}
+
+			err = utils.UpdateConfigMap(client, desiredCm)
+			if err != nil {
+				return fmt.Errorf("failed to update ConfigMap %s in namespace %s: %+v", configAutoscaler, knativeServing, err)
+			}
+			cmd.Printf("Applied autoscaling preset '%s'\n", name)
+			return nil
+		},
+	}
+	presetApplyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only show the changes without applying the preset")
+	presetApplyCmd.Flags().BoolVar(&force, "force", false, "apply the preset even if the settings violate the blocking rules")
+	return presetApplyCmd
+}
+
+// loadPresets returns the built-in presets merged with the user-defined presets in the admin config file
+func loadPresets() (map[string]map[string]string, error) {
+	presets := make(map[string]map[string]string, len(builtinPresets))
+	for name, preset := range builtinPresets {
+		presets[name] = preset
+	}
+	for name := range viper.GetStringMap(presetsConfigKey) {
+		settings := viper.GetStringMap(presetsConfigKey + "." + name)
+		if len(settings) == 0 {
+			return nil, fmt.Errorf("autoscaling preset '%s' in config file %s has no key", name, viper.ConfigFileUsed())
+		}
+		preset := make(map[string]string, len(settings))
+		for key, value := range settings {
+			preset[key] = fmt.Sprint(value)
+		}
+		presets[name] = preset
+	}
+	return presets, nil
+}
+
+// printPresetDiff prints the keys changed by the preset with the values before and after applying,
+// it returns false if no key is changed
+func printPresetDiff(cmd *cobra.Command, name string, currentCm *corev1.ConfigMap, preset map[string]string) bool {
+	changed := false
+	for _, key := range sortedKeys(preset) {
+		before, ok := currentCm.Data[key]
+		if ok && before == preset[key] {
+			continue
+		}
+		if !ok {
+			before = "<unset>"
+		}
+		if !changed {
+			cmd.Printf("Changes of ConfigMap %s by preset '%s':\n", configAutoscaler, name)
+			changed = true
+		}
+		cmd.Printf("  %s: %s -> %s\n", key, before, preset[key])
+	}
+	return changed
+}
+
+// sortedKeys returns the sorted keys of the map
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
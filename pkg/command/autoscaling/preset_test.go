@@ -0,0 +1,153 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+	asconfig "knative.dev/serving/pkg/autoscaler/config"
+)
+
+func TestBuiltinPresetsAreValid(t *testing.T) {
+	for name, preset := range builtinPresets {
+		config, err := asconfig.NewConfigFromMap(preset)
+		assert.NilError(t, err, "preset %s should be valid", name)
+		assert.Check(t, len(evaluateRules(ruleInput{config: config})) == 0, "preset %s should not violate any rule: %v", name, evaluateRules(ruleInput{config: config}))
+		for key := range preset {
+			_, ok := lookupConfigKey(key)
+			assert.Check(t, ok, "unknown key %s in preset %s", key, name)
+		}
+	}
+}
+
+func TestAutoscalingPresetApplyCommand(t *testing.T) {
+	t.Run("unknown preset", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newAutoscalerConfigMap(nil))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		_, err := testutil.ExecuteCommand(NewAutoscalingPresetCommand(p), "apply", "unknown")
+		assert.ErrorContains(t, err, "unknown autoscaling preset 'unknown', available presets: batch, cost-saving, latency-sensitive")
+	})
+
+	t.Run("operator mode should not be supported", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newAutoscalerConfigMap(nil))
+		p.InstallationMethod = pkg.InstallationMethodOperator
+		_, err := testutil.ExecuteCommand(NewAutoscalingPresetCommand(p), "apply", "batch")
+		assert.ErrorContains(t, err, "Knative managed by operator is not supported yet")
+	})
+
+	t.Run("apply built-in preset", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams(newAutoscalerConfigMap(map[string]string{
+			"enable-scale-to-zero": "true",
+			"min-scale":            "1",
+			"max-scale":            "20",
+		}))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+
+		output, err := testutil.ExecuteCommand(NewAutoscalingPresetCommand(p), "apply", "latency-sensitive", "--dry-run")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Changes of ConfigMap config-autoscaler by preset 'latency-sensitive':"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(output, "  enable-scale-to-zero: true -> false"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(output, "  scale-down-delay: <unset> -> 300s"), "unexpected output: %s", output)
+		assert.Check(t, !strings.Contains(output, "min-scale"), "unchanged key should not be shown: %s", output)
+		assert.Check(t, !strings.Contains(output, "Applied"), "preset should not be applied in dry run: %s", output)
+		cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "true", cm.Data["enable-scale-to-zero"])
+
+		output, err = testutil.ExecuteCommand(NewAutoscalingPresetCommand(p), "apply", "latency-sensitive")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Applied autoscaling preset 'latency-sensitive'"), "unexpected output: %s", output)
+		cm, err = client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "latency-sensitive", cm.Annotations[presetAnnotation])
+		assert.Equal(t, "false", cm.Data["enable-scale-to-zero"])
+		assert.Equal(t, "20", cm.Data["max-scale"], "key not in preset should be kept")
+
+		output, err = testutil.ExecuteCommand(NewAutoscalingPresetCommand(p), "apply", "latency-sensitive")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Autoscaling config is already up to date with preset 'latency-sensitive'"), "unexpected output: %s", output)
+	})
+
+	t.Run("apply user-defined preset from config file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "admin.yaml")
+		assert.NilError(t, os.WriteFile(path, []byte(`
+autoscaling:
+  presets:
+    batch:
+      min-scale: 2
+      enable-scale-to-zero: false
+      stable-window: 2m
+    invalid:
+      max-scale-limit: 5
+      max-scale: 10
+`), 0600))
+		viper.SetConfigFile(path)
+		assert.NilError(t, viper.ReadInConfig())
+		defer viper.Reset()
+
+		p, client := testutil.NewTestAdminParams(newAutoscalerConfigMap(nil))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+
+		_, err := testutil.ExecuteCommand(NewAutoscalingPresetCommand(p), "apply", "batch")
+		assert.NilError(t, err)
+		cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, map[string]string{
+			"min-scale":            "2",
+			"enable-scale-to-zero": "false",
+			"stable-window":        "2m",
+		}, cm.Data)
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingPresetCommand(p), "apply", "invalid")
+		assert.ErrorContains(t, err, "invalid autoscaling config of preset 'invalid': max-scale = 10")
+	})
+
+	t.Run("check the rules before applying", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "admin.yaml")
+		assert.NilError(t, os.WriteFile(path, []byte(`
+autoscaling:
+  presets:
+    fast-panic:
+      panic-window-percentage: 1
+`), 0600))
+		viper.SetConfigFile(path)
+		assert.NilError(t, viper.ReadInConfig())
+		defer viper.Reset()
+
+		p, client := testutil.NewTestAdminParams(newAutoscalerConfigMap(nil))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+
+		_, err := testutil.ExecuteCommand(NewAutoscalingPresetCommand(p), "apply", "fast-panic")
+		assert.ErrorContains(t, err, "autoscaling config violates 1 rule(s), use --force to update anyway")
+		assert.ErrorContains(t, err, "[panic-window] panic window of 600ms")
+		cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "", cm.Annotations[presetAnnotation], "preset violating a blocking rule should not be applied")
+
+		output, err := testutil.ExecuteCommand(NewAutoscalingPresetCommand(p), "apply", "fast-panic", "--force")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Warning: [panic-window] panic window of 600ms"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(output, "Applied autoscaling preset 'fast-panic'"), "unexpected output: %s", output)
+	})
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/command/utils"
+	asconfig "knative.dev/serving/pkg/autoscaler/config"
+)
+
+// NewAutoscalingResetCommand represents autoscaling reset command
+func NewAutoscalingResetCommand(p *pkg.AdminParams) *cobra.Command {
+	var all bool
+	AutoscalingResetCommand := &cobra.Command{
+		Use:   "reset [KEY...]",
+		Short: "Reset autoscaling config to default",
+		Long:  `Reset autoscaling config by removing the overridden keys from ConfigMap config-autoscaler, so the defaults of Knative Serving apply again`,
+		Example: `
+  # To reset stable window and scale-to-zero grace period to the defaults
+  kn admin autoscaling reset stable-window scale-to-zero-grace-period
+
+  # To reset all autoscaling config to the defaults
+  kn admin autoscaling reset --all`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if all && len(args) > 0 {
+				return errors.New("please specify either key(s) or --all")
+			}
+			if !all && len(args) == 0 {
+				return errors.New("'autoscaling reset' requires key(s) or --all")
+			}
+			return p.EnsureInstallMethodStandalone()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+
+			currentCm := &corev1.ConfigMap{}
+			currentCm, err = client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get ConfigMaps: %+v", err)
+			}
+
+			names := args
+			if all {
+				names = overriddenKeys(currentCm)
+			}
+			desiredCm := currentCm.DeepCopy()
+			reset := []string{}
+			for _, name := range names {
+				if _, ok := currentCm.Data[name]; ok {
+					delete(desiredCm.Data, name)
+					reset = append(reset, name)
+					continue
+				}
+				if _, known := lookupConfigKey(name); !known {
+					return fmt.Errorf("unknown autoscaling config key '%s'", name)
+				}
+				cmd.Printf("Key '%s' is not overridden, skipped\n", name)
+			}
+			if len(reset) == 0 {
+				cmd.Printf("No autoscaling config to reset\n")
+				return nil
+			}
+
+			// validate the desired config as the remaining overrides may depend on the reset keys
+			if _, err = asconfig.NewConfigFromMap(desiredCm.Data); err != nil {
+				return fmt.Errorf("invalid autoscaling config after reset: %v", err)
+			}
+
+			err = utils.UpdateConfigMap(client, desiredCm)
+			if err != nil {
+				return fmt.Errorf("failed to update ConfigMap %s in namespace %s: %+v", configAutoscaler, knativeServing, err)
+			}
+			printResetKeys(cmd, currentCm, reset)
+			cmd.Printf("Reset %d Knative autoscaling config key(s) to default\n", len(reset))
+			return nil
+		},
+	}
+	AutoscalingResetCommand.Flags().BoolVar(&all, "all", false, "reset all keys of autoscaling config")
+	return AutoscalingResetCommand
+}
+
+// overriddenKeys returns the sorted keys overridden in the ConfigMap, the keys starting with '_' like '_example' are skipped
+func overriddenKeys(cm *corev1.ConfigMap) []string {
+	names := []string{}
+	for name := range cm.Data {
+		if !strings.HasPrefix(name, "_") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printResetKeys prints the values of the reset keys before and after reset, the values after reset are
+// the defaults of Knative Serving from parsing an empty config
+func printResetKeys(cmd *cobra.Command, currentCm *corev1.ConfigMap, names []string) {
+	defaults, _ := asconfig.NewConfigFromMap(map[string]string{})
+	// the current config may be rejected by the autoscaler, then the raw values are shown instead
+	current, _ := asconfig.NewConfigFromMap(currentCm.Data)
+	for _, name := range names {
+		before, after := currentCm.Data[name], "<unset>"
+		if key, ok := lookupConfigKey(name); ok {
+			if current != nil {
+				before = key.value(current)
+			}
+			after = key.value(defaults)
+		}
+		cmd.Printf("Key '%s' is reset: %s -> %s\n", name, before, after)
+	}
+}
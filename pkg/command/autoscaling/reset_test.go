@@ -0,0 +1,114 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+func newAutoscalerConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configAutoscaler,
+			Namespace: knativeServing,
+		},
+		Data: data,
+	}
+}
+
+func TestAutoscalingResetCommand(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newAutoscalerConfigMap(nil))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+
+		_, err := testutil.ExecuteCommand(NewAutoscalingResetCommand(p))
+		assert.ErrorContains(t, err, "'autoscaling reset' requires key(s) or --all")
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingResetCommand(p), "stable-window", "--all")
+		assert.ErrorContains(t, err, "please specify either key(s) or --all")
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingResetCommand(p), "unknown-key")
+		assert.ErrorContains(t, err, "unknown autoscaling config key 'unknown-key'")
+
+		p.InstallationMethod = pkg.InstallationMethodOperator
+		_, err = testutil.ExecuteCommand(NewAutoscalingResetCommand(p), "--all")
+		assert.ErrorContains(t, err, "Knative managed by operator is not supported yet")
+	})
+
+	t.Run("reset keys", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams(newAutoscalerConfigMap(map[string]string{
+			"stable-window":     "120s",
+			"max-scale-up-rate": "100",
+			"min-scale":         "1",
+		}))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+
+		output, err := testutil.ExecuteCommand(NewAutoscalingResetCommand(p), "stable-window", "max-scale-up-rate", "scale-down-delay")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Key 'scale-down-delay' is not overridden, skipped"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(output, "Key 'stable-window' is reset: 2m -> 1m"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(output, "Key 'max-scale-up-rate' is reset: 100.0 -> 1000.0"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(output, "Reset 2 Knative autoscaling config key(s) to default"), "unexpected output: %s", output)
+
+		cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, map[string]string{"min-scale": "1"}, cm.Data)
+
+		output, err = testutil.ExecuteCommand(NewAutoscalingResetCommand(p), "stable-window")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "No autoscaling config to reset"), "unexpected output: %s", output)
+	})
+
+	t.Run("reset all keys", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams(newAutoscalerConfigMap(map[string]string{
+			"_example":             "################################",
+			"enable-scale-to-zero": "false",
+			"new-upstream-key":     "on",
+		}))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+
+		output, err := testutil.ExecuteCommand(NewAutoscalingResetCommand(p), "--all")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Key 'enable-scale-to-zero' is reset: false -> true"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(output, "Key 'new-upstream-key' is reset: on -> <unset>"), "unexpected output: %s", output)
+
+		cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, map[string]string{"_example": "################################"}, cm.Data)
+	})
+
+	t.Run("return error if the remaining config is invalid", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams(newAutoscalerConfigMap(map[string]string{
+			"max-scale-limit": "5",
+			"max-scale":       "5",
+		}))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+
+		_, err := testutil.ExecuteCommand(NewAutoscalingResetCommand(p), "max-scale")
+		assert.ErrorContains(t, err, "invalid autoscaling config after reset: max-scale = 0, must be in [1, max-scale-limit(5)] range")
+
+		cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "5", cm.Data["max-scale"])
+	})
+}
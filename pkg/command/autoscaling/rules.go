@@ -0,0 +1,184 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"knative.dev/serving/pkg/apis/autoscaling"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	asconfig "knative.dev/serving/pkg/autoscaler/config"
+	"knative.dev/serving/pkg/autoscaler/config/autoscalerconfig"
+)
+
+// ruleSeverity is how a violated config rule is handled
+type ruleSeverity int
+
+const (
+	// ruleWarning is printed and the config is still updated
+	ruleWarning ruleSeverity = iota
+	// ruleError blocks the update unless it's forced
+	ruleError
+)
+
+// maxListedRevisions is the max number of the revisions listed in a violation
+const maxListedRevisions = 3
+
+// ruleInput is what the config rules are evaluated against
+type ruleInput struct {
+	config *autoscalerconfig.Config
+	// revisions are the revisions in the cluster, the rules on revisions are skipped if nil
+	revisions []servingv1.Revision
+}
+
+// configRule is a cross-field rule of autoscaling config, the combinations accepted by the
+// autoscaler but misbehaving at runtime are caught by the rules
+type configRule struct {
+	name     string
+	severity ruleSeverity
+	// check returns the explanation if the rule is violated, or an empty string
+	check func(in ruleInput) string
+}
+
+// ruleViolation is a violated config rule with the explanation
+type ruleViolation struct {
+	rule        string
+	severity    ruleSeverity
+	explanation string
+}
+
+func (v ruleViolation) String() string {
+	return fmt.Sprintf("[%s] %s", v.rule, v.explanation)
+}
+
+// configRules are the rules evaluated by autoscaling update
+var configRules = []configRule{
+	{
+		name:     "panic-window",
+		severity: ruleError,
+		check: func(in ruleInput) string {
+			window := time.Duration(float64(in.config.StableWindow) * in.config.PanicWindowPercentage / 100)
+			if window >= asconfig.BucketSize {
+				return ""
+			}
+			return fmt.Sprintf("panic window of %v (panic-window-percentage %v%% of stable-window %s) is shorter than the metric bucket of %s, "+
+				"the panic mode would react to a single scrape", window, in.config.PanicWindowPercentage, describeDuration(in.config.StableWindow), asconfig.BucketSize)
+		},
+	},
+	{
+		// the default min-scale above max-scale-limit is rejected by the autoscaler already, the annotations are only checked
+		// when the revisions are created or updated
+		name:     "min-scale-limit",
+		severity: ruleError,
+		check: func(in ruleInput) string {
+			if in.config.MaxScaleLimit <= 0 {
+				return ""
+			}
+			names := revisionsMatching(in.revisions, func(revision *servingv1.Revision) bool {
+				_, value, ok := autoscaling.MinScaleAnnotation.Get(revision.Annotations)
+				if !ok {
+					return false
+				}
+				minScale, err := strconv.ParseInt(value, 10, 32)
+				return err == nil && int32(minScale) > in.config.MaxScaleLimit
+			})
+			if len(names) == 0 {
+				return ""
+			}
+			return fmt.Sprintf("min-scale of revision(s) %s is greater than max-scale-limit %d, the services can't be updated anymore",
+				describeRevisions(names), in.config.MaxScaleLimit)
+		},
+	},
+	{
+		name:     "retention-period",
+		severity: ruleWarning,
+		check: func(in ruleInput) string {
+			if !in.config.EnableScaleToZero || in.config.ScaleToZeroPodRetentionPeriod <= in.config.ScaleToZeroGracePeriod {
+				return ""
+			}
+			return fmt.Sprintf("scale-to-zero-pod-retention-period %s is longer than scale-to-zero-grace-period %s, the last pod is kept for the retention period "+
+				"and the grace period has no effect", describeDuration(in.config.ScaleToZeroPodRetentionPeriod), describeDuration(in.config.ScaleToZeroGracePeriod))
+		},
+	},
+	{
+		name:     "burst-capacity",
+		severity: ruleWarning,
+		check: func(in ruleInput) string {
+			if !in.config.EnableScaleToZero || in.config.TargetBurstCapacity != 0 {
+				return ""
+			}
+			return "target-burst-capacity 0 with scale-to-zero enabled removes the activator from the request path once a pod is ready, " +
+				"so the requests during a burst are not buffered and may fail while new pods start"
+		},
+	},
+	{
+		name:     "hpa-metric",
+		severity: ruleError,
+		check: func(in ruleInput) string {
+			if in.config.PodAutoscalerClass != autoscaling.HPA {
+				return ""
+			}
+			// only the revisions without a class annotation follow the default class of the ConfigMap
+			names := revisionsMatching(in.revisions, func(revision *servingv1.Revision) bool {
+				_, _, hasClass := autoscaling.ClassAnnotation.Get(revision.Annotations)
+				_, metric, hasMetric := autoscaling.MetricAnnotation.Get(revision.Annotations)
+				return hasMetric && !hpaMetric(metric) && !hasClass
+			})
+			if len(names) == 0 {
+				return ""
+			}
+			return fmt.Sprintf("revision(s) %s scale on metrics other than %s and %s, which are not supported by class %s",
+				describeRevisions(names), autoscaling.CPU, autoscaling.Memory, autoscaling.HPA)
+		},
+	},
+}
+
+// evaluateRules returns the violated rules of the config
+func evaluateRules(in ruleInput) []ruleViolation {
+	violations := []ruleViolation{}
+	for _, rule := range configRules {
+		if explanation := rule.check(in); explanation != "" {
+			violations = append(violations, ruleViolation{rule: rule.name, severity: rule.severity, explanation: explanation})
+		}
+	}
+	return violations
+}
+
+// revisionsMatching returns 'namespace/name' of the revisions matching the predicate
+func revisionsMatching(revisions []servingv1.Revision, predicate func(*servingv1.Revision) bool) []string {
+	names := []string{}
+	for i := range revisions {
+		if predicate(&revisions[i]) {
+			names = append(names, revisions[i].Namespace+"/"+revisions[i].Name)
+		}
+	}
+	return names
+}
+
+// hpaMetric returns true if the metric is supported by the HPA class
+func hpaMetric(metric string) bool {
+	return metric == autoscaling.CPU || metric == autoscaling.Memory
+}
+
+// describeRevisions describes the revisions, only the first few revisions are listed
+func describeRevisions(names []string) string {
+	if len(names) <= maxListedRevisions {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:maxListedRevisions], ", "), len(names)-maxListedRevisions)
+}
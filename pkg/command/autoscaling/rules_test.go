@@ -0,0 +1,87 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	asconfig "knative.dev/serving/pkg/autoscaler/config"
+)
+
+// violatedRules returns the names of the violated rules of the config
+func violatedRules(t *testing.T, data map[string]string, revisions ...*servingv1.Revision) []string {
+	config, err := asconfig.NewConfigFromMap(data)
+	assert.NilError(t, err)
+	in := ruleInput{config: config}
+	for _, revision := range revisions {
+		in.revisions = append(in.revisions, *revision)
+	}
+	names := []string{}
+	for _, v := range evaluateRules(in) {
+		names = append(names, v.rule)
+	}
+	return names
+}
+
+func TestEvaluateRules(t *testing.T) {
+	hpa := map[string]string{"autoscaling.knative.dev/class": "hpa.autoscaling.knative.dev"}
+	rps := map[string]string{"autoscaling.knative.dev/metric": "rps"}
+
+	for _, tc := range []struct {
+		name      string
+		data      map[string]string
+		revisions []*servingv1.Revision
+		expected  []string
+	}{
+		{"defaults", nil, nil, []string{}},
+		{"panic window shorter than bucket", map[string]string{"stable-window": "60s", "panic-window-percentage": "1"}, nil, []string{"panic-window"}},
+		{"panic window of one bucket", map[string]string{"stable-window": "10s", "panic-window-percentage": "10"}, nil, []string{}},
+		{"min-scale of revision above max-scale-limit", map[string]string{"max-scale-limit": "5", "max-scale": "5"},
+			[]*servingv1.Revision{newTestRevision("hello-00001", map[string]string{"autoscaling.knative.dev/min-scale": "10"}, 0)}, []string{"min-scale-limit"}},
+		{"min-scale of revision without max-scale-limit", nil,
+			[]*servingv1.Revision{newTestRevision("hello-00001", map[string]string{"autoscaling.knative.dev/min-scale": "10"}, 0)}, []string{}},
+		{"retention period longer than grace period", map[string]string{"scale-to-zero-pod-retention-period": "5m"}, nil, []string{"retention-period"}},
+		{"retention period without scale-to-zero", map[string]string{"scale-to-zero-pod-retention-period": "5m", "enable-scale-to-zero": "false"}, nil, []string{}},
+		{"zero burst capacity with scale-to-zero", map[string]string{"target-burst-capacity": "0"}, nil, []string{"burst-capacity"}},
+		{"zero burst capacity without scale-to-zero", map[string]string{"target-burst-capacity": "0", "enable-scale-to-zero": "false"}, nil, []string{}},
+		{"hpa class with rps revision", map[string]string{"pod-autoscaler-class": "hpa.autoscaling.knative.dev"},
+			[]*servingv1.Revision{newTestRevision("hello-00001", rps, 0)}, []string{"hpa-metric"}},
+		{"hpa class with concurrency revision", map[string]string{"pod-autoscaler-class": "hpa.autoscaling.knative.dev"},
+			[]*servingv1.Revision{newTestRevision("hello-00001", map[string]string{"autoscaling.knative.dev/metric": "concurrency"}, 0)}, []string{"hpa-metric"}},
+		{"hpa class with memory revision", map[string]string{"pod-autoscaler-class": "hpa.autoscaling.knative.dev"},
+			[]*servingv1.Revision{newTestRevision("hello-00001", map[string]string{"autoscaling.knative.dev/metric": "memory"}, 0)}, []string{}},
+		{"hpa class with default metric revision", map[string]string{"pod-autoscaler-class": "hpa.autoscaling.knative.dev"},
+			[]*servingv1.Revision{newTestRevision("hello-00001", nil, 0)}, []string{}},
+		{"hpa class with kpa rps revision", map[string]string{"pod-autoscaler-class": "hpa.autoscaling.knative.dev"},
+			[]*servingv1.Revision{newTestRevision("hello-00001", map[string]string{"autoscaling.knative.dev/class": "kpa.autoscaling.knative.dev", "autoscaling.knative.dev/metric": "rps"}, 0)}, []string{}},
+		{"hpa class with hpa rps revision", map[string]string{"pod-autoscaler-class": "hpa.autoscaling.knative.dev"},
+			[]*servingv1.Revision{newTestRevision("hello-00001", map[string]string{"autoscaling.knative.dev/class": "hpa.autoscaling.knative.dev", "autoscaling.knative.dev/metric": "rps"}, 0)}, []string{}},
+		{"kpa class with hpa revision", nil, []*servingv1.Revision{newTestRevision("hello-00001", hpa, 0)}, []string{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.DeepEqual(t, tc.expected, violatedRules(t, tc.data, tc.revisions...))
+		})
+	}
+}
+
+func TestDescribeRevisions(t *testing.T) {
+	assert.Equal(t, "default/a, default/b", describeRevisions([]string{"default/a", "default/b"}))
+	names := []string{"default/a", "default/b", "default/c", "default/d", "default/e"}
+	assert.Equal(t, "default/a, default/b, default/c and 2 more", describeRevisions(names))
+	assert.Check(t, strings.HasPrefix(ruleViolation{rule: "hpa-metric", explanation: "x"}.String(), "[hpa-metric] "))
+}
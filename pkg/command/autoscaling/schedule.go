@@ -0,0 +1,272 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"knative.dev/kn-plugin-admin/pkg"
+)
+
+const (
+	// AdminAutoscalingScheduleCmdName is the manager of the resources created by autoscaling schedule commands,
+	// and the name of the ServiceAccount, Role and RoleBinding shared by the schedules
+	AdminAutoscalingScheduleCmdName = "kn-admin-autoscaling-schedule"
+	// scheduleLabel is the label of the schedule name on the CronJob
+	scheduleLabel = "kn-admin.knative.dev/autoscaling-schedule"
+	// cronJobPrefix is the prefix of the CronJob name of a schedule
+	cronJobPrefix = "kn-admin-autoscaling-"
+	// scheduleImageConfigKey is the key of the default kn admin image in the admin config file
+	scheduleImageConfigKey = "autoscaling.schedule.image"
+)
+
+// scheduleLabels are the labels of the resources created by autoscaling schedule commands
+var scheduleLabels = map[string]string{
+	pkg.LabelManagedBy: AdminAutoscalingScheduleCmdName,
+}
+
+// NewAutoscalingScheduleCommand represents autoscaling schedule command
+func NewAutoscalingScheduleCommand(p *pkg.AdminParams) *cobra.Command {
+	scheduleCmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Manage time-based autoscaling schedules",
+		Long: `Manage the schedules which update autoscaling config at specific times, e.g. enable scale-to-zero at night and disable it in the morning.
+Each schedule is a CronJob in namespace knative-serving running the kn admin image, the CronJobs share a ServiceAccount which is
+allowed to update ConfigMap config-autoscaler only`,
+	}
+	scheduleCmd.AddCommand(NewAutoscalingScheduleAddCommand(p))
+	scheduleCmd.AddCommand(NewAutoscalingScheduleListCommand(p))
+	scheduleCmd.AddCommand(NewAutoscalingScheduleRemoveCommand(p))
+	return scheduleCmd
+}
+
+// cronJobName returns the name of the CronJob of the schedule
+func cronJobName(name string) string {
+	return cronJobPrefix + name
+}
+
+// scheduleArgs returns the arguments of kn admin to apply the settings, the keys are sorted so the
+// CronJob is stable across updates
+func scheduleArgs(settings map[string]string, force bool) []string {
+	args := []string{"autoscaling", "update"}
+	for _, key := range sortedKeys(settings) {
+		args = append(args, "--set", key+"="+settings[key])
+	}
+	if force {
+		args = append(args, "--force")
+	}
+	return args
+}
+
+// scheduleSettings returns the settings applied by the CronJob of the schedule, in the form of key=value
+func scheduleSettings(cronJob *batchv1.CronJob) []string {
+	settings := []string{}
+	for _, c := range cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers {
+		for i := 0; i+1 < len(c.Args); i++ {
+			if c.Args[i] == "--set" {
+				settings = append(settings, c.Args[i+1])
+			}
+		}
+	}
+	return settings
+}
+
+// newScheduleCronJob returns the CronJob which applies the settings with kn admin on the cron schedule
+func newScheduleCronJob(name, cron, timeZone, image, preset string, settings map[string]string, force bool) *batchv1.CronJob {
+	labels := map[string]string{scheduleLabel: name}
+	for k, v := range scheduleLabels {
+		labels[k] = v
+	}
+	annotations := map[string]string{}
+	if preset != "" {
+		annotations[presetAnnotation] = preset
+	}
+	// a failed update is retried twice by the Job
+	backoffLimit := int32(2)
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cronJobName(name),
+			Namespace:   knativeServing,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:          cron,
+			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: batchv1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec: corev1.PodSpec{
+							ServiceAccountName: AdminAutoscalingScheduleCmdName,
+							RestartPolicy:      corev1.RestartPolicyNever,
+							Containers: []corev1.Container{{
+								Name:  "kn-admin",
+								Image: image,
+								Args:  scheduleArgs(settings, force),
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+	if timeZone != "" {
+		cronJob.Spec.TimeZone = &timeZone
+	}
+	return cronJob
+}
+
+// ensureScheduleRBAC creates the ServiceAccount, Role and RoleBinding shared by the schedules, or reconciles the existing ones,
+// the Role allows to read config-domain to detect the installation method and to update config-autoscaler
+func ensureScheduleRBAC(client kubernetes.Interface) error {
+	meta := metav1.ObjectMeta{
+		Name:      AdminAutoscalingScheduleCmdName,
+		Namespace: knativeServing,
+		Labels:    scheduleLabels,
+	}
+	sa := &corev1.ServiceAccount{ObjectMeta: meta}
+	if _, err := client.CoreV1().ServiceAccounts(knativeServing).Create(context.TODO(), sa, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create ServiceAccount '%s' in namespace '%s': %v", sa.Name, knativeServing, err)
+	}
+
+	role := &rbacv1.Role{
+		ObjectMeta: meta,
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"config-domain"}, Verbs: []string{"get"}},
+			{APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{configAutoscaler}, Verbs: []string{"get", "update"}},
+		},
+	}
+	if err := ensureScheduleRole(client, role); err != nil {
+		return err
+	}
+
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: meta,
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: role.Name},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: sa.Name, Namespace: knativeServing}},
+	}
+	return ensureScheduleRoleBinding(client, binding)
+}
+
+// ensureScheduleRole creates the Role, or updates its rules if the existing Role has drifted
+func ensureScheduleRole(client kubernetes.Interface, role *rbacv1.Role) error {
+	_, err := client.RbacV1().Roles(knativeServing).Create(context.TODO(), role, metav1.CreateOptions{})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Role '%s' in namespace '%s': %v", role.Name, knativeServing, err)
+	}
+	existing, err := client.RbacV1().Roles(knativeServing).Get(context.TODO(), role.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get Role '%s' in namespace '%s': %v", role.Name, knativeServing, err)
+	}
+	if equality.Semantic.DeepEqual(existing.Rules, role.Rules) {
+		return nil
+	}
+	desired := existing.DeepCopy()
+	desired.Rules = role.Rules
+	if _, err = client.RbacV1().Roles(knativeServing).Update(context.TODO(), desired, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update Role '%s' in namespace '%s': %v", role.Name, knativeServing, err)
+	}
+	return nil
+}
+
+// ensureScheduleRoleBinding creates the RoleBinding, or updates its subjects if the existing RoleBinding has drifted,
+// the RoleBinding is recreated if it refers to another role as the roleRef can't be changed
+func ensureScheduleRoleBinding(client kubernetes.Interface, binding *rbacv1.RoleBinding) error {
+	_, err := client.RbacV1().RoleBindings(knativeServing).Create(context.TODO(), binding, metav1.CreateOptions{})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create RoleBinding '%s' in namespace '%s': %v", binding.Name, knativeServing, err)
+	}
+	existing, err := client.RbacV1().RoleBindings(knativeServing).Get(context.TODO(), binding.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get RoleBinding '%s' in namespace '%s': %v", binding.Name, knativeServing, err)
+	}
+	if existing.RoleRef != binding.RoleRef {
+		if err = client.RbacV1().RoleBindings(knativeServing).Delete(context.TODO(), binding.Name, metav1.DeleteOptions{}); err != nil {
+			return fmt.Errorf("failed to delete RoleBinding '%s' in namespace '%s': %v", binding.Name, knativeServing, err)
+		}
+		if _, err = client.RbacV1().RoleBindings(knativeServing).Create(context.TODO(), binding, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create RoleBinding '%s' in namespace '%s': %v", binding.Name, knativeServing, err)
+		}
+		return nil
+	}
+	if equality.Semantic.DeepEqual(existing.Subjects, binding.Subjects) {
+		return nil
+	}
+	desired := existing.DeepCopy()
+	desired.Subjects = binding.Subjects
+	if _, err = client.RbacV1().RoleBindings(knativeServing).Update(context.TODO(), desired, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update RoleBinding '%s' in namespace '%s': %v", binding.Name, knativeServing, err)
+	}
+	return nil
+}
+
+// removeScheduleRBAC deletes the ServiceAccount, Role and RoleBinding shared by the schedules
+func removeScheduleRBAC(client kubernetes.Interface) error {
+	name := AdminAutoscalingScheduleCmdName
+	if err := client.RbacV1().RoleBindings(knativeServing).Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete RoleBinding '%s' in namespace '%s': %v", name, knativeServing, err)
+	}
+	if err := client.RbacV1().Roles(knativeServing).Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete Role '%s' in namespace '%s': %v", name, knativeServing, err)
+	}
+	if err := client.CoreV1().ServiceAccounts(knativeServing).Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete ServiceAccount '%s' in namespace '%s': %v", name, knativeServing, err)
+	}
+	return nil
+}
+
+// listScheduleCronJobs returns the CronJobs of the schedules sorted by name
+func listScheduleCronJobs(client kubernetes.Interface) (*batchv1.CronJobList, error) {
+	cronJobs, err := client.BatchV1().CronJobs(knativeServing).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(scheduleLabels).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CronJobs in namespace '%s': %v", knativeServing, err)
+	}
+	sort.Slice(cronJobs.Items, func(i, j int) bool {
+		return cronJobs.Items[i].Name < cronJobs.Items[j].Name
+	})
+	return cronJobs, nil
+}
+
+// describeList joins the values with commas, or returns '<none>' if there is no value
+func describeList(values []string) string {
+	if len(values) == 0 {
+		return "<none>"
+	}
+	return strings.Join(values, ",")
+}
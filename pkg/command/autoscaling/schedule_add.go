@@ -0,0 +1,188 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"knative.dev/kn-plugin-admin/pkg"
+	asconfig "knative.dev/serving/pkg/autoscaler/config"
+)
+
+// maxScheduleNameLength is the max length of the schedule name, the CronJob name is limited to 52 characters
+// so the names of the Jobs created by it fit in 63 characters
+const maxScheduleNameLength = 52 - len(cronJobPrefix)
+
+// NewAutoscalingScheduleAddCommand represents autoscaling schedule add command
+func NewAutoscalingScheduleAddCommand(p *pkg.AdminParams) *cobra.Command {
+	var (
+		cronSchedule string
+		timeZone     string
+		preset       string
+		settings     []string
+		image        string
+		force        bool
+	)
+	scheduleAddCmd := &cobra.Command{
+		Use:   "add [NAME]",
+		Short: "Add an autoscaling schedule",
+		Long: `Add a schedule which updates ConfigMap config-autoscaler with a preset or key=value settings on the cron schedule.
+The name of the schedule defaults to the preset name. The settings are validated and checked against the cross-field rules of
+'autoscaling update' when the schedule is added, so a blocking rule has to be overridden with --force here. The rules on
+revisions are only checked when the schedule is added, the CronJob is not allowed to list revisions and skips them.
+
+The CronJob runs 'kn admin autoscaling update' in the kn admin image, which is taken from --image or the key
+'` + scheduleImageConfigKey + `' in the admin config file. The values of the preset are resolved when the schedule
+is added and applied with --set, unlike 'autoscaling preset apply' the preset name is not recorded in the annotation
+'` + presetAnnotation + `' of ConfigMap config-autoscaler, and later changes of a user-defined preset are not picked
+up until the schedule is added again`,
+		Example: `
+  # To enable scale-to-zero with preset 'cost-saving' at 20:00 on weekdays
+  kn admin autoscaling schedule add night --cron '0 20 * * 1-5' --preset cost-saving --image <kn-admin-image>
+
+  # To keep 2 pods warm from 08:00 on weekdays in time zone 'Europe/Berlin'
+  kn admin autoscaling schedule add morning --cron '0 8 * * 1-5' --time-zone Europe/Berlin \
+    --set min-scale=2 --set enable-scale-to-zero=false`,
+		Args: cobra.MaximumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if cronSchedule == "" {
+				return errors.New("'autoscaling schedule add' requires --cron")
+			}
+			if _, err := cron.ParseStandard(cronSchedule); err != nil {
+				return fmt.Errorf("invalid --cron '%s': %v", cronSchedule, err)
+			}
+			if strings.Contains(cronSchedule, "TZ=") {
+				return errors.New("time zone in --cron is not supported, please use --time-zone")
+			}
+			if timeZone != "" {
+				if _, err := time.LoadLocation(timeZone); err != nil {
+					return fmt.Errorf("invalid --time-zone '%s': %v", timeZone, err)
+				}
+			}
+			if preset == "" && len(settings) == 0 {
+				return errors.New("'autoscaling schedule add' requires --preset or --set")
+			}
+			if len(args) == 0 && preset == "" {
+				return errors.New("'autoscaling schedule add' requires the schedule name when --preset is not specified")
+			}
+			return p.EnsureInstallMethodStandalone()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := preset
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+				return fmt.Errorf("invalid schedule name '%s': %s", name, strings.Join(errs, ", "))
+			}
+			if len(name) > maxScheduleNameLength {
+				return fmt.Errorf("invalid schedule name '%s': must be no more than %d characters", name, maxScheduleNameLength)
+			}
+			if image == "" {
+				image = viper.GetString(scheduleImageConfigKey)
+			}
+			if image == "" {
+				return fmt.Errorf("'autoscaling schedule add' requires --image or '%s' in the config file", scheduleImageConfigKey)
+			}
+
+			desired := map[string]string{}
+			if preset != "" {
+				presets, err := loadPresets()
+				if err != nil {
+					return err
+				}
+				values, ok := presets[preset]
+				if !ok {
+					return fmt.Errorf("unknown autoscaling preset '%s', available presets: %s", preset, strings.Join(sortedKeys(presets), ", "))
+				}
+				for key, value := range values {
+					desired[key] = value
+				}
+			}
+			for _, setting := range settings {
+				key, value, ok := strings.Cut(setting, "=")
+				key = strings.TrimSpace(key)
+				if !ok || key == "" {
+					return fmt.Errorf("invalid --set value '%s', expected key=value", setting)
+				}
+				if _, known := lookupConfigKey(key); !known {
+					cmd.PrintErrf("Warning: key '%s' is unknown to kn admin and is not validated\n", key)
+				}
+				desired[key] = value
+			}
+
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+			currentCm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get ConfigMaps: %+v", err)
+			}
+			data := map[string]string{}
+			for key, value := range currentCm.Data {
+				data[key] = value
+			}
+			for key, value := range desired {
+				data[key] = value
+			}
+			// the settings are validated against the current config, which may change before the schedule runs
+			config, err := asconfig.NewConfigFromMap(data)
+			if err != nil {
+				return fmt.Errorf("invalid autoscaling config of schedule '%s': %v", name, err)
+			}
+			if err = checkRules(cmd, p, config, force); err != nil {
+				return err
+			}
+
+			cronJob := newScheduleCronJob(name, cronSchedule, timeZone, image, preset, desired, force)
+			_, err = client.BatchV1().CronJobs(knativeServing).Get(context.TODO(), cronJob.Name, metav1.GetOptions{})
+			switch {
+			case err == nil:
+				return fmt.Errorf("autoscaling schedule '%s' already exists, please remove it first", name)
+			case !apierrors.IsNotFound(err):
+				return fmt.Errorf("failed to get CronJob '%s' in namespace '%s': %v", cronJob.Name, knativeServing, err)
+			}
+
+			if err = ensureScheduleRBAC(client); err != nil {
+				return err
+			}
+			if _, err = client.BatchV1().CronJobs(knativeServing).Create(context.TODO(), cronJob, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("failed to create CronJob '%s' in namespace '%s': %v", cronJob.Name, knativeServing, err)
+			}
+			cmd.Printf("Autoscaling schedule '%s' is added, CronJob '%s' in namespace '%s' applies %s on '%s'\n",
+				name, cronJob.Name, knativeServing, describeList(scheduleSettings(cronJob)), cronSchedule)
+			return nil
+		},
+	}
+	scheduleAddCmd.Flags().StringVar(&cronSchedule, "cron", "", "cron schedule of the update, e.g. '0 20 * * 1-5'")
+	scheduleAddCmd.Flags().StringVar(&timeZone, "time-zone", "", "time zone of the cron schedule, e.g. 'Europe/Berlin', the time zone of kube-controller-manager is used if not set")
+	scheduleAddCmd.Flags().StringVar(&preset, "preset", "", "autoscaling preset to apply, see 'kn admin autoscaling preset --help'")
+	scheduleAddCmd.Flags().StringArrayVar(&settings, "set", nil, "set any key of config-autoscaler in the form of key=value, it can be specified multiple times and overrides the preset")
+	scheduleAddCmd.Flags().StringVar(&image, "image", "", "kn admin image run by the CronJob, the key '"+scheduleImageConfigKey+"' in the config file is used if not set")
+	scheduleAddCmd.Flags().BoolVar(&force, "force", false, "add the schedule even if the settings violate the blocking rules")
+	return scheduleAddCmd
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+func TestAutoscalingScheduleAddCommand(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newAutoscalerConfigMap(nil))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+
+		for _, tc := range []struct {
+			args []string
+			err  string
+		}{
+			{[]string{"night", "--preset", "cost-saving"}, "'autoscaling schedule add' requires --cron"},
+			{[]string{"night", "--cron", "0 25 * * *", "--preset", "cost-saving"}, "invalid --cron '0 25 * * *'"},
+			{[]string{"night", "--cron", "TZ=UTC 0 20 * * *", "--preset", "cost-saving"}, "time zone in --cron is not supported, please use --time-zone"},
+			{[]string{"night", "--cron", "0 20 * * *", "--time-zone", "Mars/Olympus", "--preset", "cost-saving"}, "invalid --time-zone 'Mars/Olympus'"},
+			{[]string{"night", "--cron", "0 20 * * *"}, "'autoscaling schedule add' requires --preset or --set"},
+			{[]string{"--cron", "0 20 * * *", "--set", "min-scale=0"}, "requires the schedule name when --preset is not specified"},
+			{[]string{"Night", "--cron", "0 20 * * *", "--preset", "cost-saving", "--image", "kn-admin:test"}, "invalid schedule name 'Night'"},
+			{[]string{strings.Repeat("n", 32), "--cron", "0 20 * * *", "--preset", "cost-saving", "--image", "kn-admin:test"}, "must be no more than 31 characters"},
+			{[]string{"night", "--cron", "0 20 * * *", "--preset", "cost-saving"}, "requires --image or 'autoscaling.schedule.image' in the config file"},
+			{[]string{"--cron", "0 20 * * *", "--preset", "unknown", "--image", "kn-admin:test"}, "unknown autoscaling preset 'unknown'"},
+			{[]string{"night", "--cron", "0 20 * * *", "--set", "min-scale", "--image", "kn-admin:test"}, "invalid --set value 'min-scale', expected key=value"},
+			{[]string{"night", "--cron", "0 20 * * *", "--set", "stable-window=1s", "--image", "kn-admin:test"}, "invalid autoscaling config of schedule 'night'"},
+			{[]string{"night", "--cron", "0 20 * * *", "--set", "panic-window-percentage=1", "--image", "kn-admin:test"}, "[panic-window]"},
+		} {
+			_, err := testutil.ExecuteCommand(NewAutoscalingScheduleAddCommand(p), tc.args...)
+			assert.ErrorContains(t, err, tc.err, "args: %v", tc.args)
+		}
+
+		p.InstallationMethod = pkg.InstallationMethodOperator
+		_, err := testutil.ExecuteCommand(NewAutoscalingScheduleAddCommand(p), "--cron", "0 20 * * *", "--preset", "cost-saving")
+		assert.ErrorContains(t, err, "Knative managed by operator is not supported yet")
+	})
+
+	t.Run("add schedule with preset", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams(newAutoscalerConfigMap(nil))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		viper.Set(scheduleImageConfigKey, "kn-admin:config")
+		defer viper.Reset()
+
+		output, err := testutil.ExecuteCommand(NewAutoscalingScheduleAddCommand(p), "--cron", "0 20 * * 1-5", "--preset", "cost-saving", "--set", "min-scale=1")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Autoscaling schedule 'cost-saving' is added, CronJob 'kn-admin-autoscaling-cost-saving' in namespace 'knative-serving' applies"), "unexpected output: %s", output)
+
+		cronJob, err := client.BatchV1().CronJobs(knativeServing).Get(context.TODO(), "kn-admin-autoscaling-cost-saving", metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "cost-saving", cronJob.Annotations[presetAnnotation])
+		assert.Equal(t, "kn-admin:config", cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image)
+		settings := scheduleSettings(cronJob)
+		assert.Equal(t, len(builtinPresets["cost-saving"]), len(settings))
+		assert.Check(t, strings.Contains(strings.Join(settings, ","), "min-scale=1"), "--set should override the preset: %v", settings)
+
+		_, err = client.CoreV1().ServiceAccounts(knativeServing).Get(context.TODO(), AdminAutoscalingScheduleCmdName, metav1.GetOptions{})
+		assert.NilError(t, err)
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingScheduleAddCommand(p), "--cron", "0 20 * * *", "--preset", "cost-saving")
+		assert.ErrorContains(t, err, "autoscaling schedule 'cost-saving' already exists, please remove it first")
+	})
+
+	t.Run("add schedule with settings and force", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams(newAutoscalerConfigMap(nil))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+
+		output, err := testutil.ExecuteCommand(NewAutoscalingScheduleAddCommand(p), "burst", "--cron", "0 8 * * *", "--time-zone", "Europe/Berlin",
+			"--set", "panic-window-percentage=1", "--image", "kn-admin:test", "--force")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Warning: [panic-window]"), "unexpected output: %s", output)
+
+		cronJob, err := client.BatchV1().CronJobs(knativeServing).Get(context.TODO(), "kn-admin-autoscaling-burst", metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "Europe/Berlin", *cronJob.Spec.TimeZone)
+		assert.DeepEqual(t, []string{"autoscaling", "update", "--set", "panic-window-percentage=1", "--force"}, cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Args)
+	})
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"strconv"
+
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1beta1 "k8s.io/apimachinery/pkg/apis/meta/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"knative.dev/client/pkg/commands"
+	"knative.dev/client/pkg/commands/flags"
+	hprinters "knative.dev/client/pkg/printers"
+	"knative.dev/kn-plugin-admin/pkg"
+)
+
+// scheduleColumnDefinitions are the columns of autoscaling schedule list command
+var scheduleColumnDefinitions = []metav1beta1.TableColumnDefinition{
+	{Name: "Name", Type: "string", Description: "Name of the autoscaling schedule.", Priority: 1},
+	{Name: "Cron", Type: "string", Description: "Cron schedule of the update.", Priority: 1},
+	{Name: "Time Zone", Type: "string", Description: "Time zone of the cron schedule.", Priority: 1},
+	{Name: "Preset", Type: "string", Description: "Autoscaling preset applied by the schedule.", Priority: 1},
+	{Name: "Settings", Type: "string", Description: "Keys of config-autoscaler updated by the schedule.", Priority: 1},
+	{Name: "Suspended", Type: "boolean", Description: "Whether the CronJob of the schedule is suspended.", Priority: 1},
+	{Name: "Last Schedule", Type: "string", Description: "Time since the last run of the schedule.", Priority: 1},
+	{Name: "Last Success", Type: "string", Description: "Time since the last successful run of the schedule.", Priority: 1},
+}
+
+// AutoscalingScheduleListHandlers adds print handlers for autoscaling schedule list command
+func AutoscalingScheduleListHandlers(h hprinters.PrintHandler) {
+	h.TableHandler(scheduleColumnDefinitions, func(cronJobs *batchv1.CronJobList, options hprinters.PrintOptions) ([]metav1beta1.TableRow, error) {
+		rows := make([]metav1beta1.TableRow, 0, len(cronJobs.Items))
+		for i := range cronJobs.Items {
+			cronJob := &cronJobs.Items[i]
+			timeZone, preset := "<default>", cronJob.Annotations[presetAnnotation]
+			if cronJob.Spec.TimeZone != nil {
+				timeZone = *cronJob.Spec.TimeZone
+			}
+			if preset == "" {
+				preset = "<none>"
+			}
+			suspended := cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend
+			row := metav1beta1.TableRow{Object: runtime.RawExtension{Object: cronJob}}
+			row.Cells = append(row.Cells,
+				cronJob.Labels[scheduleLabel],
+				cronJob.Spec.Schedule,
+				timeZone,
+				preset,
+				describeList(scheduleSettings(cronJob)),
+				strconv.FormatBool(suspended),
+				describeSince(cronJob.Status.LastScheduleTime),
+				describeSince(cronJob.Status.LastSuccessfulTime))
+			rows = append(rows, row)
+		}
+		return rows, nil
+	})
+}
+
+// describeSince describes the time since the timestamp, '<never>' if it's not set
+func describeSince(t *metav1.Time) string {
+	if t == nil {
+		return "<never>"
+	}
+	return commands.TranslateTimestampSince(*t)
+}
+
+// NewAutoscalingScheduleListCommand represents autoscaling schedule list command
+func NewAutoscalingScheduleListCommand(p *pkg.AdminParams) *cobra.Command {
+	scheduleListFlags := flags.NewListPrintFlags(AutoscalingScheduleListHandlers)
+	scheduleListCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List autoscaling schedules",
+		Long:    `List autoscaling schedules with the cron schedule, the applied preset and settings, and when the schedule last ran`,
+		Example: `
+  # To list autoscaling schedules
+  kn admin autoscaling schedule list`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+			cronJobs, err := listScheduleCronJobs(client)
+			if err != nil {
+				return err
+			}
+			if len(cronJobs.Items) == 0 {
+				cmd.Println("No autoscaling schedule found")
+				return nil
+			}
+			return scheduleListFlags.Print(cronJobs, cmd.OutOrStdout())
+		},
+	}
+	scheduleListFlags.HumanReadableFlags.AddFlags(scheduleListCmd)
+	scheduleListFlags.GenericPrintFlags.OutputFlagSpecified = func() bool {
+		return false
+	}
+	return scheduleListCmd
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+func TestAutoscalingScheduleListCommand(t *testing.T) {
+	t.Run("no schedule", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams()
+		output, err := testutil.ExecuteCommand(NewAutoscalingScheduleListCommand(p))
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "No autoscaling schedule found"), "unexpected output: %s", output)
+	})
+
+	t.Run("list schedules", func(t *testing.T) {
+		night := newScheduleCronJob("night", "0 20 * * 1-5", "Europe/Berlin", "kn-admin:test", "cost-saving", map[string]string{"min-scale": "0"}, false)
+		lastRun := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+		night.Status.LastScheduleTime, night.Status.LastSuccessfulTime = &lastRun, &lastRun
+		morning := newScheduleCronJob("morning", "0 8 * * 1-5", "", "kn-admin:test", "", map[string]string{"min-scale": "2", "enable-scale-to-zero": "false"}, false)
+		suspend := true
+		morning.Spec.Suspend = &suspend
+
+		p, _ := testutil.NewTestAdminParams(night, morning)
+		output, err := testutil.ExecuteCommand(NewAutoscalingScheduleListCommand(p))
+		assert.NilError(t, err)
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		assert.Equal(t, 3, len(lines), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[:1], "NAME", "CRON", "TIME ZONE", "PRESET", "SETTINGS", "SUSPENDED", "LAST SCHEDULE", "LAST SUCCESS"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[1:2], "morning", "0 8 * * 1-5", "<default>", "<none>", "enable-scale-to-zero=false,min-scale=2", "true", "<never>"), "unexpected output: %s", output)
+		assert.Check(t, containsLine(lines[2:3], "night", "0 20 * * 1-5", "Europe/Berlin", "cost-saving", "min-scale=0", "false", "120m"), "unexpected output: %s", output)
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"knative.dev/kn-plugin-admin/pkg"
+)
+
+// NewAutoscalingScheduleRemoveCommand represents autoscaling schedule remove command
+func NewAutoscalingScheduleRemoveCommand(p *pkg.AdminParams) *cobra.Command {
+	var all bool
+	scheduleRemoveCmd := &cobra.Command{
+		Use:     "remove [NAME...]",
+		Aliases: []string{"rm"},
+		Short:   "Remove autoscaling schedules",
+		Long: `Remove autoscaling schedules by deleting their CronJobs, the autoscaling config applied by the schedules is kept.
+The ServiceAccount, Role and RoleBinding shared by the schedules are deleted with the last schedule`,
+		Example: `
+  # To remove schedule 'night'
+  kn admin autoscaling schedule remove night
+
+  # To remove all autoscaling schedules
+  kn admin autoscaling schedule remove --all`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if all && len(args) > 0 {
+				return errors.New("please specify either schedule name(s) or --all")
+			}
+			if !all && len(args) == 0 {
+				return errors.New("'autoscaling schedule remove' requires schedule name(s) or --all")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+
+			names := args
+			if all {
+				cronJobs, err := listScheduleCronJobs(client)
+				if err != nil {
+					return err
+				}
+				names = make([]string, 0, len(cronJobs.Items))
+				for _, cronJob := range cronJobs.Items {
+					names = append(names, cronJob.Labels[scheduleLabel])
+				}
+			}
+
+			for _, name := range names {
+				cronJob, err := client.BatchV1().CronJobs(knativeServing).Get(context.TODO(), cronJobName(name), metav1.GetOptions{})
+				if apierrors.IsNotFound(err) || (err == nil && cronJob.Labels[scheduleLabel] != name) {
+					return fmt.Errorf("autoscaling schedule '%s' not found", name)
+				}
+				if err != nil {
+					return fmt.Errorf("failed to get CronJob '%s' in namespace '%s': %v", cronJobName(name), knativeServing, err)
+				}
+				// the Jobs created by the CronJob are deleted in background as well
+				propagation := metav1.DeletePropagationBackground
+				err = client.BatchV1().CronJobs(knativeServing).Delete(context.TODO(), cronJob.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+				if err != nil {
+					return fmt.Errorf("failed to delete CronJob '%s' in namespace '%s': %v", cronJob.Name, knativeServing, err)
+				}
+				cmd.Printf("Autoscaling schedule '%s' is removed\n", name)
+			}
+
+			remaining, err := listScheduleCronJobs(client)
+			if err != nil {
+				return err
+			}
+			if len(remaining.Items) == 0 {
+				return removeScheduleRBAC(client)
+			}
+			return nil
+		},
+	}
+	scheduleRemoveCmd.Flags().BoolVar(&all, "all", false, "remove all autoscaling schedules")
+	return scheduleRemoveCmd
+}
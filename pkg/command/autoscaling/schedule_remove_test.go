@@ -0,0 +1,65 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+func TestAutoscalingScheduleRemoveCommand(t *testing.T) {
+	night := newScheduleCronJob("night", "0 20 * * 1-5", "", "kn-admin:test", "cost-saving", map[string]string{"min-scale": "0"}, false)
+	morning := newScheduleCronJob("morning", "0 8 * * 1-5", "", "kn-admin:test", "", map[string]string{"min-scale": "2"}, false)
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams()
+		_, err := testutil.ExecuteCommand(NewAutoscalingScheduleRemoveCommand(p))
+		assert.ErrorContains(t, err, "'autoscaling schedule remove' requires schedule name(s) or --all")
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingScheduleRemoveCommand(p), "night", "--all")
+		assert.ErrorContains(t, err, "please specify either schedule name(s) or --all")
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingScheduleRemoveCommand(p), "night")
+		assert.ErrorContains(t, err, "autoscaling schedule 'night' not found")
+	})
+
+	t.Run("remove schedules", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams(night.DeepCopy(), morning.DeepCopy())
+		assert.NilError(t, ensureScheduleRBAC(client))
+
+		output, err := testutil.ExecuteCommand(NewAutoscalingScheduleRemoveCommand(p), "night")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Autoscaling schedule 'night' is removed"), "unexpected output: %s", output)
+		_, err = client.BatchV1().CronJobs(knativeServing).Get(context.TODO(), "kn-admin-autoscaling-night", metav1.GetOptions{})
+		assert.Check(t, err != nil, "CronJob of schedule 'night' should be deleted")
+		// the RBAC resources are kept for the remaining schedule
+		_, err = client.CoreV1().ServiceAccounts(knativeServing).Get(context.TODO(), AdminAutoscalingScheduleCmdName, metav1.GetOptions{})
+		assert.NilError(t, err)
+
+		output, err = testutil.ExecuteCommand(NewAutoscalingScheduleRemoveCommand(p), "--all")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Autoscaling schedule 'morning' is removed"), "unexpected output: %s", output)
+		_, err = client.CoreV1().ServiceAccounts(knativeServing).Get(context.TODO(), AdminAutoscalingScheduleCmdName, metav1.GetOptions{})
+		assert.Check(t, err != nil, "ServiceAccount should be deleted with the last schedule")
+		_, err = client.RbacV1().Roles(knativeServing).Get(context.TODO(), AdminAutoscalingScheduleCmdName, metav1.GetOptions{})
+		assert.Check(t, err != nil, "Role should be deleted with the last schedule")
+	})
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"knative.dev/kn-plugin-admin/pkg"
+)
+
+func TestNewScheduleCronJob(t *testing.T) {
+	cronJob := newScheduleCronJob("night", "0 20 * * 1-5", "Europe/Berlin", "kn-admin:test", "cost-saving",
+		map[string]string{"min-scale": "0", "enable-scale-to-zero": "true"}, true)
+	assert.Equal(t, "kn-admin-autoscaling-night", cronJob.Name)
+	assert.Equal(t, knativeServing, cronJob.Namespace)
+	assert.Equal(t, "night", cronJob.Labels[scheduleLabel])
+	assert.Equal(t, AdminAutoscalingScheduleCmdName, cronJob.Labels[pkg.LabelManagedBy])
+	assert.Equal(t, "cost-saving", cronJob.Annotations[presetAnnotation])
+	assert.Equal(t, "0 20 * * 1-5", cronJob.Spec.Schedule)
+	assert.Equal(t, "Europe/Berlin", *cronJob.Spec.TimeZone)
+	assert.Equal(t, batchv1.ForbidConcurrent, cronJob.Spec.ConcurrencyPolicy)
+
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	assert.Equal(t, AdminAutoscalingScheduleCmdName, podSpec.ServiceAccountName)
+	assert.Equal(t, corev1.RestartPolicyNever, podSpec.RestartPolicy)
+	assert.Equal(t, 1, len(podSpec.Containers))
+	assert.Equal(t, "kn-admin:test", podSpec.Containers[0].Image)
+	assert.DeepEqual(t, []string{"autoscaling", "update", "--set", "enable-scale-to-zero=true", "--set", "min-scale=0", "--force"}, podSpec.Containers[0].Args)
+	assert.DeepEqual(t, []string{"enable-scale-to-zero=true", "min-scale=0"}, scheduleSettings(cronJob))
+
+	cronJob = newScheduleCronJob("morning", "0 8 * * *", "", "kn-admin:test", "", map[string]string{"min-scale": "2"}, false)
+	assert.Check(t, cronJob.Spec.TimeZone == nil)
+	assert.Equal(t, "", cronJob.Annotations[presetAnnotation])
+	assert.DeepEqual(t, []string{"autoscaling", "update", "--set", "min-scale=2"}, cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Args)
+}
+
+func TestScheduleRBAC(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	assert.NilError(t, ensureScheduleRBAC(client))
+	// creating the RBAC resources again is a no-op
+	assert.NilError(t, ensureScheduleRBAC(client))
+
+	_, err := client.CoreV1().ServiceAccounts(knativeServing).Get(context.TODO(), AdminAutoscalingScheduleCmdName, metav1.GetOptions{})
+	assert.NilError(t, err)
+	role, err := client.RbacV1().Roles(knativeServing).Get(context.TODO(), AdminAutoscalingScheduleCmdName, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, 2, len(role.Rules))
+	assert.DeepEqual(t, []string{configAutoscaler}, role.Rules[1].ResourceNames)
+	assert.DeepEqual(t, []string{"get", "update"}, role.Rules[1].Verbs)
+	binding, err := client.RbacV1().RoleBindings(knativeServing).Get(context.TODO(), AdminAutoscalingScheduleCmdName, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, AdminAutoscalingScheduleCmdName, binding.Subjects[0].Name)
+	assert.Equal(t, AdminAutoscalingScheduleCmdName, binding.RoleRef.Name)
+
+	assert.NilError(t, removeScheduleRBAC(client))
+	// removing the RBAC resources again is a no-op
+	assert.NilError(t, removeScheduleRBAC(client))
+	_, err = client.CoreV1().ServiceAccounts(knativeServing).Get(context.TODO(), AdminAutoscalingScheduleCmdName, metav1.GetOptions{})
+	assert.Check(t, err != nil)
+}
+
+func TestScheduleRBACDrifted(t *testing.T) {
+	meta := metav1.ObjectMeta{Name: AdminAutoscalingScheduleCmdName, Namespace: knativeServing}
+	client := k8sfake.NewSimpleClientset(
+		&rbacv1.Role{
+			ObjectMeta: meta,
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{configAutoscaler}, Verbs: []string{"get"}},
+			},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: meta,
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "view"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "default", Namespace: knativeServing}},
+		},
+	)
+	assert.NilError(t, ensureScheduleRBAC(client))
+
+	role, err := client.RbacV1().Roles(knativeServing).Get(context.TODO(), AdminAutoscalingScheduleCmdName, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, 2, len(role.Rules))
+	assert.DeepEqual(t, []string{"get", "update"}, role.Rules[1].Verbs)
+	binding, err := client.RbacV1().RoleBindings(knativeServing).Get(context.TODO(), AdminAutoscalingScheduleCmdName, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: AdminAutoscalingScheduleCmdName}, binding.RoleRef)
+	assert.DeepEqual(t, []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: AdminAutoscalingScheduleCmdName, Namespace: knativeServing}}, binding.Subjects)
+}
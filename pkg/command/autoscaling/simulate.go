@@ -0,0 +1,195 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"knative.dev/client/pkg/printers"
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/serving/pkg/apis/autoscaling"
+	asconfig "knative.dev/serving/pkg/autoscaler/config"
+)
+
+// NewAutoscalingSimulateCommand represents autoscaling simulate command
+func NewAutoscalingSimulateCommand(p *pkg.AdminParams) *cobra.Command {
+	var (
+		traceFile         string
+		configFile        string
+		metric            string
+		target            float64
+		targetUtilization float64
+		podStartupTime    time.Duration
+	)
+	simulateCmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Simulate the autoscaling of recorded traffic",
+		Long: `Replay recorded traffic of a revision with the stable and panic mode of Knative Pod Autoscaler (KPA) offline, and show
+the predicted number of pods over time, the number of cold starts and the time spent in panic mode.
+
+The trace is a CSV file of 'time,value' records, the time is the seconds or a duration from the start of the trace, or an
+RFC3339 timestamp, and the value is the total concurrency or RPS of the revision at the time. The autoscaling config is
+taken from ConfigMap config-autoscaler in the cluster, or from a candidate config file which is either a ConfigMap or a map of
+config-autoscaler keys. The simulation starts with the initial scale of the config and assumes every new pod becomes ready
+after the pod startup time`,
+		Example: `
+  # To simulate the traffic with the autoscaling config in the cluster
+  kn admin autoscaling simulate --trace traffic.csv
+
+  # To simulate the RPS traffic with a candidate autoscaling config offline
+  kn admin autoscaling simulate --trace traffic.csv --metric rps --autoscaler-config candidate.yaml`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if traceFile == "" {
+				return errors.New("'autoscaling simulate' requires --trace")
+			}
+			if metric != autoscaling.Concurrency && metric != autoscaling.RPS {
+				return fmt.Errorf("invalid --metric '%s', must be '%s' or '%s'", metric, autoscaling.Concurrency, autoscaling.RPS)
+			}
+			if cmd.Flags().Changed("target") && target < autoscaling.TargetMin {
+				return fmt.Errorf("--target must be at least %v", autoscaling.TargetMin)
+			}
+			if cmd.Flags().Changed("target-utilization-percentage") && (targetUtilization < 1 || targetUtilization > 100) {
+				return errors.New("--target-utilization-percentage must be in [1, 100]")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(traceFile)
+			if err != nil {
+				return fmt.Errorf("failed to open trace file: %v", err)
+			}
+			defer f.Close()
+			samples, err := parseTrace(f)
+			if err != nil {
+				return err
+			}
+
+			var data map[string]string
+			if configFile != "" {
+				data, err = readAutoscalerConfigFile(configFile)
+			} else {
+				data, err = readLiveAutoscalerConfig(p)
+			}
+			if err != nil {
+				return err
+			}
+			config, err := asconfig.NewConfigFromMap(data)
+			if err != nil {
+				return fmt.Errorf("invalid autoscaling config: %v", err)
+			}
+
+			spec := simulationSpec{
+				config:         config,
+				target:         config.ContainerConcurrencyTargetDefault,
+				utilization:    config.ContainerConcurrencyTargetFraction,
+				podStartupTime: podStartupTime,
+			}
+			if metric == autoscaling.RPS {
+				spec.target, spec.utilization = config.RPSTargetDefault, config.TargetUtilization
+			}
+			if cmd.Flags().Changed("target") {
+				spec.target = target
+			}
+			if cmd.Flags().Changed("target-utilization-percentage") {
+				spec.utilization = targetUtilization / 100
+			}
+
+			return printSimulation(cmd, spec, simulate(spec, samples))
+		},
+	}
+	simulateCmd.Flags().StringVar(&traceFile, "trace", "", "CSV file of the recorded traffic in 'time,value' records")
+	simulateCmd.Flags().StringVar(&configFile, "autoscaler-config", "", "candidate autoscaling config file, ConfigMap config-autoscaler in the cluster is used if not set")
+	simulateCmd.Flags().StringVar(&metric, "metric", autoscaling.Concurrency, "metric of the traffic in the trace, 'concurrency' or 'rps'")
+	simulateCmd.Flags().Float64Var(&target, "target", 0, "target per pod, the default target of the metric in the autoscaling config is used if not set")
+	simulateCmd.Flags().Float64Var(&targetUtilization, "target-utilization-percentage", 0, "target utilization percentage, the value in the autoscaling config is used if not set")
+	simulateCmd.Flags().DurationVar(&podStartupTime, "pod-startup-time", 5*time.Second, "time for a new pod to become ready")
+	return simulateCmd
+}
+
+// readAutoscalerConfigFile reads the autoscaling config from a ConfigMap or a map of config-autoscaler keys
+func readAutoscalerConfigFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read autoscaling config file: %v", err)
+	}
+	cm := struct {
+		Kind string            `yaml:"kind"`
+		Data map[string]string `yaml:"data"`
+	}{}
+	if err := yaml.Unmarshal(content, &cm); err == nil && cm.Kind == "ConfigMap" {
+		return cm.Data, nil
+	}
+	data := map[string]string{}
+	if err := yaml.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse autoscaling config file: %v", err)
+	}
+	return data, nil
+}
+
+// readLiveAutoscalerConfig reads the autoscaling config from ConfigMap config-autoscaler in the cluster
+func readLiveAutoscalerConfig(p *pkg.AdminParams) (map[string]string, error) {
+	client, err := p.NewKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ConfigMaps: %+v", err)
+	}
+	return cm.Data, nil
+}
+
+// printSimulation prints the ticks when the desired scale, the ready pods or the mode changes, and the summary
+func printSimulation(cmd *cobra.Command, spec simulationSpec, result simulationResult) error {
+	tw := printers.NewPrefixWriter(cmd.OutOrStdout())
+	tw.WriteColsLn("TIME", "OBSERVED", "STABLE", "PANIC", "DESIRED", "READY", "MODE")
+	for i, t := range result.ticks {
+		if i > 0 && i < len(result.ticks)-1 {
+			prev := result.ticks[i-1]
+			if prev.desired == t.desired && prev.ready == t.ready && prev.panicking == t.panicking {
+				continue
+			}
+		}
+		mode := "Stable"
+		if t.panicking {
+			mode = "Panic"
+		}
+		tw.WriteColsLn(describeDuration(t.at), fmt.Sprintf("%.1f", t.observed), fmt.Sprintf("%.1f", t.stable), fmt.Sprintf("%.1f", t.panic),
+			strconv.Itoa(int(t.desired)), strconv.Itoa(int(t.ready)), mode)
+	}
+	tw.WriteLine()
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	duration := time.Duration(len(result.ticks)) * asconfig.BucketSize
+	dw := printers.NewPrefixWriter(cmd.OutOrStdout())
+	dw.WriteAttribute("Duration", describeDuration(duration))
+	dw.WriteAttribute("Target Per Pod", fmt.Sprintf("%.2f", spec.target*spec.utilization))
+	dw.WriteAttribute("Max Pods", strconv.Itoa(int(result.maxPods)))
+	dw.WriteAttribute("Average Pods", fmt.Sprintf("%.1f", result.podSeconds/duration.Seconds()))
+	dw.WriteAttribute("Cold Starts", strconv.Itoa(result.coldStarts))
+	dw.WriteAttribute("Time In Panic Mode", describeDuration(result.panicTime))
+	return dw.Flush()
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+const testTrace = `time,concurrency
+0,10
+60,100
+120,10
+300,10
+`
+
+// writeTestFile writes the content to a file in a temporary directory and returns the path
+func writeTestFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NilError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestAutoscalingSimulateCommand(t *testing.T) {
+	trace := writeTestFile(t, "trace.csv", testTrace)
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newAutoscalerConfigMap(nil))
+
+		_, err := testutil.ExecuteCommand(NewAutoscalingSimulateCommand(p))
+		assert.ErrorContains(t, err, "'autoscaling simulate' requires --trace")
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingSimulateCommand(p), "--trace", trace, "--metric", "cpu")
+		assert.ErrorContains(t, err, "invalid --metric 'cpu', must be 'concurrency' or 'rps'")
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingSimulateCommand(p), "--trace", trace, "--target", "0")
+		assert.ErrorContains(t, err, "--target must be at least")
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingSimulateCommand(p), "--trace", trace, "--target-utilization-percentage", "120")
+		assert.ErrorContains(t, err, "--target-utilization-percentage must be in [1, 100]")
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingSimulateCommand(p), "--trace", filepath.Join(t.TempDir(), "missing.csv"))
+		assert.ErrorContains(t, err, "failed to open trace file")
+	})
+
+	t.Run("live autoscaling config", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newAutoscalerConfigMap(map[string]string{"container-concurrency-target-default": "10"}))
+
+		output, err := testutil.ExecuteCommand(NewAutoscalingSimulateCommand(p), "--trace", trace)
+		assert.NilError(t, err)
+		for _, line := range []string{
+			"TIME", "OBSERVED", "DESIRED", "READY", "MODE",
+			"Panic",
+			"Duration:",
+			"Target Per Pod:      7.00",
+			"Cold Starts:         0",
+			"Time In Panic Mode:",
+		} {
+			assert.Check(t, strings.Contains(output, line), "missing '%s' in output: %s", line, output)
+		}
+	})
+
+	t.Run("missing live autoscaling config", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams()
+
+		_, err := testutil.ExecuteCommand(NewAutoscalingSimulateCommand(p), "--trace", trace)
+		assert.ErrorContains(t, err, "failed to get ConfigMaps")
+	})
+
+	t.Run("candidate autoscaling config", func(t *testing.T) {
+		// the cluster is not accessed with a candidate config
+		p, _ := testutil.NewTestAdminParams()
+
+		configMap := writeTestFile(t, "config-autoscaler.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config-autoscaler
+  namespace: knative-serving
+data:
+  panic-threshold-percentage: "1000"
+  target-burst-capacity: "0"
+`)
+		output, err := testutil.ExecuteCommand(NewAutoscalingSimulateCommand(p), "--trace", trace, "--target", "10", "--target-utilization-percentage", "100", "--autoscaler-config", configMap)
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Target Per Pod:      10.00"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(output, "Max Pods:            10"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(output, "Time In Panic Mode:  0s"), "unexpected output: %s", output)
+
+		flat := writeTestFile(t, "candidate.yaml", "requests-per-second-target-default: 50\n")
+		output, err = testutil.ExecuteCommand(NewAutoscalingSimulateCommand(p), "--trace", trace, "--metric", "rps", "--autoscaler-config", flat)
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Target Per Pod:      35.00"), "unexpected output: %s", output)
+
+		invalid := writeTestFile(t, "invalid.yaml", "stable-window: 1s\n")
+		_, err = testutil.ExecuteCommand(NewAutoscalingSimulateCommand(p), "--trace", trace, "--autoscaler-config", invalid)
+		assert.ErrorContains(t, err, "invalid autoscaling config")
+	})
+}
@@ -0,0 +1,322 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"knative.dev/serving/pkg/apis/autoscaling"
+	asconfig "knative.dev/serving/pkg/autoscaler/config"
+	"knative.dev/serving/pkg/autoscaler/config/autoscalerconfig"
+)
+
+// trafficSample is a sample of the observed traffic of a revision in a trace
+type trafficSample struct {
+	// at is the offset from the start of the trace
+	at time.Duration
+	// value is the total concurrency or RPS of the revision
+	value float64
+}
+
+// simulationSpec is the autoscaling spec of the simulated revision
+type simulationSpec struct {
+	config *autoscalerconfig.Config
+	// target is the target per pod before applying the utilization
+	target float64
+	// utilization is the target utilization as a fraction
+	utilization float64
+	// podStartupTime is the time for a new pod to become ready
+	podStartupTime time.Duration
+}
+
+// simulationTick is the state of the simulated revision at a tick
+type simulationTick struct {
+	at        time.Duration
+	observed  float64
+	stable    float64
+	panic     float64
+	desired   int32
+	ready     int32
+	panicking bool
+}
+
+// simulationResult is the outcome of replaying a trace
+type simulationResult struct {
+	ticks      []simulationTick
+	coldStarts int
+	panicTime  time.Duration
+	maxPods    int32
+	podSeconds float64
+}
+
+// pendingPods are the pods which become ready at the time
+type pendingPods struct {
+	readyAt time.Duration
+	count   int32
+}
+
+// parseTrace parses a CSV trace of 'time,value' records, the time is either the seconds or a duration from
+// the start of the trace, or an RFC3339 timestamp. A header line and lines starting with '#' are skipped
+func parseTrace(r io.Reader) ([]trafficSample, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	samples := []trafficSample{}
+	var start time.Time
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trace: %v", err)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			if len(samples) == 0 && line == 1 {
+				// header line
+				continue
+			}
+			return nil, fmt.Errorf("invalid value '%s' in line %d of trace: %v", record[1], line, err)
+		}
+		if value < 0 {
+			return nil, fmt.Errorf("invalid value '%s' in line %d of trace: must not be negative", record[1], line)
+		}
+
+		raw := strings.TrimSpace(record[0])
+		var at time.Duration
+		if seconds, err := strconv.ParseFloat(raw, 64); err == nil {
+			at = time.Duration(seconds * float64(time.Second))
+		} else if d, err := time.ParseDuration(raw); err == nil {
+			at = d
+		} else if ts, err := time.Parse(time.RFC3339, raw); err == nil {
+			if start.IsZero() {
+				start = ts
+			}
+			at = ts.Sub(start)
+		} else {
+			return nil, fmt.Errorf("invalid time '%s' in line %d of trace", raw, line)
+		}
+		if len(samples) > 0 && at < samples[len(samples)-1].at {
+			return nil, fmt.Errorf("time '%s' in line %d of trace is earlier than the previous one", raw, line)
+		}
+		samples = append(samples, trafficSample{at: at, value: value})
+	}
+	if len(samples) == 0 {
+		return nil, errors.New("no traffic sample found in trace")
+	}
+	return samples, nil
+}
+
+// simulate replays the traffic samples with the KPA algorithm every second, the observed value
+// is held until the next sample. It follows the stable and panic mode of the autoscaler in
+// Knative Serving, including the scale rates, the scale-down delay and the scale-to-zero grace period
+func simulate(spec simulationSpec, samples []trafficSample) simulationResult {
+	config := spec.config
+	tick := asconfig.BucketSize
+	stableBuckets := int(math.Max(1, math.Round(float64(config.StableWindow)/float64(tick))))
+	panicBuckets := int(math.Max(1, math.Round(float64(config.StableWindow)*config.PanicWindowPercentage/100/float64(tick))))
+	delayBuckets := int(math.Round(float64(config.ScaleDownDelay) / float64(tick)))
+	zeroDelay := config.ScaleToZeroGracePeriod
+	if config.ScaleToZeroPodRetentionPeriod > zeroDelay {
+		zeroDelay = config.ScaleToZeroPodRetentionPeriod
+	}
+	targetPerPod := math.Max(autoscaling.TargetMin, spec.target*spec.utilization)
+	panicThreshold := config.PanicThresholdPercentage / 100
+
+	result := simulationResult{}
+	history := []float64{}
+	desiredHistory := []int32{}
+	ready := config.InitialScale
+	pending := []pendingPods{}
+	// lastPanic is the last time over the panic threshold, -1 means not in panic mode
+	var lastPanic time.Duration = -1
+	var maxPanicPods int32
+	var zeroSince time.Duration = -1
+	coldStarting := false
+
+	end := samples[len(samples)-1].at
+	next := 0
+	observed := 0.0
+	for at := time.Duration(0); at <= end; at += tick {
+		for next < len(samples) && samples[next].at <= at {
+			observed = samples[next].value
+			next++
+		}
+
+		// promote the pods which become ready
+		remaining := pending[:0]
+		for _, pp := range pending {
+			if pp.readyAt <= at {
+				ready += pp.count
+			} else {
+				remaining = append(remaining, pp)
+			}
+		}
+		pending = remaining
+
+		history = append(history, observed)
+		// the panic window is never longer than the stable window
+		if len(history) > stableBuckets {
+			history = history[len(history)-stableBuckets:]
+		}
+		stable := averageOfLast(history, stableBuckets)
+		panicValue := averageOfLast(history, panicBuckets)
+
+		readyCount := math.Max(1, float64(ready))
+		maxScaleUp := math.Ceil(config.MaxScaleUpRate * readyCount)
+		maxScaleDown := math.Floor(readyCount / config.MaxScaleDownRate)
+		dspc := math.Ceil(stable / targetPerPod)
+		dppc := math.Ceil(panicValue / targetPerPod)
+		desiredStable := int32(math.Min(math.Max(dspc, maxScaleDown), maxScaleUp))
+		desiredPanic := int32(math.Min(math.Max(dppc, maxScaleDown), maxScaleUp))
+
+		overPanicThreshold := dppc/readyCount >= panicThreshold
+		switch {
+		case lastPanic < 0 && overPanicThreshold:
+			lastPanic = at
+			maxPanicPods = int32(readyCount)
+		case overPanicThreshold:
+			// extend the panic mode
+			lastPanic = at
+		case lastPanic >= 0 && lastPanic+config.StableWindow <= at:
+			// leave the panic mode after a stable window without exceeding the threshold
+			lastPanic = -1
+			maxPanicPods = 0
+		}
+
+		desired := desiredStable
+		if lastPanic >= 0 {
+			// never scale down in panic mode
+			if desiredPanic > maxPanicPods {
+				maxPanicPods = desiredPanic
+			}
+			desired = maxPanicPods
+			result.panicTime += tick
+		}
+
+		// the max desired scale within the scale-down delay is used
+		if delayBuckets > 0 {
+			desiredHistory = append(desiredHistory, desired)
+			if len(desiredHistory) > delayBuckets {
+				desiredHistory = desiredHistory[len(desiredHistory)-delayBuckets:]
+			}
+			for _, d := range desiredHistory {
+				if d > desired {
+					desired = d
+				}
+			}
+		}
+
+		if config.MinScale > 0 && desired < config.MinScale {
+			desired = config.MinScale
+		}
+		if config.MaxScale > 0 && desired > config.MaxScale {
+			desired = config.MaxScale
+		}
+
+		// the last pod is kept within the scale-to-zero grace period
+		current := ready + pendingCount(pending)
+		if desired == 0 {
+			if zeroSince < 0 {
+				zeroSince = at
+			}
+			if !config.EnableScaleToZero || (current > 0 && at-zeroSince < zeroDelay) {
+				desired = 1
+			}
+		} else {
+			zeroSince = -1
+		}
+
+		// requests arriving without ready pods are cold starts
+		if observed > 0 && ready == 0 {
+			if !coldStarting {
+				result.coldStarts++
+				coldStarting = true
+			}
+		} else if ready > 0 {
+			coldStarting = false
+		}
+
+		switch {
+		case desired > current:
+			pp := pendingPods{readyAt: at + spec.podStartupTime, count: desired - current}
+			if spec.podStartupTime <= 0 {
+				pp.readyAt = at + tick
+			}
+			pending = append(pending, pp)
+		case desired < current:
+			ready, pending = scaleDown(ready, pending, current-desired)
+		}
+
+		result.ticks = append(result.ticks, simulationTick{
+			at:        at,
+			observed:  observed,
+			stable:    stable,
+			panic:     panicValue,
+			desired:   desired,
+			ready:     ready,
+			panicking: lastPanic >= 0,
+		})
+		if ready > result.maxPods {
+			result.maxPods = ready
+		}
+		result.podSeconds += float64(ready) * tick.Seconds()
+	}
+	return result
+}
+
+// averageOfLast returns the average of the last n values
+func averageOfLast(values []float64, n int) float64 {
+	if len(values) < n {
+		n = len(values)
+	}
+	sum := 0.0
+	for _, v := range values[len(values)-n:] {
+		sum += v
+	}
+	return sum / float64(n)
+}
+
+// pendingCount returns the number of the pods which are not ready yet
+func pendingCount(pending []pendingPods) int32 {
+	var count int32
+	for _, pp := range pending {
+		count += pp.count
+	}
+	return count
+}
+
+// scaleDown removes the pods, the pods not ready yet are removed first
+func scaleDown(ready int32, pending []pendingPods, count int32) (int32, []pendingPods) {
+	for i := len(pending) - 1; i >= 0 && count > 0; i-- {
+		removed := min(pending[i].count, count)
+		pending[i].count -= removed
+		count -= removed
+		if pending[i].count == 0 {
+			pending = pending[:i]
+		}
+	}
+	return ready - count, pending
+}
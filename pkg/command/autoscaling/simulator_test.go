@@ -0,0 +1,165 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	asconfig "knative.dev/serving/pkg/autoscaler/config"
+)
+
+func newSimulationSpec(t *testing.T, data map[string]string) simulationSpec {
+	config, err := asconfig.NewConfigFromMap(data)
+	assert.NilError(t, err)
+	return simulationSpec{config: config, target: 10, utilization: 1, podStartupTime: time.Second}
+}
+
+func TestParseTrace(t *testing.T) {
+	t.Run("time formats", func(t *testing.T) {
+		samples, err := parseTrace(strings.NewReader("time,concurrency\n# comment\n0,1\n1.5,2\n1m,3\n"))
+		assert.NilError(t, err)
+		checkTrafficSamples(t, []trafficSample{
+			{at: 0, value: 1},
+			{at: 1500 * time.Millisecond, value: 2},
+			{at: time.Minute, value: 3},
+		}, samples)
+
+		samples, err = parseTrace(strings.NewReader("2020-01-01T00:00:10Z,1\n2020-01-01T00:01:00Z,2\n"))
+		assert.NilError(t, err)
+		checkTrafficSamples(t, []trafficSample{
+			{at: 0, value: 1},
+			{at: 50 * time.Second, value: 2},
+		}, samples)
+	})
+
+	t.Run("invalid traces", func(t *testing.T) {
+		for _, tc := range []struct {
+			trace string
+			err   string
+		}{
+			{"", "no traffic sample found in trace"},
+			{"time,concurrency\n", "no traffic sample found in trace"},
+			{"0,1\n1,abc\n", "invalid value 'abc' in line 2 of trace"},
+			{"0,-1\n", "invalid value '-1' in line 1 of trace: must not be negative"},
+			{"0,1\nyesterday,1\n", "invalid time 'yesterday' in line 2 of trace"},
+			{"10,1\n5,1\n", "time '5' in line 2 of trace is earlier than the previous one"},
+			{"0,1,2\n", "failed to read trace"},
+		} {
+			_, err := parseTrace(strings.NewReader(tc.trace))
+			assert.ErrorContains(t, err, tc.err, "trace: %q", tc.trace)
+		}
+	})
+}
+
+func checkTrafficSamples(t *testing.T, expected, actual []trafficSample) {
+	assert.Equal(t, len(expected), len(actual))
+	for i := range expected {
+		assert.Equal(t, expected[i], actual[i], "sample %d", i)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	t.Run("steady traffic", func(t *testing.T) {
+		spec := newSimulationSpec(t, nil)
+		result := simulate(spec, []trafficSample{{at: 0, value: 30}, {at: 2 * time.Minute, value: 30}})
+		last := result.ticks[len(result.ticks)-1]
+		assert.Equal(t, int32(3), last.desired)
+		assert.Equal(t, int32(3), last.ready)
+		assert.Equal(t, int32(3), result.maxPods)
+		assert.Equal(t, 0, result.coldStarts)
+	})
+
+	t.Run("panic on burst", func(t *testing.T) {
+		spec := newSimulationSpec(t, nil)
+		result := simulate(spec, []trafficSample{
+			{at: 0, value: 10},
+			{at: time.Minute, value: 100},
+			{at: 2 * time.Minute, value: 10},
+			{at: 5 * time.Minute, value: 10},
+		})
+		assert.Check(t, result.panicTime > 0, "expected time in panic mode")
+		assert.Equal(t, int32(10), result.maxPods)
+		// never scale down in panic mode
+		for i := 1; i < len(result.ticks); i++ {
+			if result.ticks[i].panicking && result.ticks[i-1].panicking {
+				assert.Check(t, result.ticks[i].desired >= result.ticks[i-1].desired, "scaled down in panic mode at %v", result.ticks[i].at)
+			}
+		}
+		last := result.ticks[len(result.ticks)-1]
+		assert.Equal(t, false, last.panicking)
+		assert.Equal(t, int32(1), last.ready)
+	})
+
+	t.Run("panic mode disabled by threshold", func(t *testing.T) {
+		spec := newSimulationSpec(t, map[string]string{"panic-threshold-percentage": "1000"})
+		result := simulate(spec, []trafficSample{{at: 0, value: 10}, {at: time.Minute, value: 50}, {at: 2 * time.Minute, value: 50}})
+		assert.Equal(t, time.Duration(0), result.panicTime)
+	})
+
+	t.Run("cold starts after scale to zero", func(t *testing.T) {
+		spec := newSimulationSpec(t, map[string]string{
+			"stable-window":              "10s",
+			"scale-to-zero-grace-period": "10s",
+		})
+		result := simulate(spec, []trafficSample{
+			{at: 0, value: 0},
+			{at: time.Minute, value: 5},
+			{at: 70 * time.Second, value: 0},
+			{at: 2 * time.Minute, value: 5},
+			{at: 130 * time.Second, value: 0},
+			{at: 3 * time.Minute, value: 0},
+		})
+		assert.Equal(t, 2, result.coldStarts)
+		assert.Equal(t, int32(0), result.ticks[len(result.ticks)-1].ready)
+	})
+
+	t.Run("scale to zero disabled", func(t *testing.T) {
+		spec := newSimulationSpec(t, map[string]string{"enable-scale-to-zero": "false"})
+		result := simulate(spec, []trafficSample{{at: 0, value: 0}, {at: 5 * time.Minute, value: 0}})
+		assert.Equal(t, int32(1), result.ticks[len(result.ticks)-1].ready)
+		assert.Equal(t, 0, result.coldStarts)
+	})
+
+	t.Run("min and max scale", func(t *testing.T) {
+		spec := newSimulationSpec(t, map[string]string{"min-scale": "2", "max-scale": "4"})
+		result := simulate(spec, []trafficSample{{at: 0, value: 0}, {at: time.Minute, value: 100}, {at: 3 * time.Minute, value: 0}})
+		assert.Equal(t, int32(4), result.maxPods)
+		for _, tick := range result.ticks {
+			assert.Check(t, tick.desired >= 2 && tick.desired <= 4, "desired scale %d out of bounds at %v", tick.desired, tick.at)
+		}
+	})
+
+	t.Run("scale down delay", func(t *testing.T) {
+		spec := newSimulationSpec(t, map[string]string{"stable-window": "6s", "scale-down-delay": "30s"})
+		result := simulate(spec, []trafficSample{{at: 0, value: 30}, {at: 20 * time.Second, value: 10}, {at: time.Minute, value: 10}})
+		desiredAt := func(at time.Duration) int32 {
+			return result.ticks[int(at/time.Second)].desired
+		}
+		assert.Equal(t, int32(3), desiredAt(40*time.Second))
+		assert.Equal(t, int32(1), desiredAt(time.Minute))
+	})
+
+	t.Run("pod startup time", func(t *testing.T) {
+		spec := newSimulationSpec(t, map[string]string{"panic-threshold-percentage": "1000"})
+		spec.podStartupTime = 10 * time.Second
+		result := simulate(spec, []trafficSample{{at: 0, value: 20}, {at: 20 * time.Second, value: 20}})
+		assert.Equal(t, int32(2), result.ticks[0].desired)
+		assert.Equal(t, int32(1), result.ticks[9].ready)
+		assert.Equal(t, int32(2), result.ticks[10].ready)
+	})
+}
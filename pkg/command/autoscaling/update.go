@@ -19,7 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
-	"time"
+	"strings"
 
 	"knative.dev/kn-plugin-admin/pkg/command/utils"
 
@@ -32,53 +32,43 @@ import (
 
 	"knative.dev/client/pkg/flags"
 
-	as "knative.dev/serving/pkg/apis/autoscaling"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	asconfig "knative.dev/serving/pkg/autoscaler/config"
+	"knative.dev/serving/pkg/autoscaler/config/autoscalerconfig"
 )
 
-// Autoscaling global configs
-type Config struct {
-	ScaleToZero                          bool
-	EnableScaleToZero                    string
-	StableWindow                         time.Duration
-	ScaleToZeroGracePeriod               time.Duration
-	ScaleToZeroPodRetentionPeriod        time.Duration
-	ContainerConcurrencyTargetPercentage string
-	PanicWindowPercentage                string
-	PanicThresholdPercentage             string
-	MaxScaleUpRate                       string
-	MaxScaleDownRate                     string
-	TargetBurstCapacity                  string
-	ActivatorCapacity                    string
-	RequestsPerSecondTargetDefault       string
-	ContainerConcurrencyTargetDefault    string
-	PodAutoscalerClass                   string
-}
-
-func NewConfig() Config {
-	config := Config{}
-	config.EnableScaleToZero = "enable-scale-to-zero"
-	return config
-}
-
 var (
 	knativeServing   = "knative-serving"
 	configAutoscaler = "config-autoscaler"
 )
 
 func NewAutoscalingUpdateCommand(p *pkg.AdminParams) *cobra.Command {
-	config := NewConfig()
+	var (
+		settings []string
+		force    bool
+	)
 	AutoscalingUpdateCommand := &cobra.Command{
 		Use:   "update",
 		Short: "Update autoscaling config",
-		Long:  `Update autoscaling config provided by Knative Pod Autoscaler (KPA)`,
+		Long: `Update autoscaling config provided by Knative Pod Autoscaler (KPA)
+
+The resulting config is checked against the cross-field rules, e.g. the panic window shorter than the metric bucket,
+or the HPA class while revisions scale on metrics other than cpu and memory. A violated rule either blocks the update or prints a warning with the
+explanation, and the blocking rules can be overridden with --force`,
 		Example: `
   # To enable scale-to-zero
   kn admin autoscaling update --scale-to-zero
 
   # To update stable window
-  kn admin autoscaling update --stable-window 2m`,
+  kn admin autoscaling update --stable-window 2m
+
+  # To update keys in the form of key=value, e.g: a key added in a newer Knative Serving
+  kn admin autoscaling update --set max-scale=10 --set min-scale=1
+
+  # To update the config even if it violates the blocking rules
+  kn admin autoscaling update --panic-window-percentage 1 --force`,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if cmd.Flags().NFlag() == 0 {
+			if cmd.Flags().NFlag() == 0 || (force && cmd.Flags().NFlag() == 1) {
 				return errors.New("'autoscaling update' requires flag(s)")
 			}
 			if err := p.EnsureInstallMethodStandalone(); err != nil {
@@ -87,6 +77,11 @@ func NewAutoscalingUpdateCommand(p *pkg.AdminParams) *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			desired, err := desiredConfigData(cmd, settings)
+			if err != nil {
+				return err
+			}
+
 			client, err := p.NewKubeClient()
 			if err != nil {
 				return err
@@ -98,114 +93,21 @@ func NewAutoscalingUpdateCommand(p *pkg.AdminParams) *cobra.Command {
 				return fmt.Errorf("failed to get ConfigMaps: %+v", err)
 			}
 			desiredCm := currentCm.DeepCopy()
-
-			if cmd.Flags().Changed("scale-to-zero") && cmd.Flags().Changed("no-scale-to-zero") {
-				return fmt.Errorf("please specify either --scale-to-zero or --no-scale-to-zero")
-			}
-
-			if cmd.Flags().Changed("scale-to-zero") {
-				desiredCm.Data[config.EnableScaleToZero] = "true"
-			}
-
-			if cmd.Flags().Changed("no-scale-to-zero") {
-				desiredCm.Data[config.EnableScaleToZero] = "false"
-			}
-
-			if cmd.Flags().Changed("requests-per-second-target-default") {
-				desiredCm.Data["requests-per-second-target-default"] = fmt.Sprintf("%s", config.RequestsPerSecondTargetDefault)
-			}
-
-			if cmd.Flags().Changed("container-concurrency-target-default") {
-				desiredCm.Data["container-concurrency-target-default"] = fmt.Sprintf("%s", config.ContainerConcurrencyTargetDefault)
-			}
-
-			if cmd.Flags().Changed("container-concurrency-target-percentage") {
-				desiredCm.Data["container-concurrency-target-percentage"] = fmt.Sprintf("%s", config.ContainerConcurrencyTargetPercentage)
-			}
-
-			if cmd.Flags().Changed("stable-window") {
-				if config.StableWindow < as.WindowMin || config.StableWindow > as.WindowMax {
-					return fmt.Errorf("stable-window = %v, must be in [%v; %v] range", config.StableWindow,
-						as.WindowMin, as.WindowMax)
-				}
-
-				if config.StableWindow.Round(time.Second) != config.StableWindow {
-					return fmt.Errorf("stable-window = %v, must be specified with at most second precision", config.StableWindow)
-				}
-
-				fmt.Printf("debug stable-window %vs\n", config.StableWindow.Seconds())
-				desiredCm.Data["stable-window"] = fmt.Sprintf("%vs", config.StableWindow.Seconds())
-			}
-
-			if cmd.Flags().Changed("panic-window-percentage") {
-				desiredCm.Data["panic-window-percentage"] = config.PanicWindowPercentage
+			if desiredCm.Data == nil {
+				desiredCm.Data = map[string]string{}
 			}
-
-			if cmd.Flags().Changed("panic-threshold-percentage") {
-				desiredCm.Data["panic-threshold-percentage"] = config.PanicThresholdPercentage
-			}
-
-			if cmd.Flags().Changed("max-scale-up-rate") {
-				tmp, err := strconv.ParseFloat(config.MaxScaleUpRate, 64)
-				if err != nil {
-					return fmt.Errorf("failed to parse %v", config.MaxScaleUpRate)
-				}
-				if tmp <= 1.0 {
-					return fmt.Errorf("max-scale-up-rate = %v, must be greater than 1.0", config.MaxScaleUpRate)
-				}
-				desiredCm.Data["max-scale-up-rate"] = config.MaxScaleUpRate
-			}
-
-			if cmd.Flags().Changed("max-scale-down-rate") {
-				tmp, err := strconv.ParseFloat(config.MaxScaleDownRate, 64)
-				if err != nil {
-					return fmt.Errorf("failed to parse %v", config.MaxScaleUpRate)
-				}
-				if tmp <= 1.0 {
-					return fmt.Errorf("max-scale-down-rate = %v, must be greater than 1.0", config.MaxScaleDownRate)
-				}
-				desiredCm.Data["max-scale-down-rate"] = config.MaxScaleDownRate
-			}
-
-			if cmd.Flags().Changed("scale-to-zero-grace-period") {
-				if config.ScaleToZeroGracePeriod < as.WindowMin {
-					return fmt.Errorf("scale-to-zero-grace-period must be at least %v, got %v", as.WindowMin, config.ScaleToZeroGracePeriod)
-				}
-
-				desiredCm.Data["scale-to-zero-grace-period"] = fmt.Sprintf("%vs", config.ScaleToZeroGracePeriod.Seconds())
-			}
-
-			if cmd.Flags().Changed("scale-to-zero-pod-retention-period") {
-				if config.ScaleToZeroPodRetentionPeriod < 0 {
-					return fmt.Errorf("scale-to-zero-pod-retention-period cannot be negative, was: %v", config.ScaleToZeroPodRetentionPeriod)
-				}
-				desiredCm.Data["scale-to-zero-pod-retention-period"] = fmt.Sprintf("%vs", config.ScaleToZeroPodRetentionPeriod.Seconds())
+			for key, value := range desired {
+				desiredCm.Data[key] = value
 			}
 
-			if cmd.Flags().Changed("target-burst-capacity") {
-				tmp, err := strconv.ParseFloat(config.TargetBurstCapacity, 64)
-				if err != nil {
-					return fmt.Errorf("failed to parse %v", config.MaxScaleUpRate)
-				}
-				if tmp < 0 && tmp != -1 {
-					return fmt.Errorf("target-burst-capacity must be either non-negative or -1 (for unlimited), got %s", config.TargetBurstCapacity)
-				}
-				desiredCm.Data["target-burst-capacity"] = config.TargetBurstCapacity
-			}
-
-			if cmd.Flags().Changed("pod-autoscaler-class") {
-				desiredCm.Data["pod-autoscaler-class"] = config.PodAutoscalerClass
+			// validate the desired config with the same parser as the autoscaler, so that
+			// the config rejected by the autoscaler at startup is never written
+			config, err := asconfig.NewConfigFromMap(desiredCm.Data)
+			if err != nil {
+				return fmt.Errorf("invalid autoscaling config: %v", err)
 			}
-
-			if cmd.Flags().Changed("activator-capacity") {
-				tmp, err := strconv.ParseFloat(config.ActivatorCapacity, 64)
-				if err != nil {
-					return fmt.Errorf("failed to parse %v", config.MaxScaleUpRate)
-				}
-				if tmp < 1 {
-					return fmt.Errorf("activator-capacity = %v, must be at least 1", config.ActivatorCapacity)
-				}
-				desiredCm.Data["activator-capacity"] = config.ActivatorCapacity
+			if err = checkRules(cmd, p, config, force); err != nil {
+				return err
 			}
 
 			err = utils.UpdateConfigMap(client, desiredCm)
@@ -218,21 +120,99 @@ func NewAutoscalingUpdateCommand(p *pkg.AdminParams) *cobra.Command {
 		},
 	}
 
-	flags.AddBothBoolFlagsUnhidden(AutoscalingUpdateCommand.Flags(), &config.ScaleToZero, "scale-to-zero", "", true,
-		"Enable scale-to-zero if set.")
-	AutoscalingUpdateCommand.Flags().StringVarP(&config.RequestsPerSecondTargetDefault, "requests-per-second-target-default", "", "200", "the default target value for requests per second")
-	AutoscalingUpdateCommand.Flags().StringVarP(&config.ContainerConcurrencyTargetDefault, "container-concurrency-target-default", "", "100", "the default value of container concurrency target")
-	AutoscalingUpdateCommand.Flags().StringVarP(&config.ContainerConcurrencyTargetPercentage, "container-concurrency-target-percentage", "", "0.7", "percentage of the specified target should actually be targeted by the Autoscaler")
-	AutoscalingUpdateCommand.Flags().DurationVarP(&config.StableWindow, "stable-window", "", 60*time.Second, "when operating in a stable mode, the autoscaler operates on the average concurrency over the x seconds of stable window")
-	AutoscalingUpdateCommand.Flags().StringVarP(&config.PanicWindowPercentage, "panic-window-percentage", "", "10", "The panic window is defined as a percentage of the stable window")
-	AutoscalingUpdateCommand.Flags().StringVarP(&config.PanicThresholdPercentage, "panic-threshold-percentage", "", "200", "This threshold defines when the autoscaler will move from stable mode into panic mode")
-	AutoscalingUpdateCommand.Flags().StringVarP(&config.MaxScaleUpRate, "max-scale-up-rate", "", "1000", "Maximum ratio of desired vs. observed pods")
-	AutoscalingUpdateCommand.Flags().StringVarP(&config.MaxScaleDownRate, "max-scale-down-rate", "", "2", "Maximum ratio of observed vs. desired pods")
-	AutoscalingUpdateCommand.Flags().DurationVarP(&config.ScaleToZeroGracePeriod, "scale-to-zero-grace-period", "", 30*time.Second, "the maximum seconds of time that the last pod will remain active after the Autoscaler has decided to scale pods to zero")
-	AutoscalingUpdateCommand.Flags().DurationVarP(&config.ScaleToZeroPodRetentionPeriod, "scale-to-zero-pod-retention-period", "", 0*time.Second, "the minimum seconds of time that the last pod will remain active after the Autoscaler has decided to scale pods to zero")
-	AutoscalingUpdateCommand.Flags().StringVarP(&config.TargetBurstCapacity, "target-burst-capacity", "", "200", "the desired burst capacity for the revision")
-	AutoscalingUpdateCommand.Flags().StringVarP(&config.PodAutoscalerClass, "pod-autoscaler-class", "", "kpa.autoscaling.knative.dev", "the config of Knative autoscaling to work with either the default KPA or a CPU based metric, i.e. Horizontal Pod Autoscaler (HPA)")
-	AutoscalingUpdateCommand.Flags().StringVarP(&config.ActivatorCapacity, "activator-capacity", "", "200", "number of the concurrent requests an activator task can accept")
+	// the flag defaults are the defaults of Knative Serving
+	defaults, _ := asconfig.NewConfigFromMap(nil)
+	for _, key := range configKeys {
+		switch key.kind {
+		case boolKey:
+			flags.AddBothBoolFlagsUnhidden(AutoscalingUpdateCommand.Flags(), new(bool), key.flagName(), "", key.value(defaults) == "true", key.usage)
+		case durationKey:
+			AutoscalingUpdateCommand.Flags().Duration(key.flagName(), key.duration(defaults), key.usage)
+		default:
+			AutoscalingUpdateCommand.Flags().String(key.flagName(), key.value(defaults), key.usage)
+		}
+	}
+	AutoscalingUpdateCommand.Flags().StringArrayVar(&settings, "set", nil, "set any key of config-autoscaler in the form of key=value, it can be specified multiple times")
+	AutoscalingUpdateCommand.Flags().BoolVar(&force, "force", false, "update the config even if it violates the blocking rules")
 
 	return AutoscalingUpdateCommand
 }
+
+// checkRules evaluates the config rules against the desired config and the revisions in the cluster, the warnings are
+// printed and an error is returned if any blocking rule is violated, unless it's forced
+func checkRules(cmd *cobra.Command, p *pkg.AdminParams, config *autoscalerconfig.Config, force bool) error {
+	in := ruleInput{config: config}
+	servingClient, err := p.NewServingClient()
+	if err == nil {
+		var revisions *servingv1.RevisionList
+		revisions, err = servingClient.Revisions("").List(context.TODO(), metav1.ListOptions{})
+		if err == nil {
+			in.revisions = revisions.Items
+		}
+	}
+	if err != nil {
+		cmd.PrintErrf("Warning: failed to list revisions, the rules on revisions are skipped: %v\n", err)
+	}
+
+	blocking := []string{}
+	for _, v := range evaluateRules(in) {
+		if v.severity == ruleError && !force {
+			blocking = append(blocking, v.String())
+			continue
+		}
+		cmd.PrintErrf("Warning: %s\n", v)
+	}
+	if len(blocking) > 0 {
+		return fmt.Errorf("autoscaling config violates %d rule(s), use --force to update anyway:\n  %s", len(blocking), strings.Join(blocking, "\n  "))
+	}
+	return nil
+}
+
+// desiredConfigData returns the config-autoscaler keys to update from the flags and the '--set' values
+func desiredConfigData(cmd *cobra.Command, settings []string) (map[string]string, error) {
+	desired := map[string]string{}
+	for _, key := range configKeys {
+		name := key.flagName()
+		switch key.kind {
+		case boolKey:
+			negativeName := "no-" + name
+			if cmd.Flags().Changed(name) && cmd.Flags().Changed(negativeName) {
+				return nil, fmt.Errorf("please specify either --%s or --%s", name, negativeName)
+			}
+			if cmd.Flags().Changed(name) {
+				v, _ := cmd.Flags().GetBool(name)
+				desired[key.name] = strconv.FormatBool(v)
+			}
+			if cmd.Flags().Changed(negativeName) {
+				v, _ := cmd.Flags().GetBool(negativeName)
+				desired[key.name] = strconv.FormatBool(!v)
+			}
+		case durationKey:
+			if cmd.Flags().Changed(name) {
+				d, _ := cmd.Flags().GetDuration(name)
+				desired[key.name] = fmt.Sprintf("%vs", d.Seconds())
+			}
+		default:
+			if cmd.Flags().Changed(name) {
+				desired[key.name] = cmd.Flags().Lookup(name).Value.String()
+			}
+		}
+	}
+
+	for _, setting := range settings {
+		name, value, ok := strings.Cut(setting, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --set value '%s', expected key=value", setting)
+		}
+		key, known := lookupConfigKey(name)
+		if known && cmd.Flags().Changed(key.flagName()) {
+			return nil, fmt.Errorf("key '%s' is specified by both --%s and --set", name, key.flagName())
+		}
+		if !known {
+			cmd.PrintErrf("Warning: key '%s' is unknown to kn admin and is not validated\n", name)
+		}
+		desired[name] = value
+	}
+	return desired, nil
+}
@@ -16,6 +16,8 @@ package autoscaling
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -23,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"knative.dev/kn-plugin-admin/pkg"
+	servingv1client "knative.dev/serving/pkg/client/clientset/versioned/typed/serving/v1"
 
 	"knative.dev/kn-plugin-admin/pkg/testutil"
 )
@@ -199,7 +202,19 @@ func TestNewAsUpdateSetCommand(t *testing.T) {
 		assert.ErrorContains(t, err, "max-scale-up-rate = 0.5, must be greater than 1.0", err)
 	})
 
-	t.Run("return error if set scale-to-zero-grace-period less than 6s", func(t *testing.T) {
+	t.Run("return error if set max-scale-down-rate flag less than 1.0", func(t *testing.T) {
+		cm.Data = map[string]string{
+			"max-scale-down-rate": "2.0",
+		}
+		p, client := testutil.NewTestAdminParams(cm)
+		assert.Check(t, client != nil)
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		cmd := NewAutoscalingUpdateCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "--max-scale-down-rate", "0.5")
+		assert.ErrorContains(t, err, "max-scale-down-rate = 0.5, must be greater than 1.0", err)
+	})
+
+	t.Run("return error if set scale-to-zero-grace-period to 0s", func(t *testing.T) {
 		cm.Data = map[string]string{
 			"scale-to-zero-grace-period": "30s",
 		}
@@ -207,8 +222,8 @@ func TestNewAsUpdateSetCommand(t *testing.T) {
 		assert.Check(t, client != nil)
 		p.InstallationMethod = pkg.InstallationMethodStandalone
 		cmd := NewAutoscalingUpdateCommand(p)
-		_, err := testutil.ExecuteCommand(cmd, "--scale-to-zero-grace-period", "1s")
-		assert.ErrorContains(t, err, "scale-to-zero-grace-period must be at least 6s, got 1s", err)
+		_, err := testutil.ExecuteCommand(cmd, "--scale-to-zero-grace-period", "0s")
+		assert.ErrorContains(t, err, "scale-to-zero-grace-period must be positive, was: 0s", err)
 	})
 
 	t.Run("return error if scale-to-zero-grace-period is not time duration", func(t *testing.T) {
@@ -250,7 +265,7 @@ func TestNewAsUpdateSetCommand(t *testing.T) {
 		p.InstallationMethod = pkg.InstallationMethodStandalone
 		cmd := NewAutoscalingUpdateCommand(p)
 		_, err := testutil.ExecuteCommand(cmd, "--target-burst-capacity", "-5")
-		assert.ErrorContains(t, err, "target-burst-capacity must be either non-negative or -1 (for unlimited), got -5", err)
+		assert.ErrorContains(t, err, "target-burst-capacity must be either non-negative or -1 (for unlimited), was: -5", err)
 	})
 
 	t.Run("update pod-autoscaler-class successfully", func(t *testing.T) {
@@ -282,4 +297,171 @@ func TestNewAsUpdateSetCommand(t *testing.T) {
 		_, err := testutil.ExecuteCommand(cmd, "--activator-capacity", "0.5")
 		assert.ErrorContains(t, err, "activator-capacity = 0.5, must be at least 1", err)
 	})
+
+	t.Run("return error if the value can not be parsed", func(t *testing.T) {
+		cm.Data = map[string]string{}
+		p, client := testutil.NewTestAdminParams(cm)
+		assert.Check(t, client != nil)
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		cmd := NewAutoscalingUpdateCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "--max-scale-down-rate", "abc")
+		assert.ErrorContains(t, err, "invalid autoscaling config: failed to parse data", err)
+		assert.ErrorContains(t, err, "max-scale-down-rate", err)
+	})
+
+	t.Run("return error if the desired config violates cross-field constraints", func(t *testing.T) {
+		cm.Data = map[string]string{
+			"container-concurrency-target-default": "0.02",
+		}
+		p, client := testutil.NewTestAdminParams(cm)
+		assert.Check(t, client != nil)
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		cmd := NewAutoscalingUpdateCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "--container-concurrency-target-percentage", "0.1")
+		assert.ErrorContains(t, err, "container-concurrency-target-percentage and container-concurrency-target-default yield target concurrency", err)
+
+		updated, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "", updated.Data["container-concurrency-target-percentage"], "invalid config should not be written")
+	})
+
+	t.Run("update min-scale, max-scale and scale-down-delay successfully", func(t *testing.T) {
+		cm.Data = map[string]string{}
+		p, client := testutil.NewTestAdminParams(cm)
+		assert.Check(t, client != nil)
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		cmd := NewAutoscalingUpdateCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "--min-scale", "1", "--max-scale", "10", "--scale-down-delay", "5m", "--allow-zero-initial-scale")
+		assert.NilError(t, err)
+
+		cm, err = client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, map[string]string{
+			"min-scale":                "1",
+			"max-scale":                "10",
+			"scale-down-delay":         "300s",
+			"allow-zero-initial-scale": "true",
+		}, cm.Data)
+	})
+
+	t.Run("return error if max-scale exceeds max-scale-limit", func(t *testing.T) {
+		cm.Data = map[string]string{
+			"max-scale-limit": "5",
+		}
+		p, client := testutil.NewTestAdminParams(cm)
+		assert.Check(t, client != nil)
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		cmd := NewAutoscalingUpdateCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "--max-scale", "10")
+		assert.ErrorContains(t, err, "max-scale = 10, must be in [1, max-scale-limit(5)] range", err)
+	})
+
+	t.Run("update keys with --set successfully", func(t *testing.T) {
+		cm.Data = map[string]string{
+			"initial-scale": "2",
+		}
+		p, client := testutil.NewTestAdminParams(cm)
+		assert.Check(t, client != nil)
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		cmd := NewAutoscalingUpdateCommand(p)
+		output, err := testutil.ExecuteCommand(cmd, "--set", "initial-scale=3", "--set", "new-upstream-key=on", "--no-scale-to-zero")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Warning: key 'new-upstream-key' is unknown to kn admin and is not validated"), "unexpected output: %s", output)
+
+		cm, err = client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, map[string]string{
+			"initial-scale":        "3",
+			"new-upstream-key":     "on",
+			"enable-scale-to-zero": "false",
+		}, cm.Data)
+	})
+
+	t.Run("return error if --set is invalid", func(t *testing.T) {
+		cm.Data = map[string]string{}
+		p, client := testutil.NewTestAdminParams(cm)
+		assert.Check(t, client != nil)
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+
+		_, err := testutil.ExecuteCommand(NewAutoscalingUpdateCommand(p), "--set", "min-scale")
+		assert.ErrorContains(t, err, "invalid --set value 'min-scale', expected key=value", err)
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingUpdateCommand(p), "--set", "=1")
+		assert.ErrorContains(t, err, "invalid --set value '=1', expected key=value", err)
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingUpdateCommand(p), "--min-scale", "1", "--set", "min-scale=2")
+		assert.ErrorContains(t, err, "key 'min-scale' is specified by both --min-scale and --set", err)
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingUpdateCommand(p), "--set", "min-scale=-1")
+		assert.ErrorContains(t, err, "min-scale = -1, must be at least 0", err)
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingUpdateCommand(p), "--scale-to-zero", "--no-scale-to-zero")
+		assert.ErrorContains(t, err, "please specify either --scale-to-zero or --no-scale-to-zero", err)
+
+		_, err = testutil.ExecuteCommand(NewAutoscalingUpdateCommand(p), "--force")
+		assert.ErrorContains(t, err, "'autoscaling update' requires flag(s)", err)
+	})
+
+	t.Run("return error if the desired config violates a blocking rule", func(t *testing.T) {
+		cm.Data = map[string]string{}
+		p, client := testutil.NewTestAdminParams(cm)
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+
+		_, err := testutil.ExecuteCommand(NewAutoscalingUpdateCommand(p), "--panic-window-percentage", "1")
+		assert.ErrorContains(t, err, "autoscaling config violates 1 rule(s), use --force to update anyway", err)
+		assert.ErrorContains(t, err, "[panic-window] panic window of 600ms", err)
+
+		updated, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "", updated.Data["panic-window-percentage"], "config violating a blocking rule should not be written")
+
+		output, err := testutil.ExecuteCommand(NewAutoscalingUpdateCommand(p), "--panic-window-percentage", "1", "--force")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Warning: [panic-window] panic window of 600ms"), "unexpected output: %s", output)
+
+		updated, err = client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "1", updated.Data["panic-window-percentage"])
+	})
+
+	t.Run("print warnings of the violated rules", func(t *testing.T) {
+		cm.Data = map[string]string{}
+		p, client := testutil.NewTestAdminParams(cm)
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+
+		output, err := testutil.ExecuteCommand(NewAutoscalingUpdateCommand(p), "--target-burst-capacity", "0")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Warning: [burst-capacity] target-burst-capacity 0 with scale-to-zero enabled"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(output, "Updated Knative autoscaling config"), "unexpected output: %s", output)
+
+		updated, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configAutoscaler, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "0", updated.Data["target-burst-capacity"])
+	})
+
+	t.Run("return error if revisions violate a blocking rule", func(t *testing.T) {
+		cm.Data = map[string]string{}
+		p, _ := testutil.NewTestAdminParams(cm)
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		servingClient := testutil.NewTestServingClient(newTestRevision("hello-00001", map[string]string{"autoscaling.knative.dev/metric": "rps"}, 0))
+		p.NewServingClient = func() (servingv1client.ServingV1Interface, error) {
+			return servingClient, nil
+		}
+
+		_, err := testutil.ExecuteCommand(NewAutoscalingUpdateCommand(p), "--pod-autoscaler-class", "hpa.autoscaling.knative.dev")
+		assert.ErrorContains(t, err, "[hpa-metric] revision(s) default/hello-00001 scale on metrics other than cpu and memory", err)
+	})
+
+	t.Run("skip the rules on revisions if revisions can't be listed", func(t *testing.T) {
+		cm.Data = map[string]string{}
+		p, _ := testutil.NewTestAdminParams(cm)
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		p.NewServingClient = func() (servingv1client.ServingV1Interface, error) {
+			return nil, errors.New("no serving client")
+		}
+
+		output, err := testutil.ExecuteCommand(NewAutoscalingUpdateCommand(p), "--pod-autoscaler-class", "hpa.autoscaling.knative.dev")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Warning: failed to list revisions, the rules on revisions are skipped: no serving client"), "unexpected output: %s", output)
+	})
 }
@@ -16,14 +16,17 @@ package cdc
 
 import (
 	"context"
+	"io"
 
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metav1beta1 "k8s.io/apimachinery/pkg/apis/meta/v1beta1"
+	"k8s.io/apimachinery/pkg/watch"
 	"knative.dev/client/pkg/commands/flags"
 	"knative.dev/client/pkg/printers"
 	hprinters "knative.dev/client/pkg/printers"
 	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/command/utils"
 	typev1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
 )
 
@@ -49,28 +52,51 @@ func printCdcList(cdcList *typev1alpha1.ClusterDomainClaimList, options hprinter
 func NewCdcListCommand(p *pkg.AdminParams) *cobra.Command {
 
 	cdcListFlags := flags.NewListPrintFlags(cdcListHandlers)
+	watchFlags := &utils.WatchFlags{}
 	cdcListCommand := &cobra.Command{
 		Use:   "list",
 		Short: "List cluster domain claims",
 		Long:  "List Knative cluster domain claims",
 		Example: `
   # To list all cluster domain claims
-  kn admin cdc list`,
+  kn admin cdc list
 
+  # To watch the cluster domain claims and re-render the list on changes
+  kn admin cdc list --watch`,
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return watchFlags.Validate()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := p.NewNetworkingClient()
 			if err != nil {
 				return err
 			}
-			cdcList, err := client.NetworkingV1alpha1().ClusterDomainClaims().List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
+
+			list := func(out io.Writer) (string, error) {
+				cdcList, err := client.NetworkingV1alpha1().ClusterDomainClaims().List(context.TODO(), metav1.ListOptions{})
+				if err != nil {
+					return "", err
+				}
+				return cdcList.ResourceVersion, cdcListFlags.Print(cdcList, out)
+			}
+			if !watchFlags.Watch {
+				_, err = list(cmd.OutOrStdout())
 				return err
 			}
-			err = cdcListFlags.Print(cdcList, cmd.OutOrStdout())
-			return err
+
+			lw := utils.ListWatch{
+				Kind: "ClusterDomainClaim",
+				List: list,
+				Watch: func(ctx context.Context, resourceVersion string) (watch.Interface, error) {
+					return client.NetworkingV1alpha1().ClusterDomainClaims().Watch(ctx, metav1.ListOptions{ResourceVersion: resourceVersion})
+				},
+			}
+			return lw.Run(cmd.Context(), cmd.OutOrStdout(), watchFlags.Output)
 		},
 	}
 	cdcListFlags.HumanReadableFlags.AddFlags(cdcListCommand)
+	watchFlags.AddFlags(cdcListCommand)
 	cdcListFlags.GenericPrintFlags.OutputFlagSpecified = func() bool {
 		return false
 	}
@@ -15,13 +15,17 @@
 package cdc
 
 import (
+	"context"
+	"encoding/json"
 	"strings"
 	"testing"
 
 	"gotest.tools/v3/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 	"knative.dev/kn-plugin-admin/pkg/testutil"
 	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	nwfake "knative.dev/networking/pkg/client/clientset/versioned/fake"
 )
 
 func TestCdcListCommandWithoutKubeContext(t *testing.T) {
@@ -52,3 +56,27 @@ func TestCdcListSuccess(t *testing.T) {
 		assert.Check(t, strings.Contains(out, testNs))
 	})
 }
+
+func TestCdcListWatch(t *testing.T) {
+	p := testutil.NewTestAdminParamsWithNetworkingObjects()
+	client, err := p.NewNetworkingClient()
+	assert.NilError(t, err)
+	fake := client.(*nwfake.Clientset)
+	output, err := testutil.ExecuteWatchCommand(NewCdcListCommand(p), &fake.Fake, "clusterdomainclaims", func(w *watch.FakeWatcher) {
+		cdc := &v1alpha1.ClusterDomainClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: testDomain},
+			Spec:       v1alpha1.ClusterDomainClaimSpec{Namespace: testNs},
+		}
+		_, err := fake.NetworkingV1alpha1().ClusterDomainClaims().Create(context.TODO(), cdc, metav1.CreateOptions{})
+		assert.NilError(t, err)
+		w.Add(cdc)
+	}, "--watch", "--watch-output", "json")
+	assert.NilError(t, err)
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	assert.Equal(t, 1, len(lines), "unexpected output: %s", output)
+	event := map[string]interface{}{}
+	assert.NilError(t, json.Unmarshal([]byte(lines[0]), &event))
+	assert.Equal(t, "ADDED", event["type"])
+	assert.Equal(t, "ClusterDomainClaim", event["kind"])
+	assert.Equal(t, testNs, event["object"].(map[string]interface{})["spec"].(map[string]interface{})["namespace"])
+}
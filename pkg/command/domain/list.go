@@ -17,48 +17,74 @@ package domain
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
 	"knative.dev/client/pkg/commands/flags"
 	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/command/utils"
 )
 
 // NewDomainListCommand represents 'kn-admin domain list' command
 func NewDomainListCommand(p *pkg.AdminParams) *cobra.Command {
 
 	domainListFlags := flags.NewListPrintFlags(DomainListHandlers)
+	watchFlags := &utils.WatchFlags{}
 	domainListCommand := &cobra.Command{
 		Use:   "list",
 		Short: "List domain",
 		Long:  "List Knative custom domain",
 		Example: `
   # To list all custom domains
-  kn admin domain list`,
+  kn admin domain list
 
+  # To watch the custom domains and re-render the list on changes
+  kn admin domain list --watch`,
+
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return watchFlags.Validate()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := p.NewKubeClient()
 			if err != nil {
 				return err
 			}
 
-			domainCm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configDomain, metav1.GetOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to get ConfigMap %s in namespace %s: %+v", configDomain, knativeServing, err)
-			}
-			domainCmType := metav1.TypeMeta{
-				Kind:       "ConfigMap",
-				APIVersion: "v1",
+			list := func(out io.Writer) (string, error) {
+				domainCm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configDomain, metav1.GetOptions{})
+				if err != nil {
+					return "", fmt.Errorf("failed to get ConfigMap %s in namespace %s: %+v", configDomain, knativeServing, err)
+				}
+				domainCmType := metav1.TypeMeta{
+					Kind:       "ConfigMap",
+					APIVersion: "v1",
+				}
+				domainCm.TypeMeta = domainCmType
+				return domainCm.ResourceVersion, domainListFlags.Print(domainCm, out)
 			}
-			domainCm.TypeMeta = domainCmType
-			err = domainListFlags.Print(domainCm, cmd.OutOrStdout())
-			if err != nil {
+			if !watchFlags.Watch {
+				_, err = list(cmd.OutOrStdout())
 				return err
 			}
-			return nil
+
+			lw := utils.ListWatch{
+				Kind: "ConfigMap",
+				List: list,
+				Watch: func(ctx context.Context, resourceVersion string) (watch.Interface, error) {
+					return client.CoreV1().ConfigMaps(knativeServing).Watch(ctx, metav1.ListOptions{
+						FieldSelector:   fields.OneTermEqualSelector("metadata.name", configDomain).String(),
+						ResourceVersion: resourceVersion,
+					})
+				},
+			}
+			return lw.Run(cmd.Context(), cmd.OutOrStdout(), watchFlags.Output)
 		},
 	}
 	domainListFlags.HumanReadableFlags.AddFlags(domainListCommand)
+	watchFlags.AddFlags(domainListCommand)
 	domainListFlags.GenericPrintFlags.OutputFlagSpecified = func() bool {
 		return false
 	}
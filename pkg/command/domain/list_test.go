@@ -15,12 +15,14 @@
 package domain
 
 import (
+	"context"
 	"strings"
 	"testing"
 
 	"gotest.tools/v3/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 	"knative.dev/client/pkg/util"
 
 	"knative.dev/kn-plugin-admin/pkg/testutil"
@@ -105,3 +107,26 @@ func TestDomainListCommandNoHeader(t *testing.T) {
 		assert.Check(t, util.ContainsAll(rowsOfOutput[1], "test2.domain", "app=helloworld"))
 	})
 }
+
+func TestDomainListWatch(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configDomain,
+			Namespace: knativeServing,
+		},
+		Data: map[string]string{"dummy.domain": ""},
+	}
+	p, client := testutil.NewTestAdminParams(cm)
+	output, err := testutil.ExecuteWatchCommand(NewDomainListCommand(p), &client.Fake, "configmaps", func(w *watch.FakeWatcher) {
+		updated := cm.DeepCopy()
+		updated.Data["test.domain"] = ""
+		_, err := client.CoreV1().ConfigMaps(knativeServing).Update(context.TODO(), updated, metav1.UpdateOptions{})
+		assert.NilError(t, err)
+		w.Modify(updated)
+	}, "--watch")
+	assert.NilError(t, err)
+	sections := strings.Split(output, "MODIFIED ConfigMap knative-serving/config-domain")
+	assert.Equal(t, 2, len(sections), "unexpected output: %s", output)
+	assert.Check(t, !strings.Contains(sections[0], "test.domain"), "unexpected output: %s", output)
+	assert.Check(t, util.ContainsAll(sections[1], "CUSTOM-DOMAIN", "dummy.domain", "test.domain"), "unexpected output: %s", output)
+}
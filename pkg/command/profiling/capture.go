@@ -0,0 +1,208 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profiling
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"knative.dev/kn-plugin-admin/pkg"
+)
+
+// errDiskUsageExceeded is returned when the saved profiling data exceeds the disk usage limit
+var errDiskUsageExceeded = errors.New("the profiling data exceeds the disk usage limit")
+
+// diskQuota caps the total size of the profiling data saved by all the pods and captures
+type diskQuota struct {
+	mu       sync.Mutex
+	limit    int64
+	used     int64
+	exceeded bool
+}
+
+// reserve counts n bytes more, or returns errDiskUsageExceeded if the limit is exceeded, 0 limit means no limit
+func (q *diskQuota) reserve(n int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.limit > 0 && q.used+n > q.limit {
+		q.exceeded = true
+		return errDiskUsageExceeded
+	}
+	q.used += n
+	return nil
+}
+
+// release stops counting n bytes, e.g: the bytes of a removed file
+func (q *diskQuota) release(n int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.used -= n
+}
+
+// isExceeded returns true if any data is rejected by the limit
+func (q *diskQuota) isExceeded() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.exceeded
+}
+
+// quotaWriter writes to the file only if the quota allows, it records the written bytes
+type quotaWriter struct {
+	w       io.Writer
+	quota   *diskQuota
+	written int64
+}
+
+func (w *quotaWriter) Write(p []byte) (int, error) {
+	if err := w.quota.reserve(int64(len(p))); err != nil {
+		return 0, err
+	}
+	n, err := w.w.Write(p)
+	w.written += int64(n)
+	w.quota.release(int64(len(p) - n))
+	return n, err
+}
+
+// saveProfile downloads the profile type data to the file path, the file is removed if the download fails
+func saveProfile(downloader ProfileDownloader, op profileTypeOption, path string, quota *diskQuota) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	options := []DownloadOptions{}
+	if op.downloadOption != nil {
+		options = append(options, op.downloadOption)
+	}
+	w := &quotaWriter{w: f, quota: quota}
+	err = downloader.Download(op.profileType, w, options...)
+	f.Close()
+	if err != nil {
+		os.Remove(path)
+		quota.release(w.written)
+	}
+	return err
+}
+
+// podSession keeps the port forwarding to a pod across the captures, the port forwarding is connected again
+// once it's broken, e.g: the pod is restarted
+type podSession struct {
+	podName    string
+	namespace  string
+	end        chan struct{}
+	downloader ProfileDownloader
+	// downloaded is true if any profiling data is downloaded over the current port forwarding
+	downloaded bool
+}
+
+// connect starts the port forwarding to the pod if it isn't connected
+func (s *podSession) connect(p *pkg.AdminParams) error {
+	if s.downloader != nil {
+		return nil
+	}
+	end := make(chan struct{})
+	downloader, err := newDownloaderFunc(p, s.podName, s.namespace, end)
+	if err != nil {
+		return err
+	}
+	s.end, s.downloader, s.downloaded = end, downloader, false
+	return nil
+}
+
+// close stops the port forwarding to the pod
+func (s *podSession) close() {
+	if s.downloader != nil {
+		close(s.end)
+		s.end, s.downloader = nil, nil
+	}
+}
+
+// capture downloads the profile types data of the pod and saves them to the folder, the sequence number of the
+// capture is part of the file names if it's positive. If the port forwarding used by the former captures is broken,
+// the pod is connected again and the download is retried once
+func (s *podSession) capture(p *pkg.AdminParams, progress *progressPrinter, profileTypes map[string]profileTypeOption, saveTo string, seq int, quota *diskQuota) error {
+	if err := s.connect(p); err != nil {
+		return err
+	}
+
+	// iterates specified profile types to download data
+	for k, v := range profileTypes {
+		duration := ""
+		filename := s.podName + "_" + k
+		if t, ok := v.downloadOption.(OptionProfilingTime); ok {
+			seconds := int64(time.Duration(t) / time.Second)
+			duration = fmt.Sprintf("%d second(s) ", seconds)
+			filename += "_" + durationDescription(seconds)
+		}
+		if seq > 0 {
+			filename += fmt.Sprintf("_%04d", seq)
+		}
+		filename += "_" + time.Now().Format("20060102150405")
+		dataFilePath := filepath.Join(saveTo, filename)
+
+		progress.Printf("[%s] Saving %s%s profiling data to %s\n", s.podName, duration, k, dataFilePath)
+		err := saveProfile(s.downloader, v, dataFilePath, quota)
+		if err != nil && s.downloaded && !errors.Is(err, errDiskUsageExceeded) {
+			progress.Printf("[%s] Reconnecting to the pod: %v\n", s.podName, err)
+			s.close()
+			if err = s.connect(p); err == nil {
+				err = saveProfile(s.downloader, v, dataFilePath, quota)
+			}
+		}
+		if err != nil {
+			// the port forwarding may be broken, so the pod is connected again by the next capture
+			s.close()
+			return err
+		}
+		s.downloaded = true
+	}
+	return nil
+}
+
+// reconcileSessions returns the sessions of the pods, the sessions of the pods still present are kept so their port
+// forwarding is reused, the sessions of new pods are added and the sessions of the pods which are gone are closed
+func reconcileSessions(progress *progressPrinter, sessions []*podSession, pods *corev1.PodList, namespace string) []*podSession {
+	current := make(map[string]*podSession, len(sessions))
+	for _, s := range sessions {
+		current[s.podName] = s
+	}
+	desired := make([]*podSession, 0, len(pods.Items))
+	for i := range pods.Items {
+		name := pods.Items[i].Name
+		s, ok := current[name]
+		if ok {
+			delete(current, name)
+		} else {
+			s = &podSession{podName: name, namespace: namespace}
+			if len(sessions) > 0 {
+				progress.Printf("[%s] Found new pod, starting to profile it\n", name)
+			}
+		}
+		desired = append(desired, s)
+	}
+	for _, s := range sessions {
+		if _, gone := current[s.podName]; gone {
+			progress.Printf("[%s] The pod is gone, stopped profiling it\n", s.podName)
+			s.close()
+		}
+	}
+	return desired
+}
@@ -0,0 +1,170 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profiling
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// restartingDownloader writes the data, and fails once the pod is restarted after the given number of downloads
+type restartingDownloader struct {
+	data      string
+	downloads int
+	restartAt int
+	ended     <-chan struct{}
+}
+
+func (d *restartingDownloader) Download(t ProfileType, output io.Writer, options ...DownloadOptions) error {
+	d.downloads++
+	if d.restartAt > 0 && d.downloads > d.restartAt {
+		return errors.New("connection refused")
+	}
+	_, err := io.WriteString(output, d.data)
+	return err
+}
+
+func TestDiskQuota(t *testing.T) {
+	quota := &diskQuota{limit: 10}
+	buf := &bytes.Buffer{}
+	w := &quotaWriter{w: buf, quota: quota}
+	n, err := w.Write([]byte("12345678"))
+	assert.NilError(t, err)
+	assert.Equal(t, 8, n)
+	_, err = w.Write([]byte("123"))
+	assert.Check(t, errors.Is(err, errDiskUsageExceeded))
+	assert.Check(t, quota.isExceeded())
+	assert.Equal(t, int64(8), w.written)
+	assert.Equal(t, "12345678", buf.String())
+
+	quota.release(w.written)
+	assert.NilError(t, quota.reserve(10))
+
+	unlimited := &diskQuota{}
+	assert.NilError(t, unlimited.reserve(1<<40))
+	assert.Check(t, !unlimited.isExceeded())
+}
+
+func TestSaveProfile(t *testing.T) {
+	dir := t.TempDir()
+	t.Run("save the profiling data", func(t *testing.T) {
+		quota := &diskQuota{limit: 100}
+		path := filepath.Join(dir, "heap")
+		err := saveProfile(&restartingDownloader{data: "heap-data"}, profileTypeOption{profileType: ProfileTypeHeap}, path, quota)
+		assert.NilError(t, err)
+		data, err := os.ReadFile(path)
+		assert.NilError(t, err)
+		assert.Equal(t, "heap-data", string(data))
+		assert.Equal(t, int64(9), quota.used)
+	})
+
+	t.Run("remove the file exceeding the disk usage limit", func(t *testing.T) {
+		quota := &diskQuota{limit: 5}
+		path := filepath.Join(dir, "block")
+		err := saveProfile(&restartingDownloader{data: "block-data"}, profileTypeOption{profileType: ProfileTypeBlock}, path, quota)
+		assert.Check(t, errors.Is(err, errDiskUsageExceeded))
+		_, err = os.Stat(path)
+		assert.Check(t, os.IsNotExist(err))
+		assert.Equal(t, int64(0), quota.used)
+	})
+}
+
+func TestPodSessionCapture(t *testing.T) {
+	dir := t.TempDir()
+	connections := []*restartingDownloader{}
+	newDownloaderFunc = func(_ RestConfigGetter, _ string, _ string, end <-chan struct{}) (ProfileDownloader, error) {
+		// the pod is restarted after the second download over the first port forwarding
+		d := &restartingDownloader{data: "data", ended: end}
+		if len(connections) == 0 {
+			d.restartAt = 2
+		}
+		connections = append(connections, d)
+		return d, nil
+	}
+	defer func() { newDownloaderFunc = NewDownloader }()
+
+	out := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+	progress := &progressPrinter{cmd: cmd}
+	profileTypes := map[string]profileTypeOption{heapFlagName: {profileType: ProfileTypeHeap}}
+	quota := &diskQuota{}
+	s := &podSession{podName: "activator-0", namespace: knNamespace}
+	defer s.close()
+
+	for seq := 1; seq <= 3; seq++ {
+		assert.NilError(t, s.capture(nil, progress, profileTypes, dir, seq, quota))
+	}
+	// the port forwarding is reused, and connected again once the pod is restarted
+	assert.Equal(t, 2, len(connections))
+	assert.Equal(t, 3, connections[0].downloads)
+	assert.Equal(t, 1, connections[1].downloads)
+	select {
+	case <-connections[0].ended:
+	default:
+		t.Error("the broken port forwarding should be closed")
+	}
+	assert.Check(t, strings.Contains(out.String(), "[activator-0] Reconnecting to the pod: connection refused"), "unexpected output: %s", out.String())
+
+	for _, seq := range []string{"0001", "0002", "0003"} {
+		files, err := filepath.Glob(filepath.Join(dir, "activator-0_heap_"+seq+"_*"))
+		assert.NilError(t, err)
+		assert.Equal(t, 1, len(files), "expected a file of capture %s", seq)
+	}
+}
+
+func TestReconcileSessions(t *testing.T) {
+	out := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+	progress := &progressPrinter{cmd: cmd}
+	newPods := func(names ...string) *corev1.PodList {
+		pods := &corev1.PodList{}
+		for _, name := range names {
+			pods.Items = append(pods.Items, corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		}
+		return pods
+	}
+
+	sessions := reconcileSessions(progress, nil, newPods("activator-0", "activator-1"), knNamespace)
+	assert.Equal(t, 2, len(sessions))
+	assert.Equal(t, "", out.String(), "the initial pods should not be reported as new")
+
+	end := make(chan struct{})
+	kept, gone := sessions[1], sessions[0]
+	gone.end, gone.downloader = end, &restartingDownloader{}
+	sessions = reconcileSessions(progress, sessions, newPods("activator-1", "activator-2"), knNamespace)
+	assert.Equal(t, 2, len(sessions))
+	assert.Check(t, sessions[0] == kept, "the session of the pod still present should be kept")
+	assert.Equal(t, "activator-2", sessions[1].podName)
+	assert.Equal(t, knNamespace, sessions[1].namespace)
+	select {
+	case <-end:
+	default:
+		t.Error("the port forwarding to the pod which is gone should be closed")
+	}
+	assert.Check(t, strings.Contains(out.String(), "[activator-0] The pod is gone, stopped profiling it"), "unexpected output: %s", out.String())
+	assert.Check(t, strings.Contains(out.String(), "[activator-2] Found new pod, starting to profile it"), "unexpected output: %s", out.String())
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profiling
+
+import (
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	eventingNamespace = "knative-eventing"
+	// operatorNamespace is the namespace of the upstream operator.yaml release
+	operatorNamespace = "default"
+)
+
+// component is a known Knative component which can be profiled
+type component struct {
+	// project is the Knative project the component belongs to, e.g: 'Knative Serving'
+	project string
+	// namespace is the default namespace the component is installed in
+	namespace string
+	// selector is the label selector of the component pods
+	selector string
+}
+
+// knownComponents are the components which can be used as --target, the pods are found by the label selector
+// in the namespace of the component
+var knownComponents = map[string]component{
+	// Knative Serving
+	"activator":             servingComponent("app=activator"),
+	"autoscaler":            servingComponent("app=autoscaler"),
+	"autoscaler-hpa":        servingComponent("app=autoscaler-hpa"),
+	"controller":            servingComponent("app=controller"),
+	"webhook":               servingComponent("app=webhook"),
+	"domain-mapping":        servingComponent("app=domain-mapping"),
+	"domainmapping-webhook": servingComponent("app=domainmapping-webhook"),
+	// Knative networking layers, installed in the Knative Serving namespace
+	"net-kourier-controller":     servingComponent("app=net-kourier-controller"),
+	"net-istio-controller":       servingComponent("app=net-istio-controller"),
+	"net-istio-webhook":          servingComponent("app=net-istio-webhook"),
+	"net-certmanager-controller": servingComponent("app=net-certmanager-controller"),
+	// Knative Eventing
+	"eventing-controller":  eventingComponent("app=eventing-controller"),
+	"eventing-webhook":     eventingComponent("app=eventing-webhook"),
+	"imc-controller":       eventingComponent("messaging.knative.dev/channel=in-memory-channel,messaging.knative.dev/role=controller"),
+	"imc-dispatcher":       eventingComponent("messaging.knative.dev/channel=in-memory-channel,messaging.knative.dev/role=dispatcher"),
+	"mt-broker-controller": eventingComponent("app=mt-broker-controller"),
+	"mt-broker-ingress":    eventingComponent("eventing.knative.dev/brokerRole=ingress"),
+	"mt-broker-filter":     eventingComponent("eventing.knative.dev/brokerRole=filter"),
+	// Knative Operator
+	"knative-operator": {project: "Knative Operator", namespace: operatorNamespace, selector: "name=knative-operator"},
+}
+
+// componentAliases are the short names of the known components
+var componentAliases = map[string]string{
+	"kourier":        "net-kourier-controller",
+	"net-kourier":    "net-kourier-controller",
+	"net-istio":      "net-istio-controller",
+	"certmanager":    "net-certmanager-controller",
+	"broker-ingress": "mt-broker-ingress",
+	"broker-filter":  "mt-broker-filter",
+	"operator":       "knative-operator",
+}
+
+func servingComponent(selector string) component {
+	return component{project: "Knative Serving", namespace: knNamespace, selector: selector}
+}
+
+func eventingComponent(selector string) component {
+	return component{project: "Knative Eventing", namespace: eventingNamespace, selector: selector}
+}
+
+// lookupComponent returns the known component of the name or alias
+func lookupComponent(name string) (component, bool) {
+	if canonical, ok := componentAliases[name]; ok {
+		name = canonical
+	}
+	c, ok := knownComponents[name]
+	return c, ok
+}
+
+// projectOf returns the Knative project installed in the namespace, or 'Knative' if the namespace is unknown
+// or shared with other workloads, e.g: the Knative Operator in namespace 'default'
+func projectOf(namespace string) string {
+	if namespace == metav1.NamespaceDefault {
+		return "Knative"
+	}
+	for _, c := range knownComponents {
+		if c.namespace == namespace {
+			return c.project
+		}
+	}
+	return "Knative"
+}
+
+// describeKnownComponents describes the known components and their aliases, grouped by namespace
+func describeKnownComponents() string {
+	aliases := map[string][]string{}
+	for alias, name := range componentAliases {
+		aliases[name] = append(aliases[name], alias)
+	}
+	names := make([]string, 0, len(knownComponents))
+	for name := range knownComponents {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := knownComponents[names[i]], knownComponents[names[j]]
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		return names[i] < names[j]
+	})
+
+	sb := strings.Builder{}
+	for _, name := range names {
+		sb.WriteString("  " + name)
+		if len(aliases[name]) > 0 {
+			sort.Strings(aliases[name])
+			sb.WriteString(" (" + strings.Join(aliases[name], ", ") + ")")
+		}
+		sb.WriteString(" in namespace " + knownComponents[name].namespace + "\n")
+	}
+	return sb.String()
+}
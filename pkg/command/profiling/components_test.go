@@ -0,0 +1,59 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profiling
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestLookupComponent(t *testing.T) {
+	c, ok := lookupComponent("mt-broker-ingress")
+	assert.Check(t, ok)
+	assert.Equal(t, eventingNamespace, c.namespace)
+	assert.Equal(t, "eventing.knative.dev/brokerRole=ingress", c.selector)
+
+	c, ok = lookupComponent("kourier")
+	assert.Check(t, ok)
+	assert.Equal(t, knNamespace, c.namespace)
+	assert.Equal(t, "app=net-kourier-controller", c.selector)
+
+	_, ok = lookupComponent("activator-586d468c99-w59cm")
+	assert.Check(t, !ok)
+
+	for alias, name := range componentAliases {
+		_, ok := knownComponents[name]
+		assert.Check(t, ok, "alias %s refers to unknown component %s", alias, name)
+	}
+}
+
+func TestProjectOf(t *testing.T) {
+	assert.Equal(t, "Knative Serving", projectOf(knNamespace))
+	assert.Equal(t, "Knative Eventing", projectOf(eventingNamespace))
+	// the Knative Operator shares namespace 'default' with other workloads
+	assert.Equal(t, "Knative", projectOf(operatorNamespace))
+	assert.Equal(t, "Knative", projectOf("default"))
+}
+
+func TestDescribeKnownComponents(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(describeKnownComponents()), "\n")
+	assert.Equal(t, len(knownComponents), len(lines))
+	assert.Equal(t, "knative-operator (operator) in namespace default", strings.TrimSpace(lines[0]))
+	assert.Equal(t, "eventing-controller in namespace knative-eventing", strings.TrimSpace(lines[1]))
+	assert.Check(t, strings.Contains(describeKnownComponents(), "  mt-broker-ingress (broker-ingress) in namespace knative-eventing\n"))
+	assert.Check(t, strings.Contains(describeKnownComponents(), "  net-kourier-controller (kourier, net-kourier) in namespace knative-serving\n"))
+}
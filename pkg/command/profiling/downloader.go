@@ -31,7 +31,6 @@ import (
 
 const (
 	pprofPort  uint32 = 8008
-	localPort  uint32 = 18008
 	secondsKey string = "seconds"
 )
 
@@ -68,9 +67,11 @@ type Downloader struct {
 	podName    string
 	namespace  string
 	readyCh    chan struct{} // closed by portforward.ForwardPorts() when connection is ready
-	errorCh    chan error
+	errorCh    chan error    // buffered so ForwardPorts() can return its error without a reader
 	restConfig *rest.Config
+	// localPort is the local port forwarded to the pod, 0 means a free port is allocated by the forwarder
 	localPort  uint32
+	forwarder  *portforward.PortForwarder
 	client     *http.Client
 	dialerFunc func(upgrader spdy.Upgrader, client *http.Client, method string, url *url.URL) httpstream.Dialer
 }
@@ -95,9 +96,8 @@ func NewDownloader(cfgGetter RestConfigGetter, podName, namespace string, endCh
 		podName:    podName,
 		namespace:  namespace,
 		readyCh:    make(chan struct{}),
-		errorCh:    make(chan error),
+		errorCh:    make(chan error, 1),
 		restConfig: cfg,
-		localPort:  18008,
 		client:     http.DefaultClient,
 		dialerFunc: spdy.NewDialer,
 	}
@@ -130,6 +130,7 @@ func (d *Downloader) connect(endCh <-chan struct{}) error {
 	if err != nil {
 		return err
 	}
+	d.forwarder = fw
 	go func() {
 		defer close(d.errorCh)
 		// if the func ForwardPorts() returns, the connection should not be available.
@@ -148,10 +149,13 @@ func (d *Downloader) Download(t ProfileType, output io.Writer, options ...Downlo
 	select {
 	case <-d.readyCh:
 		// connection ready
-		var err error
+		port, err := d.forwardedPort()
+		if err != nil {
+			return err
+		}
 		url := &url.URL{
 			Scheme: "http",
-			Host:   fmt.Sprintf("127.0.0.1:%d", d.localPort),
+			Host:   fmt.Sprintf("127.0.0.1:%d", port),
 			Path:   fmt.Sprintf("/debug/pprof/%s", ProfileEndpoints[t]),
 		}
 		go func() {
@@ -193,7 +197,28 @@ func (d *Downloader) Download(t ProfileType, output io.Writer, options ...Downlo
 			return err
 		}
 		return nil
-	case err := <-d.errorCh:
+	case err, ok := <-d.errorCh:
+		// errorCh is closed once the port forwarding is closed, e.g: the pod is restarted
+		if !ok || err == nil {
+			return fmt.Errorf("the port forwarding to pod %s is closed", d.podName)
+		}
 		return err
 	}
 }
+
+// forwardedPort returns the local port forwarded to the pod, the port allocated by the forwarder
+// is read once the connection is ready
+func (d *Downloader) forwardedPort() (uint32, error) {
+	if d.localPort != 0 || d.forwarder == nil {
+		return d.localPort, nil
+	}
+	ports, err := d.forwarder.GetPorts()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the forwarded port of pod %s: %v", d.podName, err)
+	}
+	if len(ports) == 0 {
+		return 0, fmt.Errorf("no port is forwarded to pod %s", d.podName)
+	}
+	d.localPort = uint32(ports[0].Local)
+	return d.localPort, nil
+}
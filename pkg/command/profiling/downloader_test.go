@@ -102,7 +102,7 @@ func TestProfileDownloader_connect(t *testing.T) {
 			podName:   "pod-1",
 			namespace: "mynamespace",
 			readyCh:   make(chan struct{}),
-			errorCh:   make(chan error),
+			errorCh:   make(chan error, 1),
 			client:    http.DefaultClient,
 			localPort: 12345,
 			restConfig: &rest.Config{
@@ -126,13 +126,44 @@ func TestProfileDownloader_connect(t *testing.T) {
 		assert.NilError(t, err)
 	})
 
+	t.Run("connect to a free local port", func(t *testing.T) {
+		d := &Downloader{
+			podName:   "pod-1",
+			namespace: "mynamespace",
+			readyCh:   make(chan struct{}),
+			errorCh:   make(chan error, 1),
+			client:    http.DefaultClient,
+			restConfig: &rest.Config{
+				Host: "http://localhost:12345",
+			},
+			dialerFunc: fakeDialerFunc(t,
+				&url.URL{
+					Scheme: "http",
+					Host:   "localhost:12345",
+					Path:   "/api/v1/namespaces/mynamespace/pods/pod-1/portforward",
+				},
+				nil),
+		}
+		ch := make(chan struct{})
+		err := d.connect(ch)
+		assert.NilError(t, err)
+		<-d.readyCh
+		port, err := d.forwardedPort()
+		assert.NilError(t, err)
+		assert.Check(t, port != 0, "expected a local port allocated by the forwarder")
+		assert.Equal(t, port, d.localPort)
+		close(ch)
+		err = <-d.errorCh
+		assert.NilError(t, err)
+	})
+
 	t.Run("dial error", func(t *testing.T) {
 		exceptDialError := errors.New("dial error")
 		d := &Downloader{
 			podName:   "pod-1",
 			namespace: "mynamespace",
 			readyCh:   make(chan struct{}),
-			errorCh:   make(chan error),
+			errorCh:   make(chan error, 1),
 			client:    http.DefaultClient,
 			localPort: 12345,
 			restConfig: &rest.Config{
@@ -183,7 +214,7 @@ func TestProfileDownload(t *testing.T) {
 
 		d := &Downloader{
 			readyCh:   make(chan struct{}),
-			errorCh:   make(chan error),
+			errorCh:   make(chan error, 1),
 			client:    http.DefaultClient,
 			localPort: uint32(port),
 		}
@@ -219,7 +250,7 @@ func TestProfileDownload(t *testing.T) {
 
 		d := &Downloader{
 			readyCh:   make(chan struct{}),
-			errorCh:   make(chan error),
+			errorCh:   make(chan error, 1),
 			client:    http.DefaultClient,
 			localPort: uint32(port),
 		}
@@ -238,7 +269,7 @@ func TestProfileDownload(t *testing.T) {
 
 		d := &Downloader{
 			readyCh: make(chan struct{}),
-			errorCh: make(chan error),
+			errorCh: make(chan error, 1),
 			client:  http.DefaultClient,
 		}
 		errChan := make(chan error)
@@ -262,7 +293,7 @@ func TestProfileDownload(t *testing.T) {
 	t.Run("error occoured while download is not started", func(t *testing.T) {
 		d := &Downloader{
 			readyCh: make(chan struct{}),
-			errorCh: make(chan error),
+			errorCh: make(chan error, 1),
 			client:  http.DefaultClient,
 		}
 		errChan := make(chan error)
@@ -279,6 +310,18 @@ func TestProfileDownload(t *testing.T) {
 		assert.Error(t, err, e.Error())
 	})
 
+	t.Run("port forwarding is closed before download", func(t *testing.T) {
+		d := &Downloader{
+			podName: "pod-1",
+			readyCh: make(chan struct{}),
+			errorCh: make(chan error, 1),
+			client:  http.DefaultClient,
+		}
+		close(d.errorCh)
+		err := d.Download(ProfileTypeHeap, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "the port forwarding to pod pod-1 is closed")
+	})
+
 	t.Run("request canceled while download is started", func(t *testing.T) {
 		downloadData := []byte("some-binary-data")
 		server := httptest.NewServer(http.HandlerFunc(
@@ -300,7 +343,7 @@ func TestProfileDownload(t *testing.T) {
 
 		d := &Downloader{
 			readyCh:   make(chan struct{}),
-			errorCh:   make(chan error),
+			errorCh:   make(chan error, 1),
 			client:    http.DefaultClient,
 			localPort: uint32(port),
 		}
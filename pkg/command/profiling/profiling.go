@@ -16,20 +16,24 @@ package profiling
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"knative.dev/kn-plugin-admin/pkg"
 	"knative.dev/kn-plugin-admin/pkg/command/utils"
+	"knative.dev/serving/pkg/apis/serving"
 )
 
 const (
@@ -48,9 +52,35 @@ const (
 
   # To download all available profiling data for specified pod activator-5979f56548
   kn admin profiling --target activator-5979f56548 --all
+
+  # To download 30 seconds cpu profiling data of activator from at most 10 pods at the same time
+  kn admin profiling --target activator --cpu 30s --parallelism 10
+
+  # To enable profiling of Knative Eventing and download heap profiling data of the in-memory channel dispatcher
+  kn admin profiling --enable --target imc-dispatcher
+  kn admin profiling --target imc-dispatcher --heap
+
+  # To download goroutine profiling data of the pods matching a label selector in namespace 'knative-serving'
+  kn admin profiling --selector app=net-kourier-controller --goroutine -n knative-serving
+
+  # To download 30 seconds cpu profiling data of the queue-proxy sidecars of service 'hello' in namespace 'default'
+  kn admin profiling --service hello -n default --cpu 30s
+
+  # To download heap profiling data of activator every 5 minutes for an hour, and save at most 500Mi of data
+  kn admin profiling --target activator --heap --interval 5m --count 12 --max-disk-usage 500Mi
+
+  # To download heap profiling data of activator every 30 minutes until 8 o'clock
+  kn admin profiling --target activator --heap --interval 30m --until 08:00
 `
 
-	targetFlagUsgae       = "The profiling target. It can be a Knative Serving component name or a specific pod name, e.g: 'activator' or 'activator-586d468c99-w59cm'"
+	targetFlagUsgae       = "The profiling target. It can be a known Knative component name or alias, or a specific pod name, e.g: 'activator', 'imc-dispatcher' or 'activator-586d468c99-w59cm'"
+	namespaceFlagUsage    = "The namespace of the profiling target, if not specified, the namespace of the known component or 'knative-serving' is used, or 'default' for '--service'"
+	intervalFlagUsage     = "Download the profiling data periodically at the interval, e.g: '5m', it requires '--count' or '--until'"
+	countFlagUsage        = "The number of periodic downloads, it requires '--interval'"
+	untilFlagUsage        = "Download the profiling data periodically until the time, in RFC3339 format e.g: '2020-07-25T08:00:00Z' or the local time of the day e.g: '08:00', it requires '--interval'"
+	maxDiskUsageFlagUsage = "The maximum total size of the downloaded profiling data, e.g: '500Mi', downloading stops once it's reached, '0' means no limit"
+	serviceFlagUsage      = "The Knative Service whose queue-proxy sidecars to profile, it can't be used with '--target' or '--selector'"
+	selectorFlagUsage     = "The label selector of the pods to profile, e.g: 'app=net-kourier-controller', it can't be used with '--target'"
 	saveToFlagUsage       = "The path to save the downloaded profiling data, if not speicifed, the data will be saved in current working folder"
 	allFlagUsage          = "Download all available profiling data"
 	cpuFlagUsage          = "Download cpu profiling data, you can specify a profiling data duration with 's' for second(s), 'm' for minute(s) and 'h' for hour(s), e.g: '1m' for one minute"
@@ -61,6 +91,7 @@ const (
 	mutexFlagUsage        = "Download holders of contended mutexes data"
 	goroutineFlagUsage    = "Download stack traces of all current goroutines data"
 	threadCreateFlagUsage = "Download stack traces that led to the creation of new OS threads data"
+	parallelismFlagUsage  = "The maximum number of pods to download profiling data from at the same time"
 
 	cpuFlagName          = "cpu"
 	heapFlagName         = "heap"
@@ -70,10 +101,23 @@ const (
 	mutexFlagName        = "mutex"
 	goroutineFlagName    = "goroutine"
 	threadCreateFlagName = "thread-create"
+	parallelismFlagName  = "parallelism"
+	intervalFlagName     = "interval"
+	countFlagName        = "count"
+	untilFlagName        = "until"
+	maxDiskUsageFlagName = "max-disk-usage"
 	knNamespace          = "knative-serving"
 	obsConfigMap         = "config-observability"
 	defaultDuration      = 5
 	defaultProfilingTime = OptionProfilingTime(defaultDuration * time.Second)
+	defaultParallelism   = 4
+	defaultMaxDiskUsage  = "1Gi"
+	defaultNamespace     = "default"
+	// queueContainerName is the name of the queue-proxy sidecar container in the revision pods
+	queueContainerName = "queue-proxy"
+	// queueProfilingPortName is the name of the queue-proxy profiling port, which is only added to the revision pods
+	// deployed while profiling is enabled
+	queueProfilingPortName = "profiling-port"
 )
 
 // profilingFlags defines flag values for profiling command
@@ -81,6 +125,9 @@ type profilingFlags struct {
 	enable              bool
 	disable             bool
 	target              string
+	namespace           string
+	selector            string
+	service             string
 	saveTo              string
 	allProfiles         bool
 	cpuProfile          string
@@ -91,6 +138,15 @@ type profilingFlags struct {
 	mutexProfile        bool
 	goroutineProfile    bool
 	threadCreateProfile bool
+	parallelism         int
+	interval            time.Duration
+	count               int
+	until               string
+	maxDiskUsage        string
+	// untilTime is parsed from until
+	untilTime time.Time
+	// diskUsageLimit is parsed from maxDiskUsage
+	diskUsageLimit int64
 }
 
 // profileTypeOption is a helper struct to download profile type data
@@ -109,8 +165,18 @@ func NewProfilingCommand(p *pkg.AdminParams) *cobra.Command {
 	var profilingCmd = &cobra.Command{
 		Use:     "profiling",
 		Aliases: []string{"prof"},
-		Short:   "Profiling Knative Serving components",
-		Long:    `Enable Knative Serving components profiling and download profiling data`,
+		Short:   "Profiling Knative components",
+		Long: `Enable Knative components profiling and download profiling data
+
+The profiling is enabled or disabled in the ConfigMap config-observability of the namespace of the target, or the
+namespace specified by '--namespace'. The known components and their aliases are:
+` + describeKnownComponents() + `
+Other targets are found by the label 'app=<target>' or the pod name in namespace 'knative-serving', or the namespace
+specified by '--namespace'.
+
+The queue-proxy sidecars of the revision pods of a Knative Service are profiled by '--service', which requires the
+profiling of Knative Serving enabled. The revisions deployed before the profiling is enabled are reported, they need
+to be restarted to expose the profiling port.`,
 		Example: profilingExample,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			flags := cmd.Flags()
@@ -123,8 +189,14 @@ func NewProfilingCommand(p *pkg.AdminParams) *cobra.Command {
 			isEnableSet := flags.Changed("enable")
 			isDisableSet := flags.Changed("disable")
 			isTargetSet := flags.Changed("target")
+			isNamespaceSet := flags.Changed("namespace")
+			isSelectorSet := flags.Changed("selector")
+			isServiceSet := flags.Changed("service")
 			isSaveToSet := flags.Changed("save-to")
 			isAllProfilesSet := flags.Changed("all")
+			isParallelismSet := flags.Changed(parallelismFlagName)
+			isPeriodicSet := (flags.Changed(intervalFlagName) || flags.Changed(countFlagName) || flags.Changed(untilFlagName) ||
+				flags.Changed(maxDiskUsageFlagName))
 			isProfileTypeSet := (flags.Changed(cpuFlagName) || flags.Changed(heapFlagName) || flags.Changed(blockFlagName) ||
 				flags.Changed(traceFlagName) || flags.Changed(memAllocsFlagName) || flags.Changed(mutexFlagName) ||
 				flags.Changed(goroutineFlagName) || flags.Changed(threadCreateFlagName))
@@ -134,20 +206,43 @@ func NewProfilingCommand(p *pkg.AdminParams) *cobra.Command {
 				return fmt.Errorf("flags '--enable' and '--disable' can not be used together")
 			}
 
-			// enable or disable can't be used with other flags
-			if (isEnableSet || isDisableSet) && (isTargetSet || isProfileTypeSet || isAllProfilesSet || isSaveToSet) {
+			// enable or disable can't be used with other flags than --namespace and the --target of a known component
+			if (isEnableSet || isDisableSet) && (isSelectorSet || isServiceSet || isProfileTypeSet || isAllProfilesSet || isSaveToSet || isParallelismSet || isPeriodicSet) {
 				return fmt.Errorf("flag '--enable' or '--disable' can not be used with other flags")
 			}
+			if (isEnableSet || isDisableSet) && isTargetSet {
+				if _, ok := lookupComponent(pflags.target); !ok {
+					return fmt.Errorf("flag '--enable' or '--disable' can only be used with the '--target' of a known component, use '--namespace' instead")
+				}
+			}
+			if isEnableSet || isDisableSet {
+				return nil
+			}
+
+			// --target, --selector and --service can't be used together
+			if isTargetSet && isSelectorSet {
+				return fmt.Errorf("flags '--target' and '--selector' can not be used together")
+			}
+			if isServiceSet && (isTargetSet || isSelectorSet) {
+				return fmt.Errorf("flag '--service' can not be used with '--target' or '--selector'")
+			}
 
 			// --target flag is needed
-			if !isTargetSet && (isProfileTypeSet || isAllProfilesSet || isSaveToSet) {
-				return fmt.Errorf("requires '--target' flag")
+			if !isTargetSet && !isSelectorSet && !isServiceSet && (isProfileTypeSet || isAllProfilesSet || isSaveToSet || isParallelismSet || isPeriodicSet || isNamespaceSet) {
+				return fmt.Errorf("requires '--target' flag, '--selector' flag or '--service' flag")
 			}
 
 			// --profile-type is needed
-			if !isProfileTypeSet && !isAllProfilesSet && (isTargetSet || isSaveToSet) {
+			if !isProfileTypeSet && !isAllProfilesSet && (isTargetSet || isSelectorSet || isServiceSet || isSaveToSet || isParallelismSet || isPeriodicSet) {
 				return fmt.Errorf("requires '--all' or a specific profiling type flag")
 			}
+			if err := pflags.validatePeriodic(flags.Changed(intervalFlagName), time.Now()); err != nil {
+				return err
+			}
+
+			if pflags.parallelism < 1 {
+				return fmt.Errorf("invalid '--parallelism' %d, it must be at least 1", pflags.parallelism)
+			}
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -155,9 +250,9 @@ func NewProfilingCommand(p *pkg.AdminParams) *cobra.Command {
 			if flags.NFlag() < 1 {
 				return nil
 			} else if flags.Changed("enable") {
-				return configProfiling(p, cmd, true)
+				return configProfiling(p, cmd, pflags.profilingNamespace(), true)
 			} else if flags.Changed("disable") {
-				return configProfiling(p, cmd, false)
+				return configProfiling(p, cmd, pflags.profilingNamespace(), false)
 			} else {
 				return downloadProfileData(p, cmd, &pflags)
 			}
@@ -165,9 +260,16 @@ func NewProfilingCommand(p *pkg.AdminParams) *cobra.Command {
 	}
 
 	flags := profilingCmd.Flags()
-	flags.BoolVar(&pflags.enable, "enable", false, "Enable Knative Serving profiling")
-	flags.BoolVar(&pflags.disable, "disable", false, "Disable Knative Serving profiling")
+	flags.BoolVar(&pflags.enable, "enable", false, "Enable Knative profiling")
+	flags.BoolVar(&pflags.disable, "disable", false, "Disable Knative profiling")
 	flags.StringVarP(&pflags.target, "target", "t", "", targetFlagUsgae)
+	flags.StringVarP(&pflags.namespace, "namespace", "n", "", namespaceFlagUsage)
+	flags.StringVarP(&pflags.selector, "selector", "l", "", selectorFlagUsage)
+	flags.StringVar(&pflags.service, "service", "", serviceFlagUsage)
+	flags.DurationVar(&pflags.interval, intervalFlagName, 0, intervalFlagUsage)
+	flags.IntVar(&pflags.count, countFlagName, 0, countFlagUsage)
+	flags.StringVar(&pflags.until, untilFlagName, "", untilFlagUsage)
+	flags.StringVar(&pflags.maxDiskUsage, maxDiskUsageFlagName, defaultMaxDiskUsage, maxDiskUsageFlagUsage)
 	flags.StringVarP(&pflags.saveTo, "save-to", "s", "", saveToFlagUsage)
 	flags.BoolVar(&pflags.allProfiles, "all", false, allFlagUsage)
 	flags.StringVarP(&pflags.cpuProfile, cpuFlagName, "", "5s", cpuFlagUsage)
@@ -178,23 +280,101 @@ func NewProfilingCommand(p *pkg.AdminParams) *cobra.Command {
 	flags.BoolVar(&pflags.mutexProfile, mutexFlagName, false, mutexFlagUsage)
 	flags.BoolVar(&pflags.goroutineProfile, goroutineFlagName, false, goroutineFlagUsage)
 	flags.BoolVar(&pflags.threadCreateProfile, threadCreateFlagName, false, threadCreateFlagUsage)
+	flags.IntVar(&pflags.parallelism, parallelismFlagName, defaultParallelism, parallelismFlagUsage)
 	return profilingCmd
 }
 
-// configProfiling enables or disables knative profiling
-func configProfiling(p *pkg.AdminParams, cmd *cobra.Command, enable bool) error {
+// validatePeriodic validates and parses the flags of periodic downloads
+func (f *profilingFlags) validatePeriodic(isIntervalSet bool, now time.Time) error {
+	isCountSet := f.count != 0
+	isUntilSet := f.until != ""
+	if isIntervalSet && f.interval <= 0 {
+		return fmt.Errorf("invalid '--interval' %s, it must be positive", f.interval)
+	}
+	if isIntervalSet && !isCountSet && !isUntilSet {
+		return fmt.Errorf("flag '--interval' requires '--count' or '--until'")
+	}
+	if !isIntervalSet && (isCountSet || isUntilSet) {
+		return fmt.Errorf("flag '--count' or '--until' requires '--interval'")
+	}
+	if f.count < 0 {
+		return fmt.Errorf("invalid '--count' %d, it must be at least 1", f.count)
+	}
+	if isUntilSet {
+		until, err := parseUntil(f.until, now)
+		if err != nil {
+			return err
+		}
+		f.untilTime = until
+	}
+
+	limit, err := resource.ParseQuantity(f.maxDiskUsage)
+	if err != nil {
+		return fmt.Errorf("invalid '--max-disk-usage' '%s': %v", f.maxDiskUsage, err)
+	}
+	if limit.Sign() < 0 {
+		return fmt.Errorf("invalid '--max-disk-usage' '%s', it must not be negative", f.maxDiskUsage)
+	}
+	f.diskUsageLimit = limit.Value()
+	return nil
+}
+
+// parseUntil parses the time in RFC3339 format, or the local time of the day in 15:04 or 15:04:05 format, which is
+// the time of the next day if it's passed today
+func parseUntil(until string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, until); err == nil {
+		if !t.After(now) {
+			return time.Time{}, fmt.Errorf("invalid '--until' '%s', it's passed", until)
+		}
+		return t, nil
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		clock, err := time.ParseInLocation(layout, until, now.Location())
+		if err != nil {
+			continue
+		}
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, now.Location())
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid '--until' '%s', expected RFC3339 format e.g: '2020-07-25T08:00:00Z' or the time of the day e.g: '08:00'", until)
+}
+
+// profilingNamespace returns the namespace of the ConfigMap config-observability of the profiling target, which is
+// the namespace specified by --namespace, the namespace of the known component, or knative-serving
+func (f *profilingFlags) profilingNamespace() string {
+	// the queue-proxy sidecars are configured by the config-observability of Knative Serving
+	if f.service != "" {
+		return knNamespace
+	}
+	if f.namespace != "" {
+		return f.namespace
+	}
+	if c, ok := lookupComponent(f.target); ok && f.selector == "" {
+		return c.namespace
+	}
+	return knNamespace
+}
+
+// configProfiling enables or disables knative profiling in the namespace
+func configProfiling(p *pkg.AdminParams, cmd *cobra.Command, namespace string, enable bool) error {
 	client, err := p.NewKubeClient()
 	if err != nil {
 		return err
 	}
 
 	currentCm := &corev1.ConfigMap{}
-	currentCm, err = client.CoreV1().ConfigMaps(knNamespace).Get(context.TODO(), obsConfigMap, metav1.GetOptions{})
+	currentCm, err = client.CoreV1().ConfigMaps(namespace).Get(context.TODO(), obsConfigMap, metav1.GetOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to get ConfigMap %s in namespace %s: %+v", obsConfigMap, knNamespace, err)
+		return fmt.Errorf("failed to get ConfigMap %s in namespace %s: %+v", obsConfigMap, namespace, err)
 	}
 
 	desiredCm := currentCm.DeepCopy()
+	if desiredCm.Data == nil {
+		desiredCm.Data = map[string]string{}
+	}
 	if enable {
 		desiredCm.Data["profiling.enable"] = "true"
 	} else {
@@ -203,23 +383,23 @@ func configProfiling(p *pkg.AdminParams, cmd *cobra.Command, enable bool) error
 
 	err = utils.UpdateConfigMap(client, desiredCm)
 	if err != nil {
-		return fmt.Errorf("failed to update ConfigMap %s in namespace %s: %+v", obsConfigMap, knNamespace, err)
+		return fmt.Errorf("failed to update ConfigMap %s in namespace %s: %+v", obsConfigMap, namespace, err)
 	}
 
 	if enable {
-		cmd.Println("Knative Serving profiling is enabled")
+		cmd.Printf("%s profiling is enabled in namespace %s\n", projectOf(namespace), namespace)
 	} else {
-		cmd.Println("Knative Serving profiling is disabled")
+		cmd.Printf("%s profiling is disabled in namespace %s\n", projectOf(namespace), namespace)
 	}
 	return nil
 }
 
-// isProfilingEnabled checks if the profiling is enabled
-func isProfilingEnabled(c kubernetes.Interface) (bool, error) {
+// isProfilingEnabled checks if the profiling is enabled in the namespace
+func isProfilingEnabled(c kubernetes.Interface, namespace string) (bool, error) {
 	currentCm := &corev1.ConfigMap{}
-	currentCm, err := c.CoreV1().ConfigMaps(knNamespace).Get(context.TODO(), obsConfigMap, metav1.GetOptions{})
+	currentCm, err := c.CoreV1().ConfigMaps(namespace).Get(context.TODO(), obsConfigMap, metav1.GetOptions{})
 	if err != nil {
-		return false, fmt.Errorf("failed to get ConfigMap %s in namespace %s: %+v", obsConfigMap, knNamespace, err)
+		return false, fmt.Errorf("failed to get ConfigMap %s in namespace %s: %+v", obsConfigMap, namespace, err)
 	}
 
 	if strings.ToLower(currentCm.Data["profiling.enable"]) == "true" {
@@ -385,84 +565,244 @@ func downloadProfileData(p *pkg.AdminParams, cmd *cobra.Command, pflags *profili
 	}
 
 	// check if profiling is enabled, if not, print message to ask user enable it first
-	enabled, err := isProfilingEnabled(client)
+	namespace := pflags.profilingNamespace()
+	enabled, err := isProfilingEnabled(client, namespace)
 	if err != nil {
 		return err
 	}
 	if !enabled {
-		return fmt.Errorf("profiling is not enabled, please use '--enable' to enalbe it first")
+		return fmt.Errorf("profiling is not enabled, please use '--enable' to enalbe it first in namespace '%s'", namespace)
 	}
 
-	// try to find target as a knative component name
-	pods, err := client.CoreV1().Pods(knNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: "app=" + pflags.target})
+	if pflags.service != "" {
+		namespace = pflags.namespace
+		if namespace == "" {
+			namespace = defaultNamespace
+		}
+	}
+	// the pods are found again by every capture, e.g: the pods are scaled or restarted between periodic captures,
+	// the revisions to restart are only reported when they change
+	warnedRestarts := ""
+	findPods := func() (*corev1.PodList, error) {
+		if pflags.service == "" {
+			return findTargetPods(client, pflags, namespace)
+		}
+		pods, restarts, err := findServicePods(client, pflags.service, namespace)
+		if key := strings.Join(restarts, ", "); key != warnedRestarts {
+			if key != "" {
+				cmd.PrintErrf("Warning: profiling is not enabled in the queue-proxy of revision(s) %s, restart them to pick up the setting, e.g: 'kubectl rollout restart deployment %s-deployment -n %s'\n",
+					key, restarts[0], namespace)
+			}
+			warnedRestarts = key
+		}
+		return pods, err
+	}
+	pods, err := findPods()
 	if err != nil {
 		return err
 	}
-	// if no pod found, try to find target as a pod name in knative namespace
-	if len(pods.Items) < 1 {
-		pods, err = client.CoreV1().Pods(knNamespace).List(context.TODO(), metav1.ListOptions{})
-		if err != nil {
-			return err
+
+	quota := &diskQuota{limit: pflags.diskUsageLimit}
+	progress := &progressPrinter{cmd: cmd}
+	sessions := reconcileSessions(progress, nil, pods, namespace)
+	defer func() {
+		for _, s := range sessions {
+			s.close()
 		}
-		if len(pods.Items) < 1 {
-			return fmt.Errorf("fail to get profiling target '%s'", pflags.target)
+	}()
+
+	// captures the profiling data once, or every --interval until --count captures are done or --until is reached
+	periodic := pflags.interval > 0
+	failed, failedPods, profiledPods := []string{}, map[string]bool{}, map[string]bool{}
+	start := time.Now()
+	for capture := 1; ; capture++ {
+		// the sequence number is only part of the file names of periodic downloads
+		seq := 0
+		if periodic {
+			seq = capture
+			if pflags.count > 0 {
+				progress.Printf("Capturing profiling data %d/%d at %s...\n", seq, pflags.count, time.Now().Format(time.RFC3339))
+			} else {
+				progress.Printf("Capturing profiling data %d at %s...\n", seq, time.Now().Format(time.RFC3339))
+			}
+		}
+		if capture > 1 {
+			if pods, err = findPods(); err != nil {
+				cmd.PrintErrf("Warning: failed to find the pods of capture %d, the former pods are profiled: %v\n", seq, err)
+			} else {
+				sessions = reconcileSessions(progress, sessions, pods, namespace)
+			}
+		}
+		for _, s := range sessions {
+			profiledPods[s.podName] = true
 		}
 
-		// check if target is found as pod
-		found := false
-		for _, p := range pods.Items {
-			if p.Name == pflags.target {
-				pods.Items = []corev1.Pod{p}
-				found = true
-				break
+		for i, err := range captureProfileData(p, progress, sessions, profileTypes, pflags, seq, quota) {
+			if err == nil || errors.Is(err, errDiskUsageExceeded) {
+				continue
+			}
+			podName := sessions[i].podName
+			failedPods[podName] = true
+			if periodic {
+				failed = append(failed, fmt.Sprintf("%s (capture %d): %v", podName, seq, err))
+			} else {
+				failed = append(failed, fmt.Sprintf("%s: %v", podName, err))
 			}
 		}
-		if !found {
-			return fmt.Errorf("fail to get profiling target '%s'", pflags.target)
+
+		if quota.isExceeded() {
+			cmd.PrintErrf("Warning: the profiling data reached the disk usage limit %s, stopped capturing\n", pflags.maxDiskUsage)
+			break
+		}
+		if !periodic || (pflags.count > 0 && capture >= pflags.count) {
+			break
+		}
+		next := start.Add(time.Duration(capture) * pflags.interval)
+		if !pflags.untilTime.IsZero() && next.After(pflags.untilTime) {
+			break
+		}
+		select {
+		case <-cmd.Context().Done():
+			return cmd.Context().Err()
+		case <-time.After(time.Until(next)):
 		}
 	}
 
-	// iterates pods to download specified profiling data
-	for _, pod := range pods.Items {
-		err = func() error {
-			cmd.Printf("Starting to download profiling data for pod %s...\n", pod.Name)
-			end := make(chan struct{})
-			downloader, err := newDownloaderFunc(p, pod.Name, knNamespace, end)
-			if err != nil {
-				return err
-			}
-			defer close(end)
-
-			// iterates specified profile types to download data
-			for k, v := range profileTypes {
-				duration := ""
-				filename := pod.Name + "_" + k
-				options := []DownloadOptions{}
-				if t, ok := v.downloadOption.(OptionProfilingTime); ok {
-					seconds := int64(time.Duration(t) / time.Second)
-					duration = strconv.FormatInt(seconds, 10) + " second(s) "
-					filename += "_" + durationDescription(seconds)
-					options = append(options, v.downloadOption)
-				}
-				filename += "_" + time.Now().Format("20060102150405")
-				dataFilePath := filepath.Join(pflags.saveTo, filename)
-				f, err := os.Create(dataFilePath)
-				if err != nil {
-					return err
-				}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to download profiling data from %d of %d pod(s):\n%s", len(failedPods), len(profiledPods), strings.Join(failed, "\n"))
+	}
+	return nil
+}
 
-				cmd.Printf("Saving %s%s profiling data to %s\n", duration, k, dataFilePath)
-				err = downloader.Download(v.profileType, f, options...)
-				f.Close()
-				if err != nil {
-					return err
-				}
+// captureProfileData downloads the specified profiling data from the pods concurrently, at most --parallelism pods at
+// the same time, and returns the error of each pod
+func captureProfileData(p *pkg.AdminParams, progress *progressPrinter, sessions []*podSession, profileTypes map[string]profileTypeOption,
+	pflags *profilingFlags, seq int, quota *diskQuota) []error {
+	semaphore := make(chan struct{}, pflags.parallelism)
+	errs := make([]error, len(sessions))
+	var wg sync.WaitGroup
+	for i := range sessions {
+		wg.Add(1)
+		go func(i int, s *podSession) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			start := time.Now()
+			progress.Printf("Starting to download profiling data for pod %s...\n", s.podName)
+			errs[i] = s.capture(p, progress, profileTypes, pflags.saveTo, seq, quota)
+			if errs[i] != nil {
+				progress.Printf("[%s] Failed to download profiling data: %v\n", s.podName, errs[i])
+				return
 			}
-			return nil
-		}()
+			progress.Printf("[%s] Downloaded profiling data in %s\n", s.podName, time.Since(start).Round(time.Millisecond))
+		}(i, sessions[i])
+	}
+	wg.Wait()
+	return errs
+}
+
+// findTargetPods finds the pods to profile in the namespace, by the --selector, the label selector of the known
+// component, the label 'app=<target>' or the pod name
+func findTargetPods(client kubernetes.Interface, pflags *profilingFlags, namespace string) (*corev1.PodList, error) {
+	if pflags.selector != "" {
+		pods, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: pflags.selector})
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if len(pods.Items) < 1 {
+			return nil, fmt.Errorf("fail to get pods with selector '%s' in namespace '%s'", pflags.selector, namespace)
 		}
+		return pods, nil
 	}
-	return nil
+
+	// try to find target as a knative component name
+	selector := "app=" + pflags.target
+	if c, ok := lookupComponent(pflags.target); ok {
+		selector = c.selector
+	}
+	pods, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) > 0 {
+		return pods, nil
+	}
+
+	// if no pod found, try to find target as a pod name in the namespace
+	pods, err = client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pods.Items {
+		if p.Name == pflags.target {
+			pods.Items = []corev1.Pod{p}
+			return pods, nil
+		}
+	}
+	return nil, fmt.Errorf("fail to get profiling target '%s' in namespace '%s'", pflags.target, namespace)
+}
+
+// findServicePods finds the running pods of the revisions of the Knative Service, whose queue-proxy sidecars serve
+// the profiling data. The sorted revisions deployed before profiling is enabled are returned as well, they have to
+// be restarted to pick up the setting
+func findServicePods(client kubernetes.Interface, service, namespace string) (*corev1.PodList, []string, error) {
+	pods, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: serving.ServiceLabelKey + "=" + service,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	profiled := []corev1.Pod{}
+	restartSet := map[string]bool{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		if hasQueueProfilingPort(&pod) {
+			profiled = append(profiled, pod)
+			continue
+		}
+		restartSet[pod.Labels[serving.RevisionLabelKey]] = true
+	}
+
+	restarts := make([]string, 0, len(restartSet))
+	for revision := range restartSet {
+		restarts = append(restarts, revision)
+	}
+	sort.Strings(restarts)
+	if len(profiled) < 1 {
+		return nil, restarts, fmt.Errorf("fail to get running pods with queue-proxy profiling enabled of service '%s' in namespace '%s'", service, namespace)
+	}
+	pods.Items = profiled
+	return pods, restarts, nil
+}
+
+// hasQueueProfilingPort checks if the queue-proxy sidecar of the revision pod exposes the profiling port
+func hasQueueProfilingPort(pod *corev1.Pod) bool {
+	for _, c := range pod.Spec.Containers {
+		if c.Name != queueContainerName {
+			continue
+		}
+		for _, port := range c.Ports {
+			if port.Name == queueProfilingPortName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// progressPrinter prints the download progress of the pods downloaded concurrently, one line at a time
+type progressPrinter struct {
+	mu  sync.Mutex
+	cmd *cobra.Command
+}
+
+// Printf prints a progress message
+func (p *progressPrinter) Printf(format string, args ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cmd.Printf(format, args...)
 }
@@ -23,12 +23,15 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"gotest.tools/v3/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	k8srt "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
@@ -72,6 +75,44 @@ func fakeDownloaderBuilder(newError, downloadError error) func(RestConfigGetter,
 	}
 }
 
+// concurrentDownloader records the maximum number of pods downloading at the same time
+type concurrentDownloader struct {
+	mu      sync.Mutex
+	current int
+	max     int
+	failed  map[string]bool
+}
+
+func (c *concurrentDownloader) builder() func(RestConfigGetter, string, string, <-chan struct{}) (ProfileDownloader, error) {
+	return func(_ RestConfigGetter, podName string, _ string, _ <-chan struct{}) (ProfileDownloader, error) {
+		var err error
+		if c.failed[podName] {
+			err = errors.New("error downloading data")
+		}
+		return &podDownloader{parent: c, error: err}, nil
+	}
+}
+
+type podDownloader struct {
+	parent *concurrentDownloader
+	error  error
+}
+
+func (d *podDownloader) Download(t ProfileType, output io.Writer, options ...DownloadOptions) error {
+	c := d.parent
+	c.mu.Lock()
+	c.current++
+	if c.current > c.max {
+		c.max = c.current
+	}
+	c.mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	c.mu.Lock()
+	c.current--
+	c.mu.Unlock()
+	return d.error
+}
+
 func removeProfileDataFiles(nameFilter string) {
 	files, err := filepath.Glob(nameFilter)
 	if err == nil {
@@ -102,10 +143,10 @@ func TestNewProfilingCommand(t *testing.T) {
 		_, err := testutil.ExecuteCommand(newProfilingCommand(), "--enable", "--disable")
 		assert.ErrorContains(t, err, "flags '--enable' and '--disable' can not be used together", err)
 
-		// --enable or --disable --target can't be used with other flags
+		// --enable or --disable can't be used with other flags
 		argsList := [][]string{
-			{"--enable", "--target", "activator"},
-			{"--disable", "--target", "activator"},
+			{"--enable", "--selector", "app=activator"},
+			{"--disable", "--selector", "app=activator"},
 			{"--enable", "--save-to", "/tmp"},
 			{"--disable", "--save-to", "/tmp"},
 			{"--enable", "--all"},
@@ -132,6 +173,29 @@ func TestNewProfilingCommand(t *testing.T) {
 			assert.ErrorContains(t, err, "flag '--enable' or '--disable' can not be used with other flags", err)
 		}
 
+		// --enable or --disable can only be used with the --target of a known component
+		for _, flag := range []string{"--enable", "--disable"} {
+			_, err := testutil.ExecuteCommand(newProfilingCommand(), flag, "--target", "activator-586d468c99-w59cm")
+			assert.ErrorContains(t, err, "flag '--enable' or '--disable' can only be used with the '--target' of a known component", err)
+		}
+
+		// --target and --selector can't be used together
+		_, err = testutil.ExecuteCommand(newProfilingCommand(), "--target", "activator", "--selector", "app=activator", "--heap")
+		assert.ErrorContains(t, err, "flags '--target' and '--selector' can not be used together", err)
+
+		// --service can't be used with --target or --selector
+		for _, args := range [][]string{
+			{"--service", "hello", "--target", "activator", "--heap"},
+			{"--service", "hello", "--selector", "app=activator", "--heap"},
+		} {
+			_, err := testutil.ExecuteCommand(newProfilingCommand(), args...)
+			assert.ErrorContains(t, err, "flag '--service' can not be used with '--target' or '--selector'", err)
+		}
+		_, err = testutil.ExecuteCommand(newProfilingCommand(), "--enable", "--service", "hello")
+		assert.ErrorContains(t, err, "flag '--enable' or '--disable' can not be used with other flags", err)
+		_, err = testutil.ExecuteCommand(newProfilingCommand(), "--service", "hello")
+		assert.ErrorContains(t, err, "requires '--all' or a specific profiling type flag", err)
+
 		// requires target
 		argsList = [][]string{
 			{"--save-to", "/tmp"},
@@ -573,4 +637,435 @@ func TestNewProfilingCommand(t *testing.T) {
 			assert.Check(t, strings.Contains(out, v), fmt.Sprintf("expected saving %s profiling data output for %s", k, podName))
 		}
 	})
+
+	t.Run("requires a positive parallelism", func(t *testing.T) {
+		_, err := testutil.ExecuteCommand(newProfilingCommand(), "--target", "activator", "--heap", "--parallelism", "0")
+		assert.ErrorContains(t, err, "invalid '--parallelism' 0, it must be at least 1", err)
+
+		_, err = testutil.ExecuteCommand(newProfilingCommand(), "--enable", "--parallelism", "2")
+		assert.ErrorContains(t, err, "flag '--enable' or '--disable' can not be used with other flags", err)
+
+		_, err = testutil.ExecuteCommand(newProfilingCommand(), "--parallelism", "2")
+		assert.ErrorContains(t, err, "requires '--target' flag", err)
+	})
+
+	t.Run("downloads profiling data from pods in parallel", func(t *testing.T) {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: obsConfigMap, Namespace: knNamespace},
+			Data:       map[string]string{"profiling.enable": "true"},
+		}
+		cmd, client := newProfilingCommandWith(cm)
+		cwd, _ := os.Getwd()
+		pods := corev1.PodList{}
+		for i := 0; i < 5; i++ {
+			pods.Items = append(pods.Items, corev1.Pod{
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("activator-5xxx%d", i),
+					Namespace: knNamespace,
+					Labels:    map[string]string{"app": "activator"},
+				},
+			})
+		}
+		client.CoreV1().(*k8sfakecorev1.FakeCoreV1).PrependReactor("list", "pods",
+			func(action k8stesting.Action) (handled bool, ret k8srt.Object, err error) {
+				return true, &pods, nil
+			})
+		downloader := &concurrentDownloader{failed: map[string]bool{"activator-5xxx3": true}}
+		newDownloaderFunc = downloader.builder()
+		defer func() {
+			newDownloaderFunc = NewDownloader
+			removeProfileDataFiles(filepath.Join(cwd, "activator-5xxx*"))
+		}()
+
+		out, err := testutil.ExecuteCommand(cmd, "--target", "activator", "--heap", "--parallelism", "2")
+		assert.ErrorContains(t, err, "failed to download profiling data from 1 of 5 pod(s)")
+		assert.ErrorContains(t, err, "activator-5xxx3: error downloading data")
+		assert.Equal(t, 2, downloader.max)
+		for _, pod := range pods.Items {
+			assert.Check(t, strings.Contains(out, fmt.Sprintf("[%s] Saving heap profiling data to %s_heap", pod.Name, filepath.Join(cwd, pod.Name))), "unexpected output: %s", out)
+			if pod.Name != "activator-5xxx3" {
+				assert.Check(t, strings.Contains(out, fmt.Sprintf("[%s] Downloaded profiling data in", pod.Name)), "unexpected output: %s", out)
+			}
+		}
+		assert.Check(t, strings.Contains(out, "[activator-5xxx3] Failed to download profiling data: error downloading data"), "unexpected output: %s", out)
+	})
+
+	t.Run("enables profiling in the namespace of a known component", func(t *testing.T) {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: obsConfigMap, Namespace: eventingNamespace},
+		}
+		cmd, client := newProfilingCommandWith(cm)
+		out, err := testutil.ExecuteCommand(cmd, "--enable", "--target", "broker-ingress")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(out, "Knative Eventing profiling is enabled in namespace knative-eventing"), "unexpected output: %s", out)
+
+		newCm, err := client.CoreV1().ConfigMaps(eventingNamespace).Get(context.TODO(), obsConfigMap, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "true", newCm.Data["profiling.enable"])
+	})
+
+	t.Run("disables profiling in the specified namespace", func(t *testing.T) {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: obsConfigMap, Namespace: "operator-system"},
+			Data:       map[string]string{"profiling.enable": "true"},
+		}
+		cmd, client := newProfilingCommandWith(cm)
+		out, err := testutil.ExecuteCommand(cmd, "--disable", "--target", "operator", "-n", "operator-system")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(out, "Knative profiling is disabled in namespace operator-system"), "unexpected output: %s", out)
+
+		newCm, err := client.CoreV1().ConfigMaps("operator-system").Get(context.TODO(), obsConfigMap, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "false", newCm.Data["profiling.enable"])
+	})
+
+	t.Run("profiling is not enabled in the namespace of a known component", func(t *testing.T) {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: obsConfigMap, Namespace: eventingNamespace},
+			Data:       map[string]string{"profiling.enable": "false"},
+		}
+		cmd, _ := newProfilingCommandWith(cm)
+		_, err := testutil.ExecuteCommand(cmd, "--target", "imc-dispatcher", "--heap")
+		assert.ErrorContains(t, err, "profiling is not enabled, please use '--enable' to enalbe it first in namespace 'knative-eventing'", err)
+	})
+
+	for _, tc := range []struct {
+		name      string
+		args      []string
+		namespace string
+		selector  string
+	}{{
+		name:      "downloads profiling data of a known component",
+		args:      []string{"--target", "imc-dispatcher"},
+		namespace: eventingNamespace,
+		selector:  "messaging.knative.dev/channel=in-memory-channel,messaging.knative.dev/role=dispatcher",
+	}, {
+		name:      "downloads profiling data of a known component in the specified namespace",
+		args:      []string{"--target", "operator", "--namespace", "operator-system"},
+		namespace: "operator-system",
+		selector:  "name=knative-operator",
+	}, {
+		name:      "downloads profiling data of the pods matching the selector",
+		args:      []string{"--selector", "app=net-kourier-controller", "-n", "kourier-system"},
+		namespace: "kourier-system",
+		selector:  "app=net-kourier-controller",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: obsConfigMap, Namespace: tc.namespace},
+				Data:       map[string]string{"profiling.enable": "true"},
+			}
+			cmd, client := newProfilingCommandWith(cm)
+			cwd, _ := os.Getwd()
+			podName := "component-6xxx"
+			podLabels, err := labels.ConvertSelectorToLabelsMap(tc.selector)
+			assert.NilError(t, err)
+			pods := corev1.PodList{Items: []corev1.Pod{{
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+				ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: tc.namespace, Labels: podLabels},
+			}}}
+			client.CoreV1().(*k8sfakecorev1.FakeCoreV1).PrependReactor("list", "pods",
+				func(action k8stesting.Action) (handled bool, ret k8srt.Object, err error) {
+					listAction := action.(k8stesting.ListAction)
+					if action.GetNamespace() != tc.namespace || listAction.GetListRestrictions().Labels.String() != tc.selector {
+						return true, &corev1.PodList{}, nil
+					}
+					return true, &pods, nil
+				})
+			namespaces := []string{}
+			newDownloaderFunc = func(_ RestConfigGetter, _ string, namespace string, _ <-chan struct{}) (ProfileDownloader, error) {
+				namespaces = append(namespaces, namespace)
+				return &fakeDownloader{}, nil
+			}
+			defer func() {
+				newDownloaderFunc = NewDownloader
+				removeProfileDataFiles(filepath.Join(cwd, podName+"_*"))
+			}()
+
+			out, err := testutil.ExecuteCommand(cmd, append(tc.args, "--heap")...)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, []string{tc.namespace}, namespaces)
+			assert.Check(t, strings.Contains(out, fmt.Sprintf("[%s] Saving heap profiling data to %s_heap", podName, filepath.Join(cwd, podName))), "unexpected output: %s", out)
+		})
+	}
+
+	t.Run("no pod matches the selector", func(t *testing.T) {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: obsConfigMap, Namespace: knNamespace},
+			Data:       map[string]string{"profiling.enable": "true"},
+		}
+		cmd, _ := newProfilingCommandWith(cm)
+		_, err := testutil.ExecuteCommand(cmd, "--selector", "app=missing", "--heap")
+		assert.ErrorContains(t, err, "fail to get pods with selector 'app=missing' in namespace 'knative-serving'", err)
+	})
+
+	t.Run("downloads profiling data of the queue-proxy sidecars of a service", func(t *testing.T) {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: obsConfigMap, Namespace: knNamespace},
+			Data:       map[string]string{"profiling.enable": "true"},
+		}
+		cmd, client := newProfilingCommandWith(cm)
+		cwd, _ := os.Getwd()
+		for _, pod := range []*corev1.Pod{
+			newRevisionPod("hello-00002-deployment-7xxx0", "hello", "hello-00002", corev1.PodRunning, true),
+			newRevisionPod("hello-00002-deployment-7xxx1", "hello", "hello-00002", corev1.PodPending, true),
+			newRevisionPod("hello-00001-deployment-7xxx2", "hello", "hello-00001", corev1.PodRunning, false),
+			newRevisionPod("other-00001-deployment-7xxx3", "other", "other-00001", corev1.PodRunning, true),
+		} {
+			_, err := client.CoreV1().Pods(pod.Namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+			assert.NilError(t, err)
+		}
+		downloaded := []string{}
+		newDownloaderFunc = func(_ RestConfigGetter, podName string, namespace string, _ <-chan struct{}) (ProfileDownloader, error) {
+			downloaded = append(downloaded, namespace+"/"+podName)
+			return &fakeDownloader{}, nil
+		}
+		defer func() {
+			newDownloaderFunc = NewDownloader
+			removeProfileDataFiles(filepath.Join(cwd, "hello-*-deployment-7xxx*"))
+		}()
+
+		out, err := testutil.ExecuteCommand(cmd, "--service", "hello", "--heap")
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []string{"default/hello-00002-deployment-7xxx0"}, downloaded)
+		assert.Check(t, strings.Contains(out, "Warning: profiling is not enabled in the queue-proxy of revision(s) hello-00001, restart them to pick up the setting, "+
+			"e.g: 'kubectl rollout restart deployment hello-00001-deployment -n default'"), "unexpected output: %s", out)
+	})
+
+	t.Run("no queue-proxy of the service is profiling enabled", func(t *testing.T) {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: obsConfigMap, Namespace: knNamespace},
+			Data:       map[string]string{"profiling.enable": "true"},
+		}
+		cmd, client := newProfilingCommandWith(cm)
+		pod := newRevisionPod("hello-00001-deployment-8xxx0", "hello", "hello-00001", corev1.PodRunning, false)
+		pod.Namespace = "demo"
+		_, err := client.CoreV1().Pods(pod.Namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+		assert.NilError(t, err)
+
+		out, err := testutil.ExecuteCommand(cmd, "--service", "hello", "-n", "demo", "--heap")
+		assert.ErrorContains(t, err, "fail to get running pods with queue-proxy profiling enabled of service 'hello' in namespace 'demo'", err)
+		assert.Check(t, strings.Contains(out, "revision(s) hello-00001, restart them"), "unexpected output: %s", out)
+	})
+
+	t.Run("profiling of the queue-proxy is not enabled in Knative Serving", func(t *testing.T) {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: obsConfigMap, Namespace: knNamespace},
+		}
+		cmd, _ := newProfilingCommandWith(cm)
+		_, err := testutil.ExecuteCommand(cmd, "--service", "hello", "-n", "demo", "--heap")
+		assert.ErrorContains(t, err, "profiling is not enabled, please use '--enable' to enalbe it first in namespace 'knative-serving'", err)
+	})
+
+	t.Run("validates the flags of periodic downloads", func(t *testing.T) {
+		for _, tc := range []struct {
+			args []string
+			err  string
+		}{
+			{[]string{"--target", "activator", "--heap", "--interval", "5m"}, "flag '--interval' requires '--count' or '--until'"},
+			{[]string{"--target", "activator", "--heap", "--count", "3"}, "flag '--count' or '--until' requires '--interval'"},
+			{[]string{"--target", "activator", "--heap", "--until", "08:00"}, "flag '--count' or '--until' requires '--interval'"},
+			{[]string{"--target", "activator", "--heap", "--interval", "0s", "--count", "3"}, "invalid '--interval' 0s, it must be positive"},
+			{[]string{"--target", "activator", "--heap", "--interval", "5m", "--count", "-1"}, "invalid '--count' -1, it must be at least 1"},
+			{[]string{"--target", "activator", "--heap", "--interval", "5m", "--until", "tomorrow"}, "invalid '--until' 'tomorrow'"},
+			{[]string{"--target", "activator", "--heap", "--max-disk-usage", "lots"}, "invalid '--max-disk-usage' 'lots'"},
+			{[]string{"--target", "activator", "--heap", "--max-disk-usage", "-1Mi"}, "invalid '--max-disk-usage' '-1Mi', it must not be negative"},
+			{[]string{"--enable", "--interval", "5m", "--count", "3"}, "flag '--enable' or '--disable' can not be used with other flags"},
+			{[]string{"--interval", "5m", "--count", "3"}, "requires '--target' flag"},
+		} {
+			_, err := testutil.ExecuteCommand(newProfilingCommand(), tc.args...)
+			assert.ErrorContains(t, err, tc.err, "args: %v", tc.args)
+		}
+	})
+
+	t.Run("parses the time to download until", func(t *testing.T) {
+		now := time.Date(2020, 7, 25, 10, 30, 0, 0, time.UTC)
+		until, err := parseUntil("2020-07-25T12:00:00Z", now)
+		assert.NilError(t, err)
+		assert.Equal(t, time.Date(2020, 7, 25, 12, 0, 0, 0, time.UTC), until)
+
+		until, err = parseUntil("11:00", now)
+		assert.NilError(t, err)
+		assert.Equal(t, time.Date(2020, 7, 25, 11, 0, 0, 0, time.UTC), until)
+
+		until, err = parseUntil("08:00:30", now)
+		assert.NilError(t, err)
+		assert.Equal(t, time.Date(2020, 7, 26, 8, 0, 30, 0, time.UTC), until)
+
+		_, err = parseUntil("2020-07-25T08:00:00Z", now)
+		assert.ErrorContains(t, err, "invalid '--until' '2020-07-25T08:00:00Z', it's passed")
+	})
+
+	t.Run("downloads profiling data periodically", func(t *testing.T) {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: obsConfigMap, Namespace: knNamespace},
+			Data:       map[string]string{"profiling.enable": "true"},
+		}
+		cmd, client := newProfilingCommandWith(cm)
+		saveTo := t.TempDir()
+		podNames := []string{"activator-9xxx0", "activator-9xxx1"}
+		pods := corev1.PodList{}
+		for _, name := range podNames {
+			pods.Items = append(pods.Items, corev1.Pod{
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: knNamespace, Labels: map[string]string{"app": "activator"}},
+			})
+		}
+		client.CoreV1().(*k8sfakecorev1.FakeCoreV1).PrependReactor("list", "pods",
+			func(action k8stesting.Action) (handled bool, ret k8srt.Object, err error) {
+				return true, &pods, nil
+			})
+		var mu sync.Mutex
+		connections := map[string]int{}
+		newDownloaderFunc = func(_ RestConfigGetter, podName string, _ string, _ <-chan struct{}) (ProfileDownloader, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			connections[podName]++
+			return &restartingDownloader{data: "data"}, nil
+		}
+		defer func() { newDownloaderFunc = NewDownloader }()
+
+		out, err := testutil.ExecuteCommand(cmd, "--target", "activator", "--heap", "--interval", "10ms", "--count", "3", "--save-to", saveTo)
+		assert.NilError(t, err)
+		// one port forwarding per pod is reused by the captures
+		assert.DeepEqual(t, map[string]int{"activator-9xxx0": 1, "activator-9xxx1": 1}, connections)
+		for _, seq := range []string{"1/3", "2/3", "3/3"} {
+			assert.Check(t, strings.Contains(out, "Capturing profiling data "+seq+" at "), "unexpected output: %s", out)
+		}
+		for _, name := range podNames {
+			for _, seq := range []string{"0001", "0002", "0003"} {
+				files, err := filepath.Glob(filepath.Join(saveTo, name+"_heap_"+seq+"_*"))
+				assert.NilError(t, err)
+				assert.Equal(t, 1, len(files), "expected a file of capture %s of %s", seq, name)
+			}
+		}
+	})
+
+	t.Run("finds the pods again by every capture", func(t *testing.T) {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: obsConfigMap, Namespace: knNamespace},
+			Data:       map[string]string{"profiling.enable": "true"},
+		}
+		cmd, client := newProfilingCommandWith(cm)
+		saveTo := t.TempDir()
+		newPods := func(names ...string) *corev1.PodList {
+			pods := &corev1.PodList{}
+			for _, name := range names {
+				pods.Items = append(pods.Items, corev1.Pod{
+					Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: knNamespace, Labels: map[string]string{"app": "activator"}},
+				})
+			}
+			return pods
+		}
+		// activator-11xxx0 is replaced by activator-11xxx2 after the first capture
+		lists := 0
+		client.CoreV1().(*k8sfakecorev1.FakeCoreV1).PrependReactor("list", "pods",
+			func(action k8stesting.Action) (handled bool, ret k8srt.Object, err error) {
+				lists++
+				if lists == 1 {
+					return true, newPods("activator-11xxx0", "activator-11xxx1"), nil
+				}
+				return true, newPods("activator-11xxx1", "activator-11xxx2"), nil
+			})
+		var mu sync.Mutex
+		connections := map[string]int{}
+		newDownloaderFunc = func(_ RestConfigGetter, podName string, _ string, _ <-chan struct{}) (ProfileDownloader, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			connections[podName]++
+			return &restartingDownloader{data: "data"}, nil
+		}
+		defer func() { newDownloaderFunc = NewDownloader }()
+
+		out, err := testutil.ExecuteCommand(cmd, "--target", "activator", "--heap", "--interval", "10ms", "--count", "3", "--save-to", saveTo)
+		assert.NilError(t, err)
+		assert.Equal(t, 3, lists)
+		assert.DeepEqual(t, map[string]int{"activator-11xxx0": 1, "activator-11xxx1": 1, "activator-11xxx2": 1}, connections)
+		assert.Check(t, strings.Contains(out, "[activator-11xxx0] The pod is gone, stopped profiling it"), "unexpected output: %s", out)
+		assert.Check(t, strings.Contains(out, "[activator-11xxx2] Found new pod, starting to profile it"), "unexpected output: %s", out)
+		for name, count := range map[string]int{"activator-11xxx0": 1, "activator-11xxx1": 3, "activator-11xxx2": 2} {
+			files, err := filepath.Glob(filepath.Join(saveTo, name+"_heap_*"))
+			assert.NilError(t, err)
+			assert.Equal(t, count, len(files), "unexpected captures of %s", name)
+		}
+	})
+
+	t.Run("reports the revisions to restart once by periodic captures", func(t *testing.T) {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: obsConfigMap, Namespace: knNamespace},
+			Data:       map[string]string{"profiling.enable": "true"},
+		}
+		cmd, client := newProfilingCommandWith(cm)
+		saveTo := t.TempDir()
+		for _, pod := range []*corev1.Pod{
+			newRevisionPod("hello-00002-deployment-12xx0", "hello", "hello-00002", corev1.PodRunning, true),
+			newRevisionPod("hello-00001-deployment-12xx1", "hello", "hello-00001", corev1.PodRunning, false),
+		} {
+			_, err := client.CoreV1().Pods(pod.Namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+			assert.NilError(t, err)
+		}
+		newDownloaderFunc = func(_ RestConfigGetter, _ string, _ string, _ <-chan struct{}) (ProfileDownloader, error) {
+			return &restartingDownloader{data: "data"}, nil
+		}
+		defer func() { newDownloaderFunc = NewDownloader }()
+
+		out, err := testutil.ExecuteCommand(cmd, "--service", "hello", "--heap", "--interval", "10ms", "--count", "3", "--save-to", saveTo)
+		assert.NilError(t, err)
+		assert.Equal(t, 1, strings.Count(out, "revision(s) hello-00001, restart them"), "unexpected output: %s", out)
+	})
+
+	t.Run("stops downloading once the disk usage limit is reached", func(t *testing.T) {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: obsConfigMap, Namespace: knNamespace},
+			Data:       map[string]string{"profiling.enable": "true"},
+		}
+		cmd, client := newProfilingCommandWith(cm)
+		saveTo := t.TempDir()
+		pods := corev1.PodList{Items: []corev1.Pod{{
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+			ObjectMeta: metav1.ObjectMeta{Name: "activator-10xxx", Namespace: knNamespace, Labels: map[string]string{"app": "activator"}},
+		}}}
+		client.CoreV1().(*k8sfakecorev1.FakeCoreV1).PrependReactor("list", "pods",
+			func(action k8stesting.Action) (handled bool, ret k8srt.Object, err error) {
+				return true, &pods, nil
+			})
+		newDownloaderFunc = func(RestConfigGetter, string, string, <-chan struct{}) (ProfileDownloader, error) {
+			return &restartingDownloader{data: "0123456789"}, nil
+		}
+		defer func() { newDownloaderFunc = NewDownloader }()
+
+		out, err := testutil.ExecuteCommand(cmd, "--target", "activator", "--heap", "--interval", "10ms", "--count", "5", "--max-disk-usage", "25", "--save-to", saveTo)
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(out, "Warning: the profiling data reached the disk usage limit 25, stopped capturing"), "unexpected output: %s", out)
+		assert.Check(t, !strings.Contains(out, "Capturing profiling data 4/5"), "unexpected output: %s", out)
+		files, err := filepath.Glob(filepath.Join(saveTo, "activator-10xxx_heap_*"))
+		assert.NilError(t, err)
+		assert.Equal(t, 2, len(files))
+	})
+}
+
+// newRevisionPod returns a pod of the revision in namespace 'default', the queue-proxy sidecar exposes the
+// profiling port if profiling is true
+func newRevisionPod(name, service, revision string, phase corev1.PodPhase, profiling bool) *corev1.Pod {
+	queue := corev1.Container{
+		Name:  queueContainerName,
+		Ports: []corev1.ContainerPort{{Name: "http-queueadm", ContainerPort: 8022}},
+	}
+	if profiling {
+		queue.Ports = append(queue.Ports, corev1.ContainerPort{Name: queueProfilingPortName, ContainerPort: 8008})
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				"serving.knative.dev/service":  service,
+				"serving.knative.dev/revision": revision,
+			},
+		},
+		Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "user-container"}, queue}},
+		Status: corev1.PodStatus{Phase: phase},
+	}
 }
@@ -18,9 +18,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
 	"knative.dev/client/pkg/commands"
 	"knative.dev/kn-plugin-admin/pkg"
 
@@ -30,12 +36,16 @@ import (
 )
 
 type registrycmdFlags struct {
-	Server         string
-	SecretName     string
-	Email          string
-	Username       string
-	Password       string
-	ServiceAccount string
+	Server            string
+	SecretName        string
+	Email             string
+	Username          string
+	Password          string
+	ServiceAccount    string
+	AllNamespaces     bool
+	NamespaceSelector string
+	Verify            bool
+	Insecure          bool
 }
 
 var registryFlags registrycmdFlags
@@ -45,7 +55,8 @@ func NewRegistryAddCommand(p *pkg.AdminParams) *cobra.Command {
 	var registryAddCmd = &cobra.Command{
 		Use:   "add",
 		Short: "Add registry with credentials",
-		Long:  `Add registry with credentials and enable service deployments from this registry`,
+		Long: `Add registry with credentials and enable service deployments from this registry,
+the existing secret is updated if the registry has been added with the same server and username for the service account`,
 		Example: `
   # To add registry with credentials
   kn admin registry add \
@@ -55,7 +66,21 @@ func NewRegistryAddCommand(p *pkg.AdminParams) *cobra.Command {
     --username=[REGISTRY_USER] \
     --password=[REGISTRY_PASSWORD] \
     --namespace=[NAMESPACE] \
-    --serviceaccount=[SERVICE_ACCOUNT]`,
+    --serviceaccount=[SERVICE_ACCOUNT]
+
+  # To add registry with credentials in all namespaces having label 'team=x'
+  kn admin registry add \
+    --server=[REGISTRY_SERVER_URL] \
+    --username=[REGISTRY_USER] \
+    --password=[REGISTRY_PASSWORD] \
+    --namespace-selector=team=x
+
+  # To verify the credentials against the registry before adding it
+  kn admin registry add \
+    --server=[REGISTRY_SERVER_URL] \
+    --username=[REGISTRY_USER] \
+    --password=[REGISTRY_PASSWORD] \
+    --verify`,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if registryFlags.Username == "" {
 				return errors.New("'registry add' requires the registry username to run provided with the --username option")
@@ -66,13 +91,24 @@ func NewRegistryAddCommand(p *pkg.AdminParams) *cobra.Command {
 			if registryFlags.Server == "" {
 				return errors.New("'registry add' requires the registry server to run provided with the --server option")
 			}
+			if registryFlags.AllNamespaces && cmd.Flags().Changed("namespace-selector") {
+				return errors.New("flags '--all-namespaces' and '--namespace-selector' can not be used together")
+			}
+			if (registryFlags.AllNamespaces || cmd.Flags().Changed("namespace-selector")) && cmd.Flags().Changed("namespace") {
+				return errors.New("flag '--namespace' can not be used with '--all-namespaces' or '--namespace-selector'")
+			}
+			if _, err := labels.Parse(registryFlags.NamespaceSelector); err != nil {
+				return fmt.Errorf("invalid namespace selector '%s': %v", registryFlags.NamespaceSelector, err)
+			}
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			namespace := cmd.Flag("namespace").Value.String()
-			if namespace == "" {
-				namespace = "default"
+			if registryFlags.Verify {
+				if err := verifyRegistry(registryFlags.Server, registryFlags.Username, registryFlags.Password, registryFlags.Insecure); err != nil {
+					return fmt.Errorf("failed to verify registry '%s': %v", registryFlags.Server, err)
+				}
 			}
+
 			dockerCfg := Registry{
 				Auths: Auths{
 					registryFlags.Server: registryCred{
@@ -84,67 +120,77 @@ func NewRegistryAddCommand(p *pkg.AdminParams) *cobra.Command {
 			}
 
 			j, err := json.Marshal(dockerCfg)
-
-			secretData := map[string][]byte{
-				DockerJSONName: j,
+			if err != nil {
+				return err
 			}
 
-			secret := &corev1.Secret{
-				TypeMeta: metav1.TypeMeta{
-					Kind:       "Secret",
-					APIVersion: "v1",
-				},
-				Type: corev1.SecretTypeDockerConfigJson,
-				ObjectMeta: metav1.ObjectMeta{
-					GenerateName: fmt.Sprintf("%s-", registryFlags.SecretName),
-					Namespace:    namespace,
-					Labels:       AdminRegistryLabels,
-				},
-				Data: secretData,
-			}
+			secret := newRegistrySecret(fmt.Sprintf("%s-", registryFlags.SecretName), j)
 
 			client, err := p.NewKubeClient()
 			if err != nil {
 				return err
 			}
 
-			secret, err = client.CoreV1().Secrets(namespace).Create(context.TODO(), secret, metav1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to create secret in namespace '%s': %v", namespace, err)
+			if !registryFlags.AllNamespaces && !cmd.Flags().Changed("namespace-selector") {
+				namespace := cmd.Flag("namespace").Value.String()
+				if namespace == "" {
+					namespace = "default"
+				}
+				sa, err := client.CoreV1().ServiceAccounts(namespace).Get(context.TODO(), registryFlags.ServiceAccount, metav1.GetOptions{})
+				if err != nil {
+					return fmt.Errorf("failed to get serviceaccount '%s' in namespace '%s': %v", registryFlags.ServiceAccount, namespace, err)
+				}
+				existing, err := applyRegistrySecret(client, sa, secret, registryFlags.Server, registryFlags.Username)
+				if err != nil {
+					return err
+				}
+				printRegistryApplied(cmd, existing, registryFlags.Server, registryFlags.ServiceAccount, namespace)
+				return nil
 			}
 
-			sa, err := client.CoreV1().ServiceAccounts(namespace).Get(context.TODO(), registryFlags.ServiceAccount, metav1.GetOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to get serviceaccount '%s' in namespace '%s': %v", registryFlags.ServiceAccount, namespace, err)
+			// record the namespace selector so that 'registry sync' can re-apply the registry later
+			secret.Annotations = map[string]string{
+				ImagePullNamespaceSelector: registryFlags.NamespaceSelector,
 			}
-			desiredSa := sa.DeepCopy()
-			desiredSa.ImagePullSecrets = append(desiredSa.ImagePullSecrets, corev1.LocalObjectReference{
-				Name: secret.Name,
+			namespaces, err := client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{
+				LabelSelector: registryFlags.NamespaceSelector,
 			})
-
-			_, err = client.CoreV1().ServiceAccounts(namespace).Update(context.TODO(), desiredSa, metav1.UpdateOptions{})
 			if err != nil {
-				return fmt.Errorf("failed to add registry secret in serviceaccount '%s' in namespace '%s': %v", registryFlags.ServiceAccount, namespace, err)
+				return fmt.Errorf("failed to list namespaces: %v", err)
 			}
-
-			// update label to record the service account that using this secret as image pull secret
-			updateLabel := map[string]string{
-				pkg.LabelManagedBy:      AdminRegistryCmdName,
-				ImagePullServiceAccount: desiredSa.Name,
+			if len(namespaces.Items) == 0 {
+				cmd.Printf("No namespace found for selector '%s'\n", registryFlags.NamespaceSelector)
+				return nil
 			}
 
-			secret.ObjectMeta.Labels = updateLabel
-			_, err = client.CoreV1().Secrets(namespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to update secret label in namespace '%s': %v", namespace, err)
+			errs := []error{}
+			for _, ns := range namespaces.Items {
+				sa, err := client.CoreV1().ServiceAccounts(ns.Name).Get(context.TODO(), registryFlags.ServiceAccount, metav1.GetOptions{})
+				if apierrors.IsNotFound(err) {
+					cmd.Printf("Serviceaccount '%s' in namespace '%s' is not found, skipped\n", registryFlags.ServiceAccount, ns.Name)
+					continue
+				}
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to get serviceaccount '%s' in namespace '%s': %v", registryFlags.ServiceAccount, ns.Name, err))
+					continue
+				}
+				existing, err := applyRegistrySecret(client, sa, secret, registryFlags.Server, registryFlags.Username)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				printRegistryApplied(cmd, existing, registryFlags.Server, registryFlags.ServiceAccount, ns.Name)
+			}
+			if len(errs) > 0 {
+				return fmt.Errorf("failed to add registry in %d namespace(s): %v", len(errs), utilerrors.NewAggregate(errs))
 			}
-
-			cmd.Printf("Private registry '%s' is added for serviceaccount '%s' in namespace '%s'\n", registryFlags.Server, registryFlags.ServiceAccount, namespace)
 			return nil
 		},
 	}
 
 	commands.AddNamespaceFlags(registryAddCmd.Flags(), false)
+	registryAddCmd.Flags().BoolVarP(&registryFlags.AllNamespaces, "all-namespaces", "A", false, "add the registry to the service account in all namespaces")
+	registryAddCmd.Flags().StringVar(&registryFlags.NamespaceSelector, "namespace-selector", "", "add the registry to the service account in the namespaces matching the label selector, e.g: 'team=x'")
 	registryAddCmd.Flags().StringVar(&registryFlags.ServiceAccount, "serviceaccount", "default", "the service account to save imagePullSecrets")
 	registryAddCmd.Flags().StringVar(&registryFlags.SecretName, "secret", "registry-secret", "registry secret name")
 	registryAddCmd.Flags().StringVar(&registryFlags.Server, "server", "", "registry address")
@@ -154,7 +200,185 @@ func NewRegistryAddCommand(p *pkg.AdminParams) *cobra.Command {
 	registryAddCmd.MarkFlagRequired("username")
 	registryAddCmd.Flags().StringVar(&registryFlags.Password, "password", "", "registry password")
 	registryAddCmd.MarkFlagRequired("password")
+	registryAddCmd.Flags().BoolVar(&registryFlags.Verify, "verify", false, "verify the credentials against the registry before saving them")
+	registryAddCmd.Flags().BoolVar(&registryFlags.Insecure, "insecure", false, insecureFlagUsage)
 
 	registryAddCmd.InitDefaultHelpFlag()
 	return registryAddCmd
 }
+
+// newRegistrySecret builds a registry secret with the given generate name and docker config json
+func newRegistrySecret(generateName string, dockerConfigJSON []byte) *corev1.Secret {
+	secretLabels := make(map[string]string, len(AdminRegistryLabels))
+	for k, v := range AdminRegistryLabels {
+		secretLabels[k] = v
+	}
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: generateName,
+			Labels:       secretLabels,
+		},
+		Data: map[string][]byte{
+			DockerJSONName: dockerConfigJSON,
+		},
+	}
+}
+
+// attachRegistrySecret creates the registry secret in the namespace of the service account
+// and adds it to the ImagePullSecrets of the service account
+func attachRegistrySecret(client kubernetes.Interface, sa *corev1.ServiceAccount, template *corev1.Secret) (*corev1.Secret, error) {
+	namespace := sa.Namespace
+	secret := template.DeepCopy()
+	secret.Namespace = namespace
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	// record the service account that using this secret as image pull secret
+	secret.Labels[ImagePullServiceAccount] = sa.Name
+
+	secret, err := client.CoreV1().Secrets(namespace).Create(context.TODO(), secret, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secret in namespace '%s': %v", namespace, err)
+	}
+
+	desiredSa := sa.DeepCopy()
+	desiredSa.ImagePullSecrets = append(desiredSa.ImagePullSecrets, corev1.LocalObjectReference{
+		Name: secret.Name,
+	})
+	_, err = client.CoreV1().ServiceAccounts(namespace).Update(context.TODO(), desiredSa, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add registry secret in serviceaccount '%s' in namespace '%s': %v", sa.Name, namespace, err)
+	}
+	return secret, nil
+}
+
+// registrySecretKey identifies the registry secrets added with the same server and username for a service account
+type registrySecretKey struct {
+	namespace      string
+	serviceAccount string
+	server         string
+	username       string
+}
+
+// registrySecretKeyOf returns the key of the registry secret, the secret is skipped
+// if it does not contain exactly one registry
+func registrySecretKeyOf(secret *corev1.Secret) (registrySecretKey, bool, error) {
+	auths, err := parseAuths(secret)
+	if err != nil {
+		return registrySecretKey{}, false, err
+	}
+	if len(auths) != 1 {
+		return registrySecretKey{}, false, nil
+	}
+	for server, auth := range auths {
+		return registrySecretKey{
+			namespace:      secret.Namespace,
+			serviceAccount: secret.Labels[ImagePullServiceAccount],
+			server:         server,
+			username:       auth.Username,
+		}, true, nil
+	}
+	return registrySecretKey{}, false, nil
+}
+
+// sortNewestFirst sorts the secrets by creation time from the newest to the oldest, then by name
+func sortNewestFirst(secrets []corev1.Secret) {
+	sort.SliceStable(secrets, func(i, j int) bool {
+		ti, tj := secrets[i].CreationTimestamp, secrets[j].CreationTimestamp
+		if !ti.Equal(&tj) {
+			return tj.Before(&ti)
+		}
+		return secrets[i].Name < secrets[j].Name
+	})
+}
+
+// applyRegistrySecret updates the newest registry secret added with the same server and username for the service account,
+// the secret is created from the template if there is none, it returns whether an existing secret is updated
+func applyRegistrySecret(client kubernetes.Interface, sa *corev1.ServiceAccount, template *corev1.Secret, server, username string) (bool, error) {
+	namespace := sa.Namespace
+	secrets, err := client.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Merge(AdminRegistryLabels, labels.Set{ImagePullServiceAccount: sa.Name})).String(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to list secret in namespace '%s': %v", namespace, err)
+	}
+
+	matched := []corev1.Secret{}
+	for _, secret := range secrets.Items {
+		key, ok, err := registrySecretKeyOf(&secret)
+		if err != nil {
+			return false, err
+		}
+		if ok && key.server == server && key.username == username {
+			matched = append(matched, secret)
+		}
+	}
+	if len(matched) == 0 {
+		_, err = attachRegistrySecret(client, sa, template)
+		return false, err
+	}
+
+	sortNewestFirst(matched)
+	existing := matched[0]
+	desiredSecret := existing.DeepCopy()
+	// the type of a secret is immutable, so an adopted '.dockercfg' secret is updated in its own format
+	desiredSecret.Data, err = registrySecretData(existing.Type, template)
+	if err != nil {
+		return false, err
+	}
+	for k, v := range template.Annotations {
+		if desiredSecret.Annotations == nil {
+			desiredSecret.Annotations = map[string]string{}
+		}
+		desiredSecret.Annotations[k] = v
+	}
+	if !equality.Semantic.DeepEqual(&existing, desiredSecret) {
+		_, err = client.CoreV1().Secrets(namespace).Update(context.TODO(), desiredSecret, metav1.UpdateOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to update secret '%s' in namespace '%s': %v", existing.Name, namespace, err)
+		}
+	}
+
+	if !hasImagePullSecret(sa, existing.Name) {
+		desiredSa := sa.DeepCopy()
+		desiredSa.ImagePullSecrets = append(desiredSa.ImagePullSecrets, corev1.LocalObjectReference{
+			Name: existing.Name,
+		})
+		_, err = client.CoreV1().ServiceAccounts(namespace).Update(context.TODO(), desiredSa, metav1.UpdateOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to add registry secret in serviceaccount '%s' in namespace '%s': %v", sa.Name, namespace, err)
+		}
+	}
+	return true, nil
+}
+
+// registrySecretData returns the data of the registry secret template in the format of the secret type, i.e:
+// '.dockercfg' for the legacy type and '.dockerconfigjson' otherwise
+func registrySecretData(secretType corev1.SecretType, template *corev1.Secret) (map[string][]byte, error) {
+	if secretType != corev1.SecretTypeDockercfg {
+		return map[string][]byte{DockerJSONName: template.Data[DockerJSONName]}, nil
+	}
+	auths, err := parseAuths(template)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(auths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal secret data '%s': %v", DockerCfgName, err)
+	}
+	return map[string][]byte{DockerCfgName: data}, nil
+}
+
+// printRegistryApplied prints whether the registry is added or updated for the service account
+func printRegistryApplied(cmd *cobra.Command, updated bool, server, serviceAccount, namespace string) {
+	if updated {
+		cmd.Printf("Private registry '%s' is updated for serviceaccount '%s' in namespace '%s'\n", server, serviceAccount, namespace)
+		return
+	}
+	cmd.Printf("Private registry '%s' is added for serviceaccount '%s' in namespace '%s'\n", server, serviceAccount, namespace)
+}
@@ -17,7 +17,9 @@ package registry
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -186,6 +188,198 @@ func TestNewRegistryAddCommand(t *testing.T) {
 		assert.NilError(t, err)
 		assert.Equal(t, 2, len(saUpdated.ImagePullSecrets))
 	})
+
+	t.Run("adding registry secret twice updates the existing secret", func(t *testing.T) {
+		sa := corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "default",
+				Namespace: "default",
+			},
+		}
+
+		p, client := testutil.NewTestAdminParams(&sa)
+		assert.Check(t, client != nil)
+		client.PrependReactor("create", "secrets", generateNameReactor)
+		cmd := NewRegistryAddCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "--username", "user", "--password", "test", "--server", "docker.io")
+		assert.NilError(t, err)
+
+		cmd = NewRegistryAddCommand(p)
+		o, err := testutil.ExecuteCommand(cmd, "--username", "user", "--password", "new-password", "--server", "docker.io")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "Private registry 'docker.io' is updated for serviceaccount 'default' in namespace 'default'"), "unexpected output: %s", o)
+
+		secrets, err := client.CoreV1().Secrets(sa.Namespace).List(context.TODO(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, 1, len(secrets.Items), "got secrets: %#v", secrets)
+		auths, err := parseAuths(&secrets.Items[0])
+		assert.NilError(t, err)
+		assert.Equal(t, "new-password", auths["docker.io"].Password)
+
+		saUpdated, err := client.CoreV1().ServiceAccounts(sa.Namespace).Get(context.TODO(), sa.Name, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []corev1.LocalObjectReference{{Name: secrets.Items[0].Name}}, saUpdated.ImagePullSecrets)
+
+		// a different username is a different registry setting
+		cmd = NewRegistryAddCommand(p)
+		o, err = testutil.ExecuteCommand(cmd, "--username", "another-user", "--password", "test", "--server", "docker.io")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "is added for serviceaccount"), "unexpected output: %s", o)
+		secrets, err = client.CoreV1().Secrets(sa.Namespace).List(context.TODO(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, 2, len(secrets.Items), "got secrets: %#v", secrets)
+	})
+
+	t.Run("adding registry secret updates the adopted dockercfg secret", func(t *testing.T) {
+		sa := corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "default",
+				Namespace: "default",
+			},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "legacy-secret"}},
+		}
+		legacy := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "legacy-secret",
+				Namespace: "default",
+			},
+			Type: corev1.SecretTypeDockercfg,
+			Data: map[string][]byte{
+				DockerCfgName: []byte(`{"docker.io":{"Username":"user","Password":"test"}}`),
+			},
+		}
+		p, client := testutil.NewTestAdminParams(&sa, legacy)
+		_, err := testutil.ExecuteCommand(NewRegistryAdoptCommand(p), "legacy-secret")
+		assert.NilError(t, err)
+		// the type of a secret can't be changed
+		client.PrependReactor("update", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if secret := action.(k8stesting.UpdateAction).GetObject().(*corev1.Secret); secret.Type != corev1.SecretTypeDockercfg {
+				return true, nil, errors.New("field is immutable")
+			}
+			return false, nil, nil
+		})
+
+		o, err := testutil.ExecuteCommand(NewRegistryAddCommand(p), "--username", "user", "--password", "new-password", "--server", "docker.io")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "Private registry 'docker.io' is updated for serviceaccount 'default' in namespace 'default'"), "unexpected output: %s", o)
+
+		updated, err := client.CoreV1().Secrets("default").Get(context.TODO(), "legacy-secret", metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, corev1.SecretTypeDockercfg, updated.Type)
+		_, ok := updated.Data[DockerJSONName]
+		assert.Check(t, !ok, "unexpected data: %v", updated.Data)
+		auths, err := parseAuths(updated)
+		assert.NilError(t, err)
+		assert.Equal(t, "new-password", auths["docker.io"].Password)
+	})
+
+	t.Run("adding registry secret with credentials rejected by the registry", func(t *testing.T) {
+		server := httptest.NewServer(newBasicAuthRegistry("user", "password"))
+		defer server.Close()
+		sa := corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "default",
+				Namespace: "default",
+			},
+		}
+
+		p, client := testutil.NewTestAdminParams(&sa)
+		assert.Check(t, client != nil)
+		client.PrependReactor("create", "secrets", generateNameReactor)
+		cmd := NewRegistryAddCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "--username", "user", "--password", "wrong", "--server", server.URL, "--verify")
+		assert.ErrorContains(t, err, "failed to verify registry")
+
+		secrets, err := client.CoreV1().Secrets(sa.Namespace).List(context.TODO(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, 0, len(secrets.Items), "got secrets: %#v", secrets)
+
+		cmd = NewRegistryAddCommand(p)
+		_, err = testutil.ExecuteCommand(cmd, "--username", "user", "--password", "password", "--server", server.URL, "--verify")
+		assert.NilError(t, err)
+	})
+
+	t.Run("flags conflict for adding registry in multiple namespaces", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams()
+		assert.Check(t, client != nil)
+
+		cmd := NewRegistryAddCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "--username", "user", "--password", "test", "--server", "docker.io", "--all-namespaces", "--namespace-selector", "team=x")
+		assert.ErrorContains(t, err, "can not be used together")
+
+		cmd = NewRegistryAddCommand(p)
+		_, err = testutil.ExecuteCommand(cmd, "--username", "user", "--password", "test", "--server", "docker.io", "--namespace-selector", "team=x", "--namespace", "default")
+		assert.ErrorContains(t, err, "flag '--namespace' can not be used with")
+
+		cmd = NewRegistryAddCommand(p)
+		_, err = testutil.ExecuteCommand(cmd, "--username", "user", "--password", "test", "--server", "docker.io", "--namespace-selector", "team in (x")
+		assert.ErrorContains(t, err, "invalid namespace selector")
+	})
+
+	t.Run("adding registry secret in namespaces matching selector", func(t *testing.T) {
+		objects := []runtime.Object{}
+		for name, team := range map[string]string{"ns1": "x", "ns2": "x", "ns3": "y"} {
+			objects = append(objects,
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"team": team}}},
+				&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: name}},
+			)
+		}
+		// namespace without the service account should be skipped
+		objects = append(objects, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns4", Labels: map[string]string{"team": "x"}}})
+
+		p, client := testutil.NewTestAdminParams(objects...)
+		assert.Check(t, client != nil)
+		client.PrependReactor("create", "secrets", generateNameReactor)
+		cmd := NewRegistryAddCommand(p)
+		o, err := testutil.ExecuteCommand(cmd, "--username", "user", "--password", "test", "--server", "docker.io", "--namespace-selector", "team=x")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "Private registry 'docker.io' is added for serviceaccount 'default' in namespace 'ns1'"), "unexpected output: %s", o)
+		assert.Check(t, strings.Contains(o, "Private registry 'docker.io' is added for serviceaccount 'default' in namespace 'ns2'"), "unexpected output: %s", o)
+		assert.Check(t, strings.Contains(o, "Serviceaccount 'default' in namespace 'ns4' is not found, skipped"), "unexpected output: %s", o)
+
+		for ns, expected := range map[string]int{"ns1": 1, "ns2": 1, "ns3": 0, "ns4": 0} {
+			secrets, err := client.CoreV1().Secrets(ns).List(context.TODO(), metav1.ListOptions{})
+			assert.NilError(t, err)
+			assert.Equal(t, expected, len(secrets.Items), "unexpected secrets in namespace %s: %#v", ns, secrets)
+			if expected == 0 {
+				continue
+			}
+			secret := secrets.Items[0]
+			assert.Equal(t, "team=x", secret.Annotations[ImagePullNamespaceSelector])
+			assert.Equal(t, "default", secret.Labels[ImagePullServiceAccount])
+
+			saUpdated, err := client.CoreV1().ServiceAccounts(ns).Get(context.TODO(), "default", metav1.GetOptions{})
+			assert.NilError(t, err)
+			assert.Equal(t, 1, len(saUpdated.ImagePullSecrets))
+			assert.Equal(t, secret.Name, saUpdated.ImagePullSecrets[0].Name)
+		}
+	})
+
+	t.Run("adding registry secret in all namespaces", func(t *testing.T) {
+		objects := []runtime.Object{}
+		for _, name := range []string{"ns1", "ns2"} {
+			objects = append(objects,
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}},
+				&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: name}},
+			)
+		}
+
+		p, client := testutil.NewTestAdminParams(objects...)
+		assert.Check(t, client != nil)
+		client.PrependReactor("create", "secrets", generateNameReactor)
+		cmd := NewRegistryAddCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "--username", "user", "--password", "test", "--server", "docker.io", "--all-namespaces")
+		assert.NilError(t, err)
+
+		secrets, err := client.CoreV1().Secrets("").List(context.TODO(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, 2, len(secrets.Items), "got secrets: %#v", secrets)
+		for _, secret := range secrets.Items {
+			selector, ok := secret.Annotations[ImagePullNamespaceSelector]
+			assert.Check(t, ok)
+			assert.Equal(t, "", selector)
+		}
+	})
 }
 
 func generateNameReactor(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
@@ -0,0 +1,138 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"knative.dev/client/pkg/commands"
+	"knative.dev/kn-plugin-admin/pkg"
+)
+
+// NewRegistryAdoptCommand represents the adopt command
+func NewRegistryAdoptCommand(p *pkg.AdminParams) *cobra.Command {
+	var serviceaccount string
+
+	var registryAdoptCmd = &cobra.Command{
+		Use:   "adopt SECRET",
+		Short: "Adopt an existing image pull secret",
+		Long:  `Adopt an existing image pull secret created by other tools, so that it can be managed by registry commands`,
+		Example: `
+  # To adopt an existing image pull secret used by service account
+  kn admin registry adopt [SECRET_NAME] \
+    --namespace=[NAMESPACE] \
+    --serviceaccount=[SERVICE_ACCOUNT]`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("'registry adopt' requires the secret name given as single argument")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			namespace := cmd.Flag("namespace").Value.String()
+			if namespace == "" {
+				namespace = "default"
+			}
+
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+
+			secret, err := client.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get secret '%s' in namespace '%s': %v", name, namespace, err)
+			}
+			if !isImagePullSecret(secret) {
+				return fmt.Errorf("secret '%s' in namespace '%s' is not an image pull secret, type: '%s'", name, namespace, secret.Type)
+			}
+			if _, err = parseAuths(secret); err != nil {
+				return err
+			}
+			if secret.Labels[pkg.LabelManagedBy] == AdminRegistryCmdName {
+				cmd.Printf("Secret '%s' in namespace '%s' is already managed\n", name, namespace)
+				return nil
+			}
+
+			serviceaccount, err = adoptingServiceAccount(client, secret, serviceaccount)
+			if err != nil {
+				return err
+			}
+
+			desiredSecret := secret.DeepCopy()
+			if desiredSecret.Labels == nil {
+				desiredSecret.Labels = map[string]string{}
+			}
+			for k, v := range AdminRegistryLabels {
+				desiredSecret.Labels[k] = v
+			}
+			desiredSecret.Labels[ImagePullServiceAccount] = serviceaccount
+			_, err = client.CoreV1().Secrets(namespace).Update(context.TODO(), desiredSecret, metav1.UpdateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to update secret label in namespace '%s': %v", namespace, err)
+			}
+
+			cmd.Printf("Secret '%s' in namespace '%s' is adopted for serviceaccount '%s'\n", name, namespace, serviceaccount)
+			return nil
+		},
+	}
+
+	commands.AddNamespaceFlags(registryAdoptCmd.Flags(), false)
+	registryAdoptCmd.Flags().StringVar(&serviceaccount, "serviceaccount", "", "the service account using the secret as imagePullSecrets, defaults to the only one using it")
+	registryAdoptCmd.InitDefaultHelpFlag()
+	return registryAdoptCmd
+}
+
+// adoptingServiceAccount returns the service account the secret is adopted for, which must use the secret
+// as image pull secret, the only service account using the secret is returned if serviceaccount is empty
+func adoptingServiceAccount(client kubernetes.Interface, secret *corev1.Secret, serviceaccount string) (string, error) {
+	serviceAccounts, err := client.CoreV1().ServiceAccounts(secret.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list serviceaccount in namespace '%s': %v", secret.Namespace, err)
+	}
+	users := []string{}
+	for i := range serviceAccounts.Items {
+		if hasImagePullSecret(&serviceAccounts.Items[i], secret.Name) {
+			users = append(users, serviceAccounts.Items[i].Name)
+		}
+	}
+
+	switch {
+	case serviceaccount != "":
+		for _, user := range users {
+			if user == serviceaccount {
+				return serviceaccount, nil
+			}
+		}
+		return "", fmt.Errorf("serviceaccount '%s' in namespace '%s' doesn't use secret '%s' as image pull secret",
+			serviceaccount, secret.Namespace, secret.Name)
+	case len(users) == 0:
+		return "", fmt.Errorf("secret '%s' in namespace '%s' isn't used as image pull secret by any serviceaccount",
+			secret.Name, secret.Namespace)
+	case len(users) > 1:
+		return "", fmt.Errorf("secret '%s' in namespace '%s' is used by serviceaccounts '%s', specify one with the --serviceaccount option",
+			secret.Name, secret.Namespace, strings.Join(users, "', '"))
+	}
+	return users[0], nil
+}
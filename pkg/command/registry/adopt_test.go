@@ -0,0 +1,168 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+func TestNewRegistryAdoptCommand(t *testing.T) {
+	t.Run("kubectl context is not set", func(t *testing.T) {
+		p := testutil.NewTestAdminWithoutKubeConfig()
+		cmd := NewRegistryAdoptCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "test-secret")
+		assert.Error(t, err, testutil.ErrNoKubeConfiguration)
+	})
+
+	t.Run("no secret name given", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams()
+		assert.Check(t, client != nil)
+		cmd := NewRegistryAdoptCommand(p)
+		_, err := testutil.ExecuteCommand(cmd)
+		assert.ErrorContains(t, err, "requires the secret name")
+	})
+
+	t.Run("secret not found", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams()
+		assert.Check(t, client != nil)
+		cmd := NewRegistryAdoptCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "test-secret")
+		assert.ErrorContains(t, err, "failed to get secret 'test-secret'")
+	})
+
+	t.Run("secret is not an image pull secret", func(t *testing.T) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-secret",
+				Namespace: "default",
+			},
+			Type: corev1.SecretTypeOpaque,
+		}
+		p, client := testutil.NewTestAdminParams(secret)
+		assert.Check(t, client != nil)
+		cmd := NewRegistryAdoptCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "test-secret")
+		assert.ErrorContains(t, err, "is not an image pull secret")
+	})
+
+	t.Run("adopt secret successfully", func(t *testing.T) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-secret",
+				Namespace: "custom-namespace",
+				Labels: map[string]string{
+					"app": "test",
+				},
+			},
+			Type: corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{
+				DockerJSONName: []byte(`{"auths":{"docker.io":{"username":"user","password":"password"}}}`),
+			},
+		}
+		sa := &corev1.ServiceAccount{
+			ObjectMeta:       metav1.ObjectMeta{Name: "custom-serviceaccount", Namespace: "custom-namespace"},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "test-secret"}},
+		}
+		p, client := testutil.NewTestAdminParams(secret, sa)
+		assert.Check(t, client != nil)
+		cmd := NewRegistryAdoptCommand(p)
+		o, err := testutil.ExecuteCommand(cmd, "test-secret", "--namespace", "custom-namespace", "--serviceaccount", "custom-serviceaccount")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "Secret 'test-secret' in namespace 'custom-namespace' is adopted for serviceaccount 'custom-serviceaccount'"), "unexpected output: %s", o)
+
+		updated, err := client.CoreV1().Secrets("custom-namespace").Get(context.TODO(), "test-secret", metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, AdminRegistryCmdName, updated.Labels[pkg.LabelManagedBy])
+		assert.Equal(t, "custom-serviceaccount", updated.Labels[ImagePullServiceAccount])
+		assert.Equal(t, "test", updated.Labels["app"])
+
+		cmd = NewRegistryAdoptCommand(p)
+		o, err = testutil.ExecuteCommand(cmd, "test-secret", "--namespace", "custom-namespace")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "is already managed"), "unexpected output: %s", o)
+	})
+
+	t.Run("serviceaccount doesn't use the secret", func(t *testing.T) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+		}
+		sa := &corev1.ServiceAccount{
+			ObjectMeta:       metav1.ObjectMeta{Name: "builder", Namespace: "default"},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "test-secret"}},
+		}
+		p, client := testutil.NewTestAdminParams(secret, sa, &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default"},
+		})
+		_, err := testutil.ExecuteCommand(NewRegistryAdoptCommand(p), "test-secret", "--serviceaccount", "default")
+		assert.ErrorContains(t, err, "serviceaccount 'default' in namespace 'default' doesn't use secret 'test-secret' as image pull secret")
+
+		updated, err := client.CoreV1().Secrets("default").Get(context.TODO(), "test-secret", metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Check(t, updated.Labels[pkg.LabelManagedBy] == "", "secret should not be adopted")
+	})
+
+	t.Run("adopt secret for the serviceaccount using it", func(t *testing.T) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+		}
+		builder := &corev1.ServiceAccount{
+			ObjectMeta:       metav1.ObjectMeta{Name: "builder", Namespace: "default"},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "test-secret"}},
+		}
+		p, client := testutil.NewTestAdminParams(secret, builder)
+		o, err := testutil.ExecuteCommand(NewRegistryAdoptCommand(p), "test-secret")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "Secret 'test-secret' in namespace 'default' is adopted for serviceaccount 'builder'"), "unexpected output: %s", o)
+
+		updated, err := client.CoreV1().Secrets("default").Get(context.TODO(), "test-secret", metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "builder", updated.Labels[ImagePullServiceAccount])
+	})
+
+	t.Run("secret not used by any serviceaccount", func(t *testing.T) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+		}
+		p, _ := testutil.NewTestAdminParams(secret)
+		_, err := testutil.ExecuteCommand(NewRegistryAdoptCommand(p), "test-secret")
+		assert.ErrorContains(t, err, "isn't used as image pull secret by any serviceaccount")
+	})
+
+	t.Run("secret used by several serviceaccounts", func(t *testing.T) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+		}
+		users := []corev1.LocalObjectReference{{Name: "test-secret"}}
+		p, _ := testutil.NewTestAdminParams(secret,
+			&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "builder", Namespace: "default"}, ImagePullSecrets: users},
+			&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default"}, ImagePullSecrets: users})
+		_, err := testutil.ExecuteCommand(NewRegistryAdoptCommand(p), "test-secret")
+		assert.ErrorContains(t, err, "is used by serviceaccounts 'builder', 'default', specify one with the --serviceaccount option")
+	})
+}
@@ -0,0 +1,507 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metav1beta1 "k8s.io/apimachinery/pkg/apis/meta/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"knative.dev/client/pkg/commands/flags"
+	hprinters "knative.dev/client/pkg/printers"
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/command/utils"
+)
+
+var (
+	// registryCACerts is the ConfigMap in knative-serving namespace storing the CA certificates of registries
+	registryCACerts = "registry-custom-certs"
+	// controllerDeployment is the Deployment of Knative Serving controller which resolves tags to digests
+	controllerDeployment = "controller"
+
+	// customCertsVolume, customCertsMountPath and customCertsField follow the conventions of Knative Operator
+	customCertsVolume    = "custom-certs"
+	customCertsMountPath = "/knative-custom-certs"
+	customCertsField     = "controller-custom-certs"
+	sslCertDirEnv        = "SSL_CERT_DIR"
+)
+
+// NewRegistryCACommand represents the ca command
+func NewRegistryCACommand(p *pkg.AdminParams) *cobra.Command {
+	var caCmd = &cobra.Command{
+		Use:   "ca",
+		Short: "Manage CA certificates of registries",
+		Long: `Manage CA certificates trusted by Knative Serving controller when resolving tags to digests,
+e.g: the CA of a private registry`,
+	}
+	caCmd.AddCommand(newRegistryCAAddCommand(p))
+	caCmd.AddCommand(newRegistryCAListCommand(p))
+	caCmd.AddCommand(newRegistryCARemoveCommand(p))
+	return caCmd
+}
+
+func newRegistryCAAddCommand(p *pkg.AdminParams) *cobra.Command {
+	var (
+		cert string
+		name string
+	)
+	caAddCmd := &cobra.Command{
+		Use:   "add",
+		Short: "Add a CA certificate for registries",
+		Long:  `Add a CA certificate bundle for registries and configure Knative Serving controller to trust it`,
+		Example: `
+  # To trust the CA of a private registry when resolving tags to digests
+  kn admin registry ca add --cert ca.pem`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if cert == "" {
+				return errors.New("'registry ca add' requires the CA certificate file provided with the --cert option")
+			}
+			if name == "" {
+				name = filepath.Base(cert)
+			}
+			if errs := validation.IsConfigMapKey(name); len(errs) > 0 {
+				return fmt.Errorf("invalid CA certificate name '%s': %s", name, strings.Join(errs, ", "))
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(cert)
+			if err != nil {
+				return fmt.Errorf("failed to read CA certificate file '%s': %v", cert, err)
+			}
+			if _, err = parseCertificates(data); err != nil {
+				return fmt.Errorf("invalid CA certificate file '%s': %v", cert, err)
+			}
+
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+
+			cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), registryCACerts, metav1.GetOptions{})
+			switch {
+			case apierrors.IsNotFound(err):
+				cm = &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      registryCACerts,
+						Namespace: knativeServing,
+						Labels: map[string]string{
+							pkg.LabelManagedBy: AdminRegistryCmdName,
+						},
+					},
+					Data: map[string]string{name: string(data)},
+				}
+				if _, err = client.CoreV1().ConfigMaps(knativeServing).Create(context.TODO(), cm, metav1.CreateOptions{}); err != nil {
+					return fmt.Errorf("failed to create ConfigMap %s in namespace %s: %+v", registryCACerts, knativeServing, err)
+				}
+			case err != nil:
+				return fmt.Errorf("failed to get ConfigMap %s in namespace %s: %+v", registryCACerts, knativeServing, err)
+			default:
+				desiredCm := cm.DeepCopy()
+				if desiredCm.Data == nil {
+					desiredCm.Data = map[string]string{}
+				}
+				desiredCm.Data[name] = string(data)
+				if err = utils.UpdateConfigMap(client, desiredCm); err != nil {
+					return fmt.Errorf("failed to update ConfigMap %s in namespace %s: %+v", registryCACerts, knativeServing, err)
+				}
+			}
+			cmd.Printf("CA certificate '%s' is added for registries\n", name)
+
+			return configureCustomCerts(cmd, p, true)
+		},
+	}
+	caAddCmd.Flags().StringVar(&cert, "cert", "", "the file of the PEM encoded CA certificate bundle")
+	caAddCmd.MarkFlagRequired("cert")
+	caAddCmd.Flags().StringVar(&name, "name", "", "the name of the CA certificate, defaults to the file name")
+	caAddCmd.InitDefaultHelpFlag()
+	return caAddCmd
+}
+
+func registryCAListHandlers(h hprinters.PrintHandler) {
+	columnDefinitions := []metav1beta1.TableColumnDefinition{
+		{Name: "Name", Type: "string", Description: "Name of the CA certificate.", Priority: 1},
+		{Name: "Subject", Type: "string", Description: "Subject of the CA certificate.", Priority: 1},
+		{Name: "Expires", Type: "string", Description: "Expiration time of the CA certificate.", Priority: 1},
+	}
+	h.TableHandler(columnDefinitions, printRegistryCACerts)
+}
+
+// printRegistryCACerts populates a table row for each certificate in the CA bundles
+func printRegistryCACerts(cm *corev1.ConfigMap, options hprinters.PrintOptions) ([]metav1beta1.TableRow, error) {
+	names := make([]string, 0, len(cm.Data))
+	for name := range cm.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := []metav1beta1.TableRow{}
+	for _, name := range names {
+		certs, err := parseCertificates([]byte(cm.Data[name]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CA certificate '%s': %v", name, err)
+		}
+		for _, c := range certs {
+			row := metav1beta1.TableRow{}
+			row.Cells = append(row.Cells, name, c.Subject.String(), c.NotAfter.UTC().Format(time.RFC3339))
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func newRegistryCAListCommand(p *pkg.AdminParams) *cobra.Command {
+	listFlags := flags.NewListPrintFlags(registryCAListHandlers)
+	caListCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List CA certificates of registries",
+		Long:    `List CA certificates of registries trusted by Knative Serving controller`,
+		Example: `
+  # To list CA certificates of registries
+  kn admin registry ca list`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+			cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), registryCACerts, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				cmd.Println("No CA certificate found for registries")
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get ConfigMap %s in namespace %s: %+v", registryCACerts, knativeServing, err)
+			}
+			return listFlags.Print(cm, cmd.OutOrStdout())
+		},
+	}
+	listFlags.HumanReadableFlags.AddFlags(caListCmd)
+	listFlags.GenericPrintFlags.OutputFlagSpecified = func() bool {
+		return false
+	}
+	return caListCmd
+}
+
+func newRegistryCARemoveCommand(p *pkg.AdminParams) *cobra.Command {
+	caRemoveCmd := &cobra.Command{
+		Use:   "remove NAME...",
+		Short: "Remove CA certificates of registries",
+		Long: `Remove CA certificates of registries, Knative Serving controller does not mount the CA certificates
+any more once all of them are removed`,
+		Example: `
+  # To remove the CA certificate ca.pem
+  kn admin registry ca remove ca.pem`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("'registry ca remove' requires the name(s) of CA certificate as arguments")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+			cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), registryCACerts, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get ConfigMap %s in namespace %s: %+v", registryCACerts, knativeServing, err)
+			}
+
+			desiredCm := cm.DeepCopy()
+			for _, name := range args {
+				if _, ok := desiredCm.Data[name]; !ok {
+					return fmt.Errorf("CA certificate '%s' is not found", name)
+				}
+				delete(desiredCm.Data, name)
+			}
+
+			if len(desiredCm.Data) > 0 {
+				if err = utils.UpdateConfigMap(client, desiredCm); err != nil {
+					return fmt.Errorf("failed to update ConfigMap %s in namespace %s: %+v", registryCACerts, knativeServing, err)
+				}
+				for _, name := range args {
+					cmd.Printf("CA certificate '%s' is removed\n", name)
+				}
+				return nil
+			}
+
+			// stop mounting the CA certificates before deleting the ConfigMap, otherwise the controller can not start
+			if err = configureCustomCerts(cmd, p, false); err != nil {
+				return err
+			}
+			if err = client.CoreV1().ConfigMaps(knativeServing).Delete(context.TODO(), registryCACerts, metav1.DeleteOptions{}); err != nil {
+				return fmt.Errorf("failed to delete ConfigMap %s in namespace %s: %+v", registryCACerts, knativeServing, err)
+			}
+			for _, name := range args {
+				cmd.Printf("CA certificate '%s' is removed\n", name)
+			}
+			return nil
+		},
+	}
+	caRemoveCmd.InitDefaultHelpFlag()
+	return caRemoveCmd
+}
+
+// parseCertificates parses the PEM encoded certificates, at least one certificate is required
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	certs := []*x509.Certificate{}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	return certs, nil
+}
+
+// configureCustomCerts configures Knative Serving controller to trust the CA certificates or not,
+// depending on how Knative is installed
+func configureCustomCerts(cmd *cobra.Command, p *pkg.AdminParams, enabled bool) error {
+	switch p.InstallationMethod {
+	case pkg.InstallationMethodStandalone:
+		client, err := p.NewKubeClient()
+		if err != nil {
+			return err
+		}
+		updated, err := updateControllerDeployment(client, enabled)
+		if err != nil {
+			return err
+		}
+		if updated {
+			cmd.Printf("Deployment '%s' in namespace '%s' is updated\n", controllerDeployment, knativeServing)
+		}
+		return nil
+	case pkg.InstallationMethodOperator:
+		updated, name, err := updateKnativeServingCustomCerts(p, enabled)
+		if err != nil {
+			return err
+		}
+		if updated {
+			cmd.Printf("KnativeServing '%s' in namespace '%s' is updated\n", name, knativeServing)
+		}
+		return nil
+	}
+	return pkg.ErrorInstallationMethodUnknown
+}
+
+// updateControllerDeployment mounts the CA certificates into the controller and adds them to SSL_CERT_DIR,
+// or reverts it if enabled is false, it returns whether the Deployment is updated
+func updateControllerDeployment(client kubernetes.Interface, enabled bool) (bool, error) {
+	updated := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		deployment, err := client.AppsV1().Deployments(knativeServing).Get(context.TODO(), controllerDeployment, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		desired := deployment.DeepCopy()
+		if !setCustomCerts(desired, enabled) {
+			return nil
+		}
+		if _, err = client.AppsV1().Deployments(knativeServing).Update(context.TODO(), desired, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		updated = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update Deployment %s in namespace %s: %+v", controllerDeployment, knativeServing, err)
+	}
+	return updated, nil
+}
+
+// setCustomCerts adds or removes the volume, volume mount and SSL_CERT_DIR of the CA certificates
+// in the controller Deployment, it returns whether the Deployment is changed
+func setCustomCerts(deployment *appsv1.Deployment, enabled bool) bool {
+	changed := false
+	podSpec := &deployment.Spec.Template.Spec
+
+	volumes := []corev1.Volume{}
+	found := false
+	for _, v := range podSpec.Volumes {
+		if v.Name == customCertsVolume {
+			found = true
+			if !enabled {
+				changed = true
+				continue
+			}
+		}
+		volumes = append(volumes, v)
+	}
+	if enabled && !found {
+		volumes = append(volumes, corev1.Volume{
+			Name: customCertsVolume,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: registryCACerts},
+				},
+			},
+		})
+		changed = true
+	}
+	podSpec.Volumes = volumes
+
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.Name != controllerDeployment {
+			continue
+		}
+
+		mounts := []corev1.VolumeMount{}
+		found = false
+		for _, m := range c.VolumeMounts {
+			if m.Name == customCertsVolume {
+				found = true
+				if !enabled {
+					changed = true
+					continue
+				}
+			}
+			mounts = append(mounts, m)
+		}
+		if enabled && !found {
+			mounts = append(mounts, corev1.VolumeMount{
+				Name:      customCertsVolume,
+				MountPath: customCertsMountPath,
+				ReadOnly:  true,
+			})
+			changed = true
+		}
+		c.VolumeMounts = mounts
+
+		env := []corev1.EnvVar{}
+		found = false
+		for _, e := range c.Env {
+			if e.Name == sslCertDirEnv {
+				found = true
+				// SSL_CERT_DIR is a colon separated list, the directories set by others are kept
+				dirs := []string{}
+				for _, dir := range strings.Split(e.Value, ":") {
+					if dir != "" && dir != customCertsMountPath {
+						dirs = append(dirs, dir)
+					}
+				}
+				if enabled {
+					dirs = append(dirs, customCertsMountPath)
+				}
+				if value := strings.Join(dirs, ":"); value != e.Value {
+					changed = true
+					if value == "" {
+						continue
+					}
+					e.Value = value
+				}
+			}
+			env = append(env, e)
+		}
+		if enabled && !found {
+			env = append(env, corev1.EnvVar{Name: sslCertDirEnv, Value: customCertsMountPath})
+			changed = true
+		}
+		c.Env = env
+	}
+	return changed
+}
+
+// updateKnativeServingCustomCerts sets or removes the 'controller-custom-certs' field of KnativeServing,
+// it returns whether the KnativeServing is updated and its name
+func updateKnativeServingCustomCerts(p *pkg.AdminParams, enabled bool) (bool, string, error) {
+	client, err := p.NewDynamicClient()
+	if err != nil {
+		return false, "", err
+	}
+	resource := client.Resource(pkg.KnativeServingResource).Namespace(knativeServing)
+	list, err := resource.List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to list KnativeServing in namespace %s: %+v", knativeServing, err)
+	}
+	if len(list.Items) == 0 {
+		return false, "", fmt.Errorf("no KnativeServing found in namespace %s", knativeServing)
+	}
+
+	name := list.Items[0].GetName()
+	updated := false
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		ks, err := resource.Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		desired := ks.DeepCopy()
+		current, found, err := unstructured.NestedStringMap(ks.Object, "spec", customCertsField)
+		if err != nil {
+			return err
+		}
+		if enabled {
+			if current["type"] == "ConfigMap" && current["name"] == registryCACerts {
+				return nil
+			}
+			// the field takes a single ConfigMap or Secret, don't replace the certificates configured by others
+			if found && current["name"] != "" {
+				return fmt.Errorf("%s of KnativeServing already refers to %s '%s', add the registry CA certificates to it instead",
+					customCertsField, current["type"], current["name"])
+			}
+			if err = unstructured.SetNestedStringMap(desired.Object, map[string]string{
+				"type": "ConfigMap",
+				"name": registryCACerts,
+			}, "spec", customCertsField); err != nil {
+				return err
+			}
+		} else {
+			if !found || current["name"] != registryCACerts {
+				return nil
+			}
+			unstructured.RemoveNestedField(desired.Object, "spec", customCertsField)
+		}
+		if _, err = resource.Update(context.TODO(), desired, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		updated = true
+		return nil
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to update KnativeServing %s in namespace %s: %+v", name, knativeServing, err)
+	}
+	return updated, name, nil
+}
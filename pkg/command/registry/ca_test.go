@@ -0,0 +1,250 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+// writeTestCACert writes a self-signed CA certificate into a temporary file and returns the file path
+func writeTestCACert(t *testing.T, commonName string) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+
+	path := filepath.Join(t.TempDir(), commonName+".pem")
+	assert.NilError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	return path
+}
+
+func newControllerDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      controllerDeployment,
+			Namespace: knativeServing,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: controllerDeployment,
+						Env:  []corev1.EnvVar{{Name: "SYSTEM_NAMESPACE", Value: knativeServing}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func TestNewRegistryCACommand(t *testing.T) {
+	cmd := NewRegistryCACommand(nil)
+	assert.Check(t, cmd.HasSubCommands(), "cmd ca should have subcommands")
+	assert.Equal(t, 3, len(cmd.Commands()), "ca command should have 3 subcommands")
+	for _, name := range []string{"add", "list", "remove"} {
+		_, _, err := cmd.Find([]string{name})
+		assert.NilError(t, err, "ca command should have %s subcommand", name)
+	}
+}
+
+func TestRegistryCAStandalone(t *testing.T) {
+	t.Run("invalid CA certificate", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newControllerDeployment())
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		path := filepath.Join(t.TempDir(), "ca.pem")
+		assert.NilError(t, os.WriteFile(path, []byte("not a certificate"), 0600))
+
+		_, err := testutil.ExecuteCommand(NewRegistryCACommand(p), "add", "--cert", path)
+		assert.ErrorContains(t, err, "no PEM encoded certificate found")
+
+		_, err = testutil.ExecuteCommand(NewRegistryCACommand(p), "add", "--cert", filepath.Join(t.TempDir(), "missing.pem"))
+		assert.ErrorContains(t, err, "failed to read CA certificate file")
+	})
+
+	t.Run("add, list and remove CA certificates", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams(newControllerDeployment())
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+
+		output, err := testutil.ExecuteCommand(NewRegistryCACommand(p), "add", "--cert", writeTestCACert(t, "registry-a"))
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "CA certificate 'registry-a.pem' is added for registries"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(output, "Deployment 'controller' in namespace 'knative-serving' is updated"), "unexpected output: %s", output)
+
+		output, err = testutil.ExecuteCommand(NewRegistryCACommand(p), "add", "--cert", writeTestCACert(t, "registry-b"), "--name", "b.pem")
+		assert.NilError(t, err)
+		assert.Check(t, !strings.Contains(output, "Deployment"), "deployment should not be updated again: %s", output)
+
+		deployment, err := client.AppsV1().Deployments(knativeServing).Get(context.TODO(), controllerDeployment, metav1.GetOptions{})
+		assert.NilError(t, err)
+		podSpec := deployment.Spec.Template.Spec
+		assert.Equal(t, 1, len(podSpec.Volumes))
+		assert.Equal(t, registryCACerts, podSpec.Volumes[0].ConfigMap.Name)
+		assert.DeepEqual(t, []corev1.VolumeMount{{Name: customCertsVolume, MountPath: customCertsMountPath, ReadOnly: true}}, podSpec.Containers[0].VolumeMounts)
+		assert.DeepEqual(t, []corev1.EnvVar{
+			{Name: "SYSTEM_NAMESPACE", Value: knativeServing},
+			{Name: sslCertDirEnv, Value: customCertsMountPath},
+		}, podSpec.Containers[0].Env)
+
+		output, err = testutil.ExecuteCommand(NewRegistryCACommand(p), "list")
+		assert.NilError(t, err)
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		assert.Equal(t, 3, len(lines))
+		assert.Check(t, strings.Contains(lines[0], "SUBJECT"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(lines[1], "b.pem") && strings.Contains(lines[1], "CN=registry-b") && strings.Contains(lines[1], "2030-01-01T00:00:00Z"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(lines[2], "registry-a.pem") && strings.Contains(lines[2], "CN=registry-a"), "unexpected output: %s", output)
+
+		_, err = testutil.ExecuteCommand(NewRegistryCACommand(p), "remove", "c.pem")
+		assert.ErrorContains(t, err, "CA certificate 'c.pem' is not found")
+
+		output, err = testutil.ExecuteCommand(NewRegistryCACommand(p), "remove", "b.pem")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "CA certificate 'b.pem' is removed"), "unexpected output: %s", output)
+		assert.Check(t, !strings.Contains(output, "Deployment"), "deployment should not be updated: %s", output)
+
+		output, err = testutil.ExecuteCommand(NewRegistryCACommand(p), "remove", "registry-a.pem")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Deployment 'controller' in namespace 'knative-serving' is updated"), "unexpected output: %s", output)
+
+		_, err = client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), registryCACerts, metav1.GetOptions{})
+		assert.ErrorContains(t, err, "not found")
+		deployment, err = client.AppsV1().Deployments(knativeServing).Get(context.TODO(), controllerDeployment, metav1.GetOptions{})
+		assert.NilError(t, err)
+		podSpec = deployment.Spec.Template.Spec
+		assert.Equal(t, 0, len(podSpec.Volumes))
+		assert.Equal(t, 0, len(podSpec.Containers[0].VolumeMounts))
+		assert.DeepEqual(t, []corev1.EnvVar{{Name: "SYSTEM_NAMESPACE", Value: knativeServing}}, podSpec.Containers[0].Env)
+
+		output, err = testutil.ExecuteCommand(NewRegistryCACommand(p), "list")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "No CA certificate found for registries"), "unexpected output: %s", output)
+	})
+
+	t.Run("keep the existing SSL_CERT_DIR", func(t *testing.T) {
+		deployment := newControllerDeployment()
+		deployment.Spec.Template.Spec.Containers[0].Env = append(deployment.Spec.Template.Spec.Containers[0].Env,
+			corev1.EnvVar{Name: sslCertDirEnv, Value: "/etc/ssl/certs"})
+		p, client := testutil.NewTestAdminParams(deployment)
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+
+		_, err := testutil.ExecuteCommand(NewRegistryCACommand(p), "add", "--cert", writeTestCACert(t, "registry-a"))
+		assert.NilError(t, err)
+		deployment, err = client.AppsV1().Deployments(knativeServing).Get(context.TODO(), controllerDeployment, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []corev1.EnvVar{
+			{Name: "SYSTEM_NAMESPACE", Value: knativeServing},
+			{Name: sslCertDirEnv, Value: "/etc/ssl/certs:" + customCertsMountPath},
+		}, deployment.Spec.Template.Spec.Containers[0].Env)
+
+		_, err = testutil.ExecuteCommand(NewRegistryCACommand(p), "remove", "registry-a.pem")
+		assert.NilError(t, err)
+		deployment, err = client.AppsV1().Deployments(knativeServing).Get(context.TODO(), controllerDeployment, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []corev1.EnvVar{
+			{Name: "SYSTEM_NAMESPACE", Value: knativeServing},
+			{Name: sslCertDirEnv, Value: "/etc/ssl/certs"},
+		}, deployment.Spec.Template.Spec.Containers[0].Env)
+	})
+}
+
+func TestRegistryCAOperator(t *testing.T) {
+	ks := &unstructured.Unstructured{}
+	ks.SetAPIVersion("operator.knative.dev/v1beta1")
+	ks.SetKind("KnativeServing")
+	ks.SetName("knative-serving")
+	ks.SetNamespace(knativeServing)
+	dynamicClient := testutil.NewTestDynamicClient(ks)
+
+	p, client := testutil.NewTestAdminParams()
+	p.InstallationMethod = pkg.InstallationMethodOperator
+	p.NewDynamicClient = func() (dynamic.Interface, error) {
+		return dynamicClient, nil
+	}
+
+	output, err := testutil.ExecuteCommand(NewRegistryCACommand(p), "add", "--cert", writeTestCACert(t, "registry"))
+	assert.NilError(t, err)
+	assert.Check(t, strings.Contains(output, "KnativeServing 'knative-serving' in namespace 'knative-serving' is updated"), "unexpected output: %s", output)
+
+	got, err := dynamicClient.Resource(pkg.KnativeServingResource).Namespace(knativeServing).Get(context.TODO(), "knative-serving", metav1.GetOptions{})
+	assert.NilError(t, err)
+	customCerts, _, err := unstructured.NestedStringMap(got.Object, "spec", customCertsField)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[string]string{"type": "ConfigMap", "name": registryCACerts}, customCerts)
+
+	_, err = client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), registryCACerts, metav1.GetOptions{})
+	assert.NilError(t, err)
+
+	_, err = testutil.ExecuteCommand(NewRegistryCACommand(p), "remove", "registry.pem")
+	assert.NilError(t, err)
+	got, err = dynamicClient.Resource(pkg.KnativeServingResource).Namespace(knativeServing).Get(context.TODO(), "knative-serving", metav1.GetOptions{})
+	assert.NilError(t, err)
+	_, found, err := unstructured.NestedStringMap(got.Object, "spec", customCertsField)
+	assert.NilError(t, err)
+	assert.Check(t, !found, "controller-custom-certs should be removed")
+}
+
+func TestRegistryCAOperatorCustomCertsInUse(t *testing.T) {
+	ks := &unstructured.Unstructured{}
+	ks.SetAPIVersion("operator.knative.dev/v1beta1")
+	ks.SetKind("KnativeServing")
+	ks.SetName("knative-serving")
+	ks.SetNamespace(knativeServing)
+	assert.NilError(t, unstructured.SetNestedStringMap(ks.Object, map[string]string{"type": "Secret", "name": "corporate-certs"}, "spec", customCertsField))
+	dynamicClient := testutil.NewTestDynamicClient(ks)
+
+	p, _ := testutil.NewTestAdminParams()
+	p.InstallationMethod = pkg.InstallationMethodOperator
+	p.NewDynamicClient = func() (dynamic.Interface, error) {
+		return dynamicClient, nil
+	}
+
+	_, err := testutil.ExecuteCommand(NewRegistryCACommand(p), "add", "--cert", writeTestCACert(t, "registry"))
+	assert.ErrorContains(t, err, "controller-custom-certs of KnativeServing already refers to Secret 'corporate-certs'")
+
+	got, err := dynamicClient.Resource(pkg.KnativeServingResource).Namespace(knativeServing).Get(context.TODO(), "knative-serving", metav1.GetOptions{})
+	assert.NilError(t, err)
+	customCerts, _, err := unstructured.NestedStringMap(got.Object, "spec", customCertsField)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[string]string{"type": "Secret", "name": "corporate-certs"}, customCerts)
+}
@@ -0,0 +1,161 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+
+	"knative.dev/client/pkg/commands"
+	"knative.dev/kn-plugin-admin/pkg"
+)
+
+// NewRegistryDedupeCommand represents the dedupe command
+func NewRegistryDedupeCommand(p *pkg.AdminParams) *cobra.Command {
+	var registryDedupeCmd = &cobra.Command{
+		Use:   "dedupe",
+		Short: "Merge duplicate registry settings",
+		Long: `Merge the registry secrets added with the same server and username for a service account,
+the newest secret is kept and the service accounts referencing the duplicates are updated to reference it`,
+		Example: `
+  # To merge duplicate registry settings in namespace 'default'
+  kn admin registry dedupe
+
+  # To merge duplicate registry settings in all namespaces
+  kn admin registry dedupe --all-namespaces`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace := cmd.Flag("namespace").Value.String()
+			if namespace == "" {
+				namespace = "default"
+			}
+			if allNamespaces, _ := cmd.Flags().GetBool("all-namespaces"); allNamespaces {
+				namespace = ""
+			}
+
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+
+			// get all credential secrets which have the label managed-by=kn-admin-registry
+			secrets, err := client.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{
+				LabelSelector: labels.SelectorFromSet(AdminRegistryLabels).String(),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to list secret: %v", err)
+			}
+
+			groups := make(map[registrySecretKey][]corev1.Secret)
+			for _, secret := range secrets.Items {
+				key, ok, err := registrySecretKeyOf(&secret)
+				if err != nil {
+					return err
+				}
+				if ok {
+					groups[key] = append(groups[key], secret)
+				}
+			}
+
+			keys := []registrySecretKey{}
+			for key, group := range groups {
+				if len(group) > 1 {
+					keys = append(keys, key)
+				}
+			}
+			if len(keys) == 0 {
+				cmd.Println("No duplicate registry found")
+				return nil
+			}
+			sort.Slice(keys, func(i, j int) bool {
+				return fmt.Sprintf("%v", keys[i]) < fmt.Sprintf("%v", keys[j])
+			})
+
+			errs := []error{}
+			for _, key := range keys {
+				if err := dedupeRegistrySecrets(cmd, client, groups[key]); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			if len(errs) > 0 {
+				return fmt.Errorf("failed to merge duplicate registries: %v", utilerrors.NewAggregate(errs))
+			}
+			return nil
+		},
+	}
+	commands.AddNamespaceFlags(registryDedupeCmd.Flags(), true)
+	registryDedupeCmd.InitDefaultHelpFlag()
+	return registryDedupeCmd
+}
+
+// dedupeRegistrySecrets keeps the newest one of the duplicate secrets in a namespace, the service accounts
+// referencing the duplicates are updated to reference the kept one before the duplicates are deleted
+func dedupeRegistrySecrets(cmd *cobra.Command, client kubernetes.Interface, secrets []corev1.Secret) error {
+	sortNewestFirst(secrets)
+	kept := secrets[0]
+	namespace := kept.Namespace
+	duplicates := sets.New[string]()
+	for _, secret := range secrets[1:] {
+		duplicates.Insert(secret.Name)
+	}
+
+	serviceAccounts, err := client.CoreV1().ServiceAccounts(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list serviceaccounts in namespace '%s': %v", namespace, err)
+	}
+	for _, sa := range serviceAccounts.Items {
+		desiredSa := sa.DeepCopy()
+		desiredSa.ImagePullSecrets = []corev1.LocalObjectReference{}
+		changed := false
+		for _, ips := range sa.ImagePullSecrets {
+			if !duplicates.Has(ips.Name) {
+				desiredSa.ImagePullSecrets = append(desiredSa.ImagePullSecrets, ips)
+				continue
+			}
+			changed = true
+			// replace the first duplicate with the kept secret to keep the order of ImagePullSecrets
+			if !hasImagePullSecret(desiredSa, kept.Name) && !hasImagePullSecret(&sa, kept.Name) {
+				desiredSa.ImagePullSecrets = append(desiredSa.ImagePullSecrets, corev1.LocalObjectReference{Name: kept.Name})
+			}
+		}
+		if !changed {
+			continue
+		}
+		_, err = client.CoreV1().ServiceAccounts(namespace).Update(context.TODO(), desiredSa, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to update serviceaccount '%s' in namespace '%s': %v", sa.Name, namespace, err)
+		}
+		cmd.Printf("ImagePullSecrets of serviceaccount '%s' in namespace '%s' is updated\n", sa.Name, namespace)
+	}
+
+	errs := []error{}
+	for _, name := range sets.List(duplicates) {
+		err = client.CoreV1().Secrets(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete secret '%s' in namespace '%s': %v", name, namespace, err))
+			continue
+		}
+		cmd.Printf("Secret '%s' in namespace '%s' is merged into '%s'\n", name, namespace, kept.Name)
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+// newDuplicateTestSecret returns a registry secret for the default serviceaccount created at the given time
+func newDuplicateTestSecret(t *testing.T, name, namespace, username string, created time.Time) *corev1.Secret {
+	secret := newRegistryTestSecret(t, name, namespace, username, "docker.io")
+	secret.Labels[ImagePullServiceAccount] = "default"
+	secret.CreationTimestamp = metav1.NewTime(created)
+	return secret
+}
+
+func TestNewRegistryDedupeCommand(t *testing.T) {
+	t.Run("kubectl context is not set", func(t *testing.T) {
+		p := testutil.NewTestAdminWithoutKubeConfig()
+		cmd := NewRegistryDedupeCommand(p)
+		_, err := testutil.ExecuteCommand(cmd)
+		assert.Error(t, err, testutil.ErrNoKubeConfiguration)
+	})
+
+	t.Run("no duplicate registry", func(t *testing.T) {
+		now := time.Now()
+		p, client := testutil.NewTestAdminParams(
+			newDuplicateTestSecret(t, "secret-1", "default", "user", now),
+			newDuplicateTestSecret(t, "secret-2", "default", "another-user", now),
+		)
+		assert.Check(t, client != nil)
+		o, err := testutil.ExecuteCommand(NewRegistryDedupeCommand(p))
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "No duplicate registry found"), "unexpected output: %s", o)
+	})
+
+	t.Run("merge duplicate registries", func(t *testing.T) {
+		now := time.Now()
+		sa := &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default"},
+			ImagePullSecrets: []corev1.LocalObjectReference{
+				{Name: "other-secret"}, {Name: "secret-1"}, {Name: "secret-2"}, {Name: "secret-3"},
+			},
+		}
+		builder := &corev1.ServiceAccount{
+			ObjectMeta:       metav1.ObjectMeta{Name: "builder", Namespace: "default"},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "secret-2"}},
+		}
+		p, client := testutil.NewTestAdminParams(sa, builder,
+			newDuplicateTestSecret(t, "secret-1", "default", "user", now.Add(-2*time.Hour)),
+			newDuplicateTestSecret(t, "secret-2", "default", "user", now.Add(-time.Hour)),
+			newDuplicateTestSecret(t, "secret-3", "default", "user", now),
+			newDuplicateTestSecret(t, "secret-4", "other", "user", now),
+		)
+
+		o, err := testutil.ExecuteCommand(NewRegistryDedupeCommand(p))
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "Secret 'secret-1' in namespace 'default' is merged into 'secret-3'"), "unexpected output: %s", o)
+		assert.Check(t, strings.Contains(o, "Secret 'secret-2' in namespace 'default' is merged into 'secret-3'"), "unexpected output: %s", o)
+
+		secrets, err := client.CoreV1().Secrets("").List(context.TODO(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, 2, len(secrets.Items), "got secrets: %#v", secrets)
+
+		saUpdated, err := client.CoreV1().ServiceAccounts("default").Get(context.TODO(), "default", metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []corev1.LocalObjectReference{{Name: "other-secret"}, {Name: "secret-3"}}, saUpdated.ImagePullSecrets)
+
+		builderUpdated, err := client.CoreV1().ServiceAccounts("default").Get(context.TODO(), "builder", metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []corev1.LocalObjectReference{{Name: "secret-3"}}, builderUpdated.ImagePullSecrets)
+	})
+
+	t.Run("merge duplicate registries in all namespaces", func(t *testing.T) {
+		now := time.Now()
+		p, client := testutil.NewTestAdminParams(
+			newDuplicateTestSecret(t, "secret-1", "ns1", "user", now.Add(-time.Hour)),
+			newDuplicateTestSecret(t, "secret-2", "ns1", "user", now),
+			newDuplicateTestSecret(t, "secret-3", "ns2", "user", now.Add(-time.Hour)),
+			newDuplicateTestSecret(t, "secret-4", "ns2", "user", now),
+		)
+
+		_, err := testutil.ExecuteCommand(NewRegistryDedupeCommand(p), "--all-namespaces")
+		assert.NilError(t, err)
+
+		secrets, err := client.CoreV1().Secrets("").List(context.TODO(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		names := []string{}
+		for _, secret := range secrets.Items {
+			names = append(names, secret.Name)
+		}
+		assert.DeepEqual(t, []string{"secret-2", "secret-4"}, names)
+	})
+}
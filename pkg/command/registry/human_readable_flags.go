@@ -15,30 +15,86 @@
 package registry
 
 import (
-	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1beta1 "k8s.io/apimachinery/pkg/apis/meta/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	hprinters "knative.dev/client/pkg/printers"
+	"knative.dev/kn-plugin-admin/pkg"
 )
 
+// registryColumnDefinitions are the columns shared by registry list handlers
+var registryColumnDefinitions = []metav1beta1.TableColumnDefinition{
+	{Name: "Namespace", Type: "string", Description: "Namespace of the Knative service.", Priority: 0},
+	{Name: "ServiceAccount", Type: "string", Description: "The ServiceAccount to save ImagePullSecrets.", Priority: 1},
+	{Name: "Secret", Type: "string", Description: "The Secret to save registry.", Priority: 1},
+	{Name: "UserName", Type: "string", Description: "The username of the registry.", Priority: 1},
+	{Name: "Server", Type: "string", Description: "The server url of the registry.", Priority: 1},
+	{Name: "Email", Type: "string", Description: "The email of the registry user.", Priority: 1},
+}
+
 // RegistryListHandlers adds print handlers for registry list command
 func RegistryListHandlers(h hprinters.PrintHandler) {
-	registryColumnDefinitions := []metav1beta1.TableColumnDefinition{
-		{Name: "Namespace", Type: "string", Description: "Namespace of the Knative service.", Priority: 0},
-		{Name: "ServiceAccount", Type: "string", Description: "The ServiceAccount to save ImagePullSecrets.", Priority: 1},
-		{Name: "Secret", Type: "string", Description: "The Secret to save registry.", Priority: 1},
-		{Name: "UserName", Type: "string", Description: "The username of the registry.", Priority: 1},
-		{Name: "Server", Type: "string", Description: "The server url of the registry.", Priority: 1},
-		{Name: "Email", Type: "string", Description: "The email of the registry user.", Priority: 1},
-	}
-
 	h.TableHandler(registryColumnDefinitions, printRegistry)
 	h.TableHandler(registryColumnDefinitions, printRegistryList)
 }
 
+// RegistryListAllHandlers returns print handlers for registry list command with '--all' flag,
+// references contains the ServiceAccounts referencing each secret indexed by 'namespace/name'
+func RegistryListAllHandlers(references map[string][]string) func(h hprinters.PrintHandler) {
+	return RegistryListUsageHandlers(nil, references)
+}
+
+// RegistryListUsageHandlers returns print handlers for registry list command with '--show-usage' flag,
+// services contains the Knative Services depending on each secret indexed by 'namespace/name', the column
+// of Services is shown if services is not nil, and the column of Managed is shown if references is not nil
+func RegistryListUsageHandlers(services map[string][]string, references map[string][]string) func(h hprinters.PrintHandler) {
+	return func(h hprinters.PrintHandler) {
+		columnDefinitions := append([]metav1beta1.TableColumnDefinition{}, registryColumnDefinitions...)
+		if references != nil {
+			columnDefinitions = append(columnDefinitions,
+				metav1beta1.TableColumnDefinition{Name: "Managed", Type: "string", Description: "Whether the Secret is managed by kn admin.", Priority: 1},
+			)
+		}
+		if services != nil {
+			columnDefinitions = append(columnDefinitions,
+				metav1beta1.TableColumnDefinition{Name: "Services", Type: "string", Description: "The Knative Services pulling images with the Secret.", Priority: 1},
+			)
+		}
+		printSecret := func(secret *corev1.Secret, options hprinters.PrintOptions) ([]metav1beta1.TableRow, error) {
+			key := secret.Namespace + "/" + secret.Name
+			sa := secret.Labels[ImagePullServiceAccount]
+			extraCells := []interface{}{}
+			if references != nil {
+				managed := secret.Labels[pkg.LabelManagedBy] == AdminRegistryCmdName
+				sa = strings.Join(references[key], ",")
+				extraCells = append(extraCells, strconv.FormatBool(managed))
+			}
+			if services != nil {
+				extraCells = append(extraCells, strings.Join(services[key], ","))
+			}
+			return registryRows(secret, options, sa, extraCells...)
+		}
+		h.TableHandler(columnDefinitions, printSecret)
+		h.TableHandler(columnDefinitions, func(secretList *corev1.SecretList, options hprinters.PrintOptions) ([]metav1beta1.TableRow, error) {
+			rows := make([]metav1beta1.TableRow, 0, len(secretList.Items))
+			for _, secret := range secretList.Items {
+				r, err := printSecret(&secret, options)
+				if err != nil {
+					return nil, err
+				}
+				rows = append(rows, r...)
+			}
+			return rows, nil
+		})
+	}
+}
+
 // Private functions
 
 // printRegistryList populates the registry list table rows
@@ -57,30 +113,41 @@ func printRegistryList(secretList *corev1.SecretList, options hprinters.PrintOpt
 
 // printRegistry populates the registry table rows
 func printRegistry(secret *corev1.Secret, options hprinters.PrintOptions) ([]metav1beta1.TableRow, error) {
-	row := metav1beta1.TableRow{
-		Object: runtime.RawExtension{Object: secret},
-	}
+	return registryRows(secret, options, secret.Labels[ImagePullServiceAccount])
+}
 
-	if options.AllNamespaces {
-		row.Cells = append(row.Cells, secret.Namespace)
+// registryRows populates a table row for each registry server in the secret, a secret without registry server
+// is shown in a row of '<none>', and a secret which can't be parsed is shown in a row of the error
+func registryRows(secret *corev1.Secret, options hprinters.PrintOptions, sa string, extraCells ...interface{}) ([]metav1beta1.TableRow, error) {
+	newRow := func(username, server, email string) metav1beta1.TableRow {
+		row := metav1beta1.TableRow{
+			Object: runtime.RawExtension{Object: secret},
+		}
+		if options.AllNamespaces {
+			row.Cells = append(row.Cells, secret.Namespace)
+		}
+		row.Cells = append(row.Cells, sa, secret.Name, username, server, email)
+		row.Cells = append(row.Cells, extraCells...)
+		return row
 	}
 
-	sa := secret.Labels[ImagePullServiceAccount]
-	name := secret.Name
-	registry := Registry{}
-	err := json.Unmarshal(secret.Data[DockerJSONName], &registry)
+	auths, err := parseAuths(secret)
 	if err != nil {
-		return []metav1beta1.TableRow{row}, err
+		return []metav1beta1.TableRow{newRow("<unknown>", fmt.Sprintf("<error: %v>", err), "<unknown>")}, nil
+	}
+	if len(auths) == 0 {
+		return []metav1beta1.TableRow{newRow("<none>", "<none>", "<none>")}, nil
 	}
-	for secretServer, secretAuth := range registry.Auths {
-		row.Cells = append(row.Cells,
-			sa,
-			name,
-			secretAuth.Username,
-			secretServer,
-			secretAuth.Email,
-		)
+
+	servers := make([]string, 0, len(auths))
+	for server := range auths {
+		servers = append(servers, server)
 	}
+	sort.Strings(servers)
 
-	return []metav1beta1.TableRow{row}, nil
+	rows := make([]metav1beta1.TableRow, 0, len(servers))
+	for _, server := range servers {
+		rows = append(rows, newRow(auths[server].Username, server, auths[server].Email))
+	}
+	return rows, nil
 }
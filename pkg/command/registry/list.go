@@ -17,23 +17,33 @@ package registry
 import (
 	"context"
 	"fmt"
+	"io"
 	"sort"
 
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 
 	"knative.dev/client/pkg/commands"
 	"knative.dev/client/pkg/commands/flags"
 	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/command/utils"
 )
 
 // NewRegistryListCommand represents the list command
 func NewRegistryListCommand(p *pkg.AdminParams) *cobra.Command {
 	registryListFlags := flags.NewListPrintFlags(RegistryListHandlers)
-	var serviceaccount string
+	watchFlags := &utils.WatchFlags{}
+	var (
+		serviceaccount string
+		all            bool
+		showUsage      bool
+	)
 	var registryListCmd = &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
@@ -43,7 +53,23 @@ func NewRegistryListCommand(p *pkg.AdminParams) *cobra.Command {
   # To list registry settings
   kn admin registry list \
     --namespace=[NAMESPACE] \
-    --serviceaccount=[SERVICE_ACCOUNT]`,
+    --serviceaccount=[SERVICE_ACCOUNT]
+
+  # To list all image pull secrets including the ones not managed by kn admin
+  kn admin registry list --all
+
+  # To list registry settings with the Knative Services depending on them
+  kn admin registry list --show-usage
+
+  # To watch the registry settings and emit the changes as JSON events, the secret data is redacted
+  kn admin registry list --watch --watch-output json`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// the service accounts referencing the secrets are not watched, so --all can't be re-rendered on their changes
+			if watchFlags.Watch && all {
+				return fmt.Errorf("--watch can not be used with --all")
+			}
+			return watchFlags.Validate()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// retrieve namespaces
 			namespace := cmd.Flag("namespace").Value.String()
@@ -56,41 +82,108 @@ func NewRegistryListCommand(p *pkg.AdminParams) *cobra.Command {
 				return err
 			}
 
-			namespacesList, err := searchNamespace(client, namespace)
-			if err != nil {
-				return fmt.Errorf("failed to search specified namespaces: %v", err)
-			}
+			list := func(out io.Writer) (string, error) {
+				namespacesList, err := searchNamespace(client, namespace)
+				if err != nil {
+					return "", fmt.Errorf("failed to search specified namespaces: %v", err)
+				}
 
-			secretList := &corev1.SecretList{}
-			for _, ns := range namespacesList.Items {
-				err = addSecrets(client, ns.Name, serviceaccount, secretList)
-			}
+				secretList := &corev1.SecretList{}
+				var references map[string][]string
+				if all {
+					references = make(map[string][]string)
+					for _, ns := range namespacesList.Items {
+						if err = addAllSecrets(client, ns.Name, serviceaccount, secretList, references); err != nil {
+							return "", fmt.Errorf("failed to list secrets in namespace '%s': %v", ns.Name, err)
+						}
+					}
+					registryListFlags.PrinterHandler = RegistryListAllHandlers(references)
+				} else {
+					for _, ns := range namespacesList.Items {
+						if err = addSecrets(client, ns.Name, serviceaccount, secretList); err != nil {
+							return "", fmt.Errorf("failed to list secrets in namespace '%s': %v", ns.Name, err)
+						}
+					}
+				}
 
-			// empty namespace indicates all-namespaces flag is specified
-			if namespace == "" {
-				registryListFlags.EnsureWithNamespace()
-			}
+				missing := []string{}
+				if showUsage {
+					servingClient, err := p.NewServingClient()
+					if err != nil {
+						return "", err
+					}
+					services := make(map[string][]string)
+					for _, ns := range namespacesList.Items {
+						usage, err := resolveRegistryUsage(client, servingClient, ns.Name)
+						if err != nil {
+							return "", err
+						}
+						for secret, s := range usage.services {
+							services[ns.Name+"/"+secret] = s
+						}
+						missing = append(missing, usage.missing...)
+					}
+					registryListFlags.PrinterHandler = RegistryListUsageHandlers(services, references)
+				}
+
+				// empty namespace indicates all-namespaces flag is specified
+				if namespace == "" {
+					registryListFlags.EnsureWithNamespace()
+				}
 
-			// Sort secretList by namespace and name (in this order)
-			sort.SliceStable(secretList.Items, func(i, j int) bool {
-				a := secretList.Items[i]
-				b := secretList.Items[j]
+				// Sort secretList by namespace and name (in this order)
+				sort.SliceStable(secretList.Items, func(i, j int) bool {
+					a := secretList.Items[i]
+					b := secretList.Items[j]
 
-				if a.Namespace != b.Namespace {
-					return a.Namespace < b.Namespace
+					if a.Namespace != b.Namespace {
+						return a.Namespace < b.Namespace
+					}
+					return a.ObjectMeta.Name < b.ObjectMeta.Name
+				})
+
+				if err = registryListFlags.Print(secretList, out); err != nil {
+					return "", err
+				}
+				for _, m := range missing {
+					cmd.PrintErrf("Warning: %s\n", m)
 				}
-				return a.ObjectMeta.Name < b.ObjectMeta.Name
-			})
+				return secretList.ResourceVersion, nil
+			}
+			if !watchFlags.Watch {
+				_, err = list(cmd.OutOrStdout())
+				return err
+			}
 
-			return registryListFlags.Print(secretList, cmd.OutOrStdout())
+			// the secrets of all namespaces are watched if the namespace is not specified
+			selectorLabels := labels.Set{}
+			for k, v := range AdminRegistryLabels {
+				selectorLabels[k] = v
+			}
+			if serviceaccount != "" {
+				selectorLabels[ImagePullServiceAccount] = serviceaccount
+			}
+			selector := labels.SelectorFromSet(selectorLabels).String()
+			lw := utils.ListWatch{
+				Kind: "Secret",
+				List: list,
+				Watch: func(ctx context.Context, resourceVersion string) (watch.Interface, error) {
+					return client.CoreV1().Secrets(namespace).Watch(ctx, metav1.ListOptions{LabelSelector: selector, ResourceVersion: resourceVersion})
+				},
+				Redact: redactSecret,
+			}
+			return lw.Run(cmd.Context(), cmd.OutOrStdout(), watchFlags.Output)
 		},
 	}
 	commands.AddNamespaceFlags(registryListCmd.Flags(), false)
 	registryListFlags.HumanReadableFlags.AddFlags(registryListCmd)
+	watchFlags.AddFlags(registryListCmd)
 	registryListFlags.GenericPrintFlags.OutputFlagSpecified = func() bool {
 		return false
 	}
 	registryListCmd.Flags().StringVar(&serviceaccount, "serviceaccount", "", "the service account to save imagePullSecrets")
+	registryListCmd.Flags().BoolVar(&all, "all", false, "list all image pull secrets and the service accounts referencing them, including the ones not managed by kn admin")
+	registryListCmd.Flags().BoolVar(&showUsage, "show-usage", false, "show the Knative Services pulling images with each secret and warn about images from registries without credentials")
 	registryListCmd.InitDefaultHelpFlag()
 	return registryListCmd
 }
@@ -120,6 +213,7 @@ func addSecrets(kubeclient kubernetes.Interface, ns string, sa string, secretLis
 	if err != nil {
 		return err
 	}
+	secretList.ResourceVersion = secrets.ResourceVersion
 	for _, secret := range secrets.Items {
 		if sa == "" || (sa == secret.Labels[ImagePullServiceAccount]) {
 			secretList.Items = append(secretList.Items, secret)
@@ -127,3 +221,42 @@ func addSecrets(kubeclient kubernetes.Interface, ns string, sa string, secretLis
 	}
 	return nil
 }
+
+// addAllSecrets adds all image pull secrets in the namespace and records the service accounts referencing them,
+// if sa is specified, only the secrets referenced by the service account are added
+func addAllSecrets(kubeclient kubernetes.Interface, ns string, sa string, secretList *corev1.SecretList, references map[string][]string) error {
+	secrets, err := kubeclient.CoreV1().Secrets(ns).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	serviceAccounts, err := kubeclient.CoreV1().ServiceAccounts(ns).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	secretList.ResourceVersion = secrets.ResourceVersion
+	for _, serviceAccount := range serviceAccounts.Items {
+		for _, ips := range serviceAccount.ImagePullSecrets {
+			key := ns + "/" + ips.Name
+			references[key] = append(references[key], serviceAccount.Name)
+		}
+	}
+	for _, secret := range secrets.Items {
+		if !isImagePullSecret(&secret) {
+			continue
+		}
+		if sa == "" || sets.New(references[ns+"/"+secret.Name]...).Has(sa) {
+			secretList.Items = append(secretList.Items, secret)
+		}
+	}
+	return nil
+}
+
+// redactSecret removes the data of the secret from the JSON events of list --watch
+func redactSecret(obj runtime.Object) runtime.Object {
+	if secret, ok := obj.(*corev1.Secret); ok {
+		secret.Data = nil
+		secret.StringData = nil
+		delete(secret.Annotations, corev1.LastAppliedConfigAnnotation)
+	}
+	return obj
+}
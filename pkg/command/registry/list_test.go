@@ -22,8 +22,11 @@ import (
 	"gotest.tools/v3/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	servingv1client "knative.dev/serving/pkg/client/clientset/versioned/typed/serving/v1"
 
 	"knative.dev/client/pkg/util"
 	"knative.dev/kn-plugin-admin/pkg"
@@ -143,6 +146,120 @@ func TestNewRegistryListCommand(t *testing.T) {
 		assert.Check(t, util.ContainsAll(outputRows[2], defaultNamespace, defaultServiceAccount, fakeSecretName4, fakeUsername4, fakeServer4, fakeEmail4))
 	})
 
+	t.Run("list all image pull secrets including unmanaged ones", func(t *testing.T) {
+		unmanaged := corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "unmanaged-secret",
+				Namespace: fakeNamespace,
+			},
+			Type: corev1.SecretTypeDockercfg,
+			Data: map[string][]byte{
+				DockerCfgName: []byte(`{"quay.io":{"username":"quay-user","email":"quay-email"}}`),
+			},
+		}
+		opaque := corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "opaque-secret",
+				Namespace: fakeNamespace,
+			},
+			Type: corev1.SecretTypeOpaque,
+		}
+		sa1 := createMockServiceAccountWithParams(fakeServiceAccount, fakeNamespace, []corev1.LocalObjectReference{{Name: "unmanaged-secret"}})
+		sa2 := createMockServiceAccountWithParams(defaultServiceAccount, fakeNamespace, []corev1.LocalObjectReference{{Name: "unmanaged-secret"}})
+		fakeNS := corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fakeNamespace,
+			},
+		}
+		p, client := testutil.NewTestAdminParams(&fakeNS, &sa1, &sa2, &unmanaged, &opaque)
+		assert.Check(t, client != nil)
+
+		cmd := NewRegistryListCommand(p)
+		output, err := testutil.ExecuteCommand(cmd, "--namespace", fakeNamespace, "--all")
+		assert.NilError(t, err)
+		outputRows := strings.Split(output, "\n")
+
+		assert.Equal(t, len(outputRows), 3)
+		assert.Check(t, util.ContainsAll(outputRows[0], "SERVICEACCOUNT", "SECRET", "USERNAME", "SERVER", "EMAIL", "MANAGED"))
+		assert.Check(t, util.ContainsAll(outputRows[1], defaultServiceAccount+","+fakeServiceAccount, "unmanaged-secret", "quay-user", "quay.io", "quay-email", "false"))
+
+		cmd = NewRegistryListCommand(p)
+		output, err = testutil.ExecuteCommand(cmd, "--namespace", fakeNamespace, "--serviceaccount", "other", "--all")
+		assert.NilError(t, err)
+		assert.Equal(t, len(strings.Split(output, "\n")), 2)
+	})
+
+	t.Run("list image pull secrets without registry or with invalid data", func(t *testing.T) {
+		empty := createMockSecretWithParams("empty-secret", fakeNamespace, fakeServiceAccount, "", "", "")
+		empty.Type = corev1.SecretTypeDockerConfigJson
+		empty.Data[DockerJSONName] = []byte(`{"auths":{}}`)
+		invalid := corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "invalid-secret",
+				Namespace: fakeNamespace,
+			},
+			Type: corev1.SecretTypeDockercfg,
+			Data: map[string][]byte{
+				DockerCfgName: []byte(`not-json`),
+			},
+		}
+		sa := createMockServiceAccountWithParams(fakeServiceAccount, fakeNamespace, []corev1.LocalObjectReference{{Name: "empty-secret"}, {Name: "invalid-secret"}})
+		fakeNS := corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fakeNamespace,
+			},
+		}
+		p, _ := testutil.NewTestAdminParams(&fakeNS, &sa, &empty, &invalid)
+
+		output, err := testutil.ExecuteCommand(NewRegistryListCommand(p), "--namespace", fakeNamespace, "--all")
+		assert.NilError(t, err)
+		outputRows := strings.Split(strings.TrimSpace(output), "\n")
+		assert.Equal(t, len(outputRows), 3, "unexpected output: %s", output)
+		assert.Check(t, util.ContainsAll(outputRows[1], fakeServiceAccount, "empty-secret", "<none>", "true"), "unexpected output: %s", output)
+		assert.Check(t, util.ContainsAll(outputRows[2], fakeServiceAccount, "invalid-secret", "<unknown>", "<error: failed unmarshal secret data '.dockercfg'", "false"), "unexpected output: %s", output)
+
+		output, err = testutil.ExecuteCommand(NewRegistryListCommand(p), "--namespace", fakeNamespace)
+		assert.NilError(t, err)
+		assert.Check(t, util.ContainsAll(output, "empty-secret", "<none>"), "unexpected output: %s", output)
+	})
+
+	t.Run("list registries with the services depending on them", func(t *testing.T) {
+		quaySecret := newRegistryTestSecret(t, "quay-secret", fakeNamespace, "quay-user", "quay.io")
+		quaySecret.Labels[ImagePullServiceAccount] = defaultServiceAccount
+		sa := createMockServiceAccountWithParams(defaultServiceAccount, fakeNamespace, []corev1.LocalObjectReference{{Name: "quay-secret"}})
+		fakeNS := corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fakeNamespace,
+			},
+		}
+		p, client := testutil.NewTestAdminParams(&fakeNS, &sa, quaySecret)
+		assert.Check(t, client != nil)
+		servingClient := testutil.NewTestServingClient(
+			newTestService("hello", fakeNamespace, "", "quay.io/team/hello", ""),
+			newTestService("world", fakeNamespace, "", "quay.io/team/world", ""),
+			newTestService("public", fakeNamespace, "", "gcr.io/team/public", ""),
+		)
+		p.NewServingClient = func() (servingv1client.ServingV1Interface, error) {
+			return servingClient, nil
+		}
+
+		cmd := NewRegistryListCommand(p)
+		output, err := testutil.ExecuteCommand(cmd, "--namespace", fakeNamespace, "--show-usage")
+		assert.NilError(t, err)
+		outputRows := strings.Split(output, "\n")
+
+		assert.Equal(t, len(outputRows), 4)
+		assert.Check(t, util.ContainsAll(outputRows[0], "SERVICEACCOUNT", "SECRET", "USERNAME", "SERVER", "EMAIL", "SERVICES"))
+		assert.Check(t, util.ContainsAll(outputRows[1], defaultServiceAccount, "quay-secret", "quay-user", "quay.io", "hello,world"))
+		assert.Check(t, util.ContainsAll(outputRows[2], "Warning: Service 'public'", "'gcr.io' without credentials"))
+
+		cmd = NewRegistryListCommand(p)
+		output, err = testutil.ExecuteCommand(cmd, "--namespace", fakeNamespace, "--show-usage", "--all")
+		assert.NilError(t, err)
+		outputRows = strings.Split(output, "\n")
+		assert.Check(t, util.ContainsAll(outputRows[0], "MANAGED", "SERVICES"))
+		assert.Check(t, util.ContainsAll(outputRows[1], "quay-secret", "true", "hello,world"))
+	})
 }
 
 func fakeRegistry() kubernetes.Interface {
@@ -243,3 +360,40 @@ func createMockSecretWithParams(name, namespace, serviceaccount, username, serve
 	}
 	return secret
 }
+
+func TestRegistryListWatch(t *testing.T) {
+	client := fakeRegistry().(*k8sfake.Clientset)
+	p := &pkg.AdminParams{
+		NewKubeClient: func() (kubernetes.Interface, error) {
+			return client, nil
+		},
+	}
+	output, err := testutil.ExecuteWatchCommand(NewRegistryListCommand(p), &client.Fake, "secrets", func(w *watch.FakeWatcher) {
+		secret := createMockSecretWithParams(fakeSecretName1, fakeNamespace, fakeServiceAccount, fakeUsername1, fakeServer1, fakeEmail1)
+		w.Modify(&secret)
+	}, "--namespace", fakeNamespace, "--watch", "--watch-output", "json")
+	assert.NilError(t, err)
+	assert.Check(t, util.ContainsAll(output, `"type":"MODIFIED"`, `"kind":"Secret"`, fakeSecretName1), "unexpected output: %s", output)
+	assert.Check(t, !strings.Contains(output, `"data"`), "secret data should be redacted: %s", output)
+	assert.Check(t, !strings.Contains(output, fakeUsername1), "secret data should be redacted: %s", output)
+
+	t.Run("watch the secrets of the service account", func(t *testing.T) {
+		client.ClearActions()
+		_, err := testutil.ExecuteWatchCommand(NewRegistryListCommand(p), &client.Fake, "secrets", func(w *watch.FakeWatcher) {},
+			"--namespace", fakeNamespace, "--serviceaccount", fakeServiceAccount, "--watch", "--watch-output", "json")
+		assert.NilError(t, err)
+		selectors := []string{}
+		for _, action := range client.Actions() {
+			if watchAction, ok := action.(k8stesting.WatchAction); ok {
+				selectors = append(selectors, watchAction.GetWatchRestrictions().Labels.String())
+			}
+		}
+		assert.Equal(t, 1, len(selectors))
+		assert.Check(t, util.ContainsAll(selectors[0], ImagePullServiceAccount+"="+fakeServiceAccount, pkg.LabelManagedBy+"="+AdminRegistryCmdName), "unexpected selector: %s", selectors[0])
+	})
+
+	t.Run("watch can not be used with all", func(t *testing.T) {
+		_, err := testutil.ExecuteCommand(NewRegistryListCommand(p), "--namespace", fakeNamespace, "--all", "--watch")
+		assert.ErrorContains(t, err, "--watch can not be used with --all")
+	})
+}
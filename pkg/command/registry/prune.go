@@ -0,0 +1,171 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"knative.dev/client/pkg/commands"
+	"knative.dev/kn-plugin-admin/pkg"
+)
+
+// NewRegistryPruneCommand represents the prune command
+func NewRegistryPruneCommand(p *pkg.AdminParams) *cobra.Command {
+	var dryRun, force bool
+
+	var registryPruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Delete registry secrets not referenced by any service account or Knative Service",
+		Long: `Delete the registry secrets managed by kn admin which are not referenced in the ImagePullSecrets of any service account,
+nor in the ImagePullSecrets of any Knative Service or Revision, e.g: the secrets left after partial failures of 'registry add'
+or 'registry remove', or after the service account is deleted.
+The secrets to delete are listed and confirmed before deleting them, unless --force is specified`,
+		Example: `
+  # To list the registry secrets to delete in namespace 'default' without deleting them
+  kn admin registry prune --dry-run
+
+  # To delete the registry secrets not referenced in namespace 'default' after confirmation
+  kn admin registry prune
+
+  # To delete the registry secrets not referenced in all namespaces without confirmation
+  kn admin registry prune --all-namespaces --force`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace := cmd.Flag("namespace").Value.String()
+			if namespace == "" {
+				namespace = "default"
+			}
+			if allNamespaces, _ := cmd.Flags().GetBool("all-namespaces"); allNamespaces {
+				namespace = ""
+			}
+
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+			servingClient, err := p.NewServingClient()
+			if err != nil {
+				return err
+			}
+
+			// get all credential secrets which have the label managed-by=kn-admin-registry
+			secrets, err := client.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{
+				LabelSelector: labels.SelectorFromSet(AdminRegistryLabels).String(),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to list secret: %v", err)
+			}
+			serviceAccounts, err := client.CoreV1().ServiceAccounts(namespace).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to list serviceaccounts: %v", err)
+			}
+
+			referenced, err := servingImagePullSecrets(servingClient, namespace)
+			if err != nil {
+				return err
+			}
+			for _, sa := range serviceAccounts.Items {
+				for _, ips := range sa.ImagePullSecrets {
+					referenced.Insert(sa.Namespace + "/" + ips.Name)
+				}
+			}
+
+			orphans := []corev1.Secret{}
+			for _, secret := range secrets.Items {
+				if !referenced.Has(secret.Namespace + "/" + secret.Name) {
+					orphans = append(orphans, secret)
+				}
+			}
+			if len(orphans) == 0 {
+				cmd.Println("No registry secret found to prune")
+				return nil
+			}
+			sort.Slice(orphans, func(i, j int) bool {
+				if orphans[i].Namespace != orphans[j].Namespace {
+					return orphans[i].Namespace < orphans[j].Namespace
+				}
+				return orphans[i].Name < orphans[j].Name
+			})
+
+			cmd.Println("Secrets not referenced by any serviceaccount or Knative Service:")
+			for _, secret := range orphans {
+				cmd.Printf("  %s/%s\n", secret.Namespace, secret.Name)
+			}
+			if dryRun {
+				cmd.Printf("%d secret(s) would be deleted (dry run)\n", len(orphans))
+				return nil
+			}
+			if !force {
+				confirmed, err := confirmPrune(cmd, len(orphans))
+				if err != nil {
+					return err
+				}
+				if !confirmed {
+					cmd.Println("Prune is canceled, no secret is deleted")
+					return nil
+				}
+			}
+
+			errs := []error{}
+			for _, secret := range orphans {
+				err = client.CoreV1().Secrets(secret.Namespace).Delete(context.TODO(), secret.Name, metav1.DeleteOptions{})
+				if apierrors.IsNotFound(err) {
+					cmd.Printf("Secret '%s' in namespace '%s' is not found, skipped\n", secret.Name, secret.Namespace)
+					continue
+				}
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to delete secret '%s' in namespace '%s': %v", secret.Name, secret.Namespace, err))
+					continue
+				}
+				cmd.Printf("Secret '%s' in namespace '%s' is deleted\n", secret.Name, secret.Namespace)
+			}
+			if len(errs) > 0 {
+				return fmt.Errorf("failed to prune secrets: %v", utilerrors.NewAggregate(errs))
+			}
+			return nil
+		},
+	}
+
+	commands.AddNamespaceFlags(registryPruneCmd.Flags(), true)
+	registryPruneCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only list the secrets to delete without deleting them")
+	registryPruneCmd.Flags().BoolVar(&force, "force", false, "delete the secrets without confirmation")
+	registryPruneCmd.InitDefaultHelpFlag()
+	return registryPruneCmd
+}
+
+// confirmPrune asks whether to delete the listed secrets, only 'y' or 'yes' confirms the deletion
+func confirmPrune(cmd *cobra.Command, count int) (bool, error) {
+	cmd.Printf("Delete %d secret(s)? [y/N]: ", count)
+	answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err == io.EOF {
+		// the input isn't terminated by a newline, e.g: it's not a terminal
+		cmd.Println()
+	} else if err != nil {
+		return false, fmt.Errorf("failed to read the confirmation: %v", err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
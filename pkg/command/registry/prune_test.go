@@ -0,0 +1,125 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	servingv1client "knative.dev/serving/pkg/client/clientset/versioned/typed/serving/v1"
+
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+func TestNewRegistryPruneCommand(t *testing.T) {
+	t.Run("kubectl context is not set", func(t *testing.T) {
+		p := testutil.NewTestAdminWithoutKubeConfig()
+		cmd := NewRegistryPruneCommand(p)
+		_, err := testutil.ExecuteCommand(cmd)
+		assert.Error(t, err, testutil.ErrNoKubeConfiguration)
+	})
+
+	t.Run("no secret to prune", func(t *testing.T) {
+		secret := newRegistryTestSecret(t, "test-secret", "default", "user", "docker.io")
+		sa := createMockServiceAccountWithParams("default", "default", []corev1.LocalObjectReference{{Name: "test-secret"}})
+		p, client := testutil.NewTestAdminParams(&sa, secret)
+		assert.Check(t, client != nil)
+		o, err := testutil.ExecuteCommand(NewRegistryPruneCommand(p))
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "No registry secret found to prune"), "unexpected output: %s", o)
+	})
+
+	t.Run("prune secrets not referenced by any serviceaccount", func(t *testing.T) {
+		referenced := newRegistryTestSecret(t, "referenced", "ns1", "user", "docker.io")
+		// a secret with the same name in another namespace is not referenced
+		orphan1 := newRegistryTestSecret(t, "referenced", "ns2", "user", "docker.io")
+		orphan2 := newRegistryTestSecret(t, "orphan", "ns1", "user", "docker.io")
+		unmanaged := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: "ns1"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+		}
+		sa := createMockServiceAccountWithParams("default", "ns1", []corev1.LocalObjectReference{{Name: "referenced"}})
+		p, client := testutil.NewTestAdminParams(&sa, referenced, orphan1, orphan2, unmanaged)
+
+		o, err := testutil.ExecuteCommand(NewRegistryPruneCommand(p), "--all-namespaces", "--dry-run")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "  ns1/orphan\n  ns2/referenced\n2 secret(s) would be deleted (dry run)"), "unexpected output: %s", o)
+		secrets, err := client.CoreV1().Secrets("").List(context.TODO(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, 4, len(secrets.Items))
+
+		// the current namespace is pruned by default
+		o, err = testutil.ExecuteCommand(NewRegistryPruneCommand(p))
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "No registry secret found to prune"), "unexpected output: %s", o)
+
+		// the deletion is canceled without confirmation
+		cmd := NewRegistryPruneCommand(p)
+		cmd.SetIn(strings.NewReader("n\n"))
+		o, err = testutil.ExecuteCommand(cmd, "--namespace", "ns1")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "Delete 1 secret(s)? [y/N]: Prune is canceled, no secret is deleted"), "unexpected output: %s", o)
+		cmd = NewRegistryPruneCommand(p)
+		cmd.SetIn(strings.NewReader(""))
+		o, err = testutil.ExecuteCommand(cmd, "--namespace", "ns1")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "Prune is canceled, no secret is deleted"), "unexpected output: %s", o)
+
+		cmd = NewRegistryPruneCommand(p)
+		cmd.SetIn(strings.NewReader("y\n"))
+		o, err = testutil.ExecuteCommand(cmd, "--namespace", "ns1")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "Secret 'orphan' in namespace 'ns1' is deleted"), "unexpected output: %s", o)
+		assert.Check(t, !strings.Contains(o, "ns2"), "unexpected output: %s", o)
+
+		o, err = testutil.ExecuteCommand(NewRegistryPruneCommand(p), "--all-namespaces", "--force")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "Secret 'referenced' in namespace 'ns2' is deleted"), "unexpected output: %s", o)
+
+		secrets, err = client.CoreV1().Secrets("").List(context.TODO(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		names := []string{}
+		for _, secret := range secrets.Items {
+			names = append(names, secret.Namespace+"/"+secret.Name)
+		}
+		assert.DeepEqual(t, []string{"ns1/referenced", "ns1/unmanaged"}, names)
+	})
+
+	t.Run("keep secrets referenced by Knative Services and Revisions", func(t *testing.T) {
+		byService := newRegistryTestSecret(t, "by-service", "default", "user", "docker.io")
+		byRevision := newRegistryTestSecret(t, "by-revision", "default", "user", "quay.io")
+		orphan := newRegistryTestSecret(t, "orphan", "default", "user", "gcr.io")
+		service := newTestService("hello", "default", "", "docker.io/team/hello", "")
+		addImagePullSecrets(&service.Spec.Template.Spec.PodSpec, "by-service")
+		revision := newTestRevision("hello-00001", "default", "", "quay.io/team/hello")
+		addImagePullSecrets(&revision.Spec.PodSpec, "by-revision")
+		p, client := testutil.NewTestAdminParams(byService, byRevision, orphan)
+		servingClient := testutil.NewTestServingClient(service, revision)
+		p.NewServingClient = func() (servingv1client.ServingV1Interface, error) {
+			return servingClient, nil
+		}
+
+		o, err := testutil.ExecuteCommand(NewRegistryPruneCommand(p), "--force")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "Secret 'orphan' in namespace 'default' is deleted"), "unexpected output: %s", o)
+		secrets, err := client.CoreV1().Secrets("default").List(context.TODO(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, 2, len(secrets.Items))
+	})
+}
@@ -15,7 +15,13 @@
 package registry
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	"knative.dev/kn-plugin-admin/pkg"
 )
 
@@ -24,9 +30,14 @@ var (
 	AdminRegistryCmdName = "kn-admin-registry"
 	// DockerJSONName is used to represent ".dockerconfigjson" in secret data
 	DockerJSONName = ".dockerconfigjson"
+	// DockerCfgName is used to represent ".dockercfg" in legacy secret data
+	DockerCfgName = ".dockercfg"
 	// ImagePullServiceAccount is used to store service account name which use this secret
 	// as ImagePullSecret in this secret's label
 	ImagePullServiceAccount = "image-pull-service-account"
+	// ImagePullNamespaceSelector is used to store the namespace selector in this secret's annotation
+	// when the registry is added to multiple namespaces, an empty value matches all namespaces
+	ImagePullNamespaceSelector = "image-pull-namespace-selector"
 )
 
 // AdminRegistryLabels is a set of labels which will be added to the registry resources to indicate
@@ -45,6 +56,7 @@ type registryCred struct {
 	Username string `json:"Username"`
 	Password string `json:"Password"`
 	Email    string `json:"Email"`
+	Auth     string `json:"auth,omitempty"`
 }
 
 // Auths is a map of docker credentials indexed by server url
@@ -60,5 +72,47 @@ func NewPrivateRegistryCmd(p *pkg.AdminParams) *cobra.Command {
 	privateRegistryCmd.AddCommand(NewRegistryAddCommand(p))
 	privateRegistryCmd.AddCommand(NewRegistryRmCommand(p))
 	privateRegistryCmd.AddCommand(NewRegistryListCommand(p))
+	privateRegistryCmd.AddCommand(NewRegistrySyncCommand(p))
+	privateRegistryCmd.AddCommand(NewRegistryVerifyCommand(p))
+	privateRegistryCmd.AddCommand(NewRegistryAdoptCommand(p))
+	privateRegistryCmd.AddCommand(NewRegistryTagResolutionCommand(p))
+	privateRegistryCmd.AddCommand(NewRegistryCACommand(p))
+	privateRegistryCmd.AddCommand(NewRegistryDedupeCommand(p))
+	privateRegistryCmd.AddCommand(NewRegistryPruneCommand(p))
 	return privateRegistryCmd
 }
+
+// isImagePullSecret checks if the secret is a docker config secret which can be used as image pull secret
+func isImagePullSecret(secret *corev1.Secret) bool {
+	return secret.Type == corev1.SecretTypeDockerConfigJson || secret.Type == corev1.SecretTypeDockercfg
+}
+
+// parseAuths returns the docker credentials stored in secret data '.dockerconfigjson' or '.dockercfg',
+// the username is decoded from 'auth' field if it is not set
+func parseAuths(secret *corev1.Secret) (Auths, error) {
+	auths := Auths{}
+	if data, ok := secret.Data[DockerJSONName]; ok {
+		registry := Registry{}
+		if err := json.Unmarshal(data, &registry); err != nil {
+			return nil, fmt.Errorf("failed unmarshal secret data '%s': %v", DockerJSONName, err)
+		}
+		auths = registry.Auths
+	} else if data, ok := secret.Data[DockerCfgName]; ok {
+		if err := json.Unmarshal(data, &auths); err != nil {
+			return nil, fmt.Errorf("failed unmarshal secret data '%s': %v", DockerCfgName, err)
+		}
+	}
+
+	for server, cred := range auths {
+		if cred.Username != "" || cred.Auth == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(cred.Auth)
+		if err != nil {
+			continue
+		}
+		cred.Username = strings.SplitN(string(decoded), ":", 2)[0]
+		auths[server] = cred
+	}
+	return auths, nil
+}
@@ -17,6 +17,8 @@ package registry
 import (
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
+
 	"knative.dev/kn-plugin-admin/pkg/testutil"
 
 	"gotest.tools/v3/assert"
@@ -27,7 +29,7 @@ func TestNewPrivateRegistryCmd(t *testing.T) {
 	assert.Check(t, client != nil)
 	cmd := NewPrivateRegistryCmd(p)
 	assert.Check(t, cmd.HasSubCommands(), "cmd registry should have subcommands")
-	assert.Equal(t, 3, len(cmd.Commands()), "registry command should have 3 subcommands")
+	assert.Equal(t, 10, len(cmd.Commands()), "registry command should have 10 subcommands")
 
 	_, _, err := cmd.Find([]string{"add"})
 	assert.NilError(t, err, "registry command should have add subcommand")
@@ -37,4 +39,64 @@ func TestNewPrivateRegistryCmd(t *testing.T) {
 
 	_, _, err = cmd.Find([]string{"list"})
 	assert.NilError(t, err, "registry command should have list subcommand")
+
+	_, _, err = cmd.Find([]string{"sync"})
+	assert.NilError(t, err, "registry command should have sync subcommand")
+
+	_, _, err = cmd.Find([]string{"verify"})
+	assert.NilError(t, err, "registry command should have verify subcommand")
+
+	_, _, err = cmd.Find([]string{"adopt"})
+	assert.NilError(t, err, "registry command should have adopt subcommand")
+
+	_, _, err = cmd.Find([]string{"tag-resolution"})
+	assert.NilError(t, err, "registry command should have tag-resolution subcommand")
+
+	_, _, err = cmd.Find([]string{"ca"})
+	assert.NilError(t, err, "registry command should have ca subcommand")
+
+	_, _, err = cmd.Find([]string{"dedupe"})
+	assert.NilError(t, err, "registry command should have dedupe subcommand")
+
+	_, _, err = cmd.Find([]string{"prune"})
+	assert.NilError(t, err, "registry command should have prune subcommand")
+}
+
+func TestParseAuths(t *testing.T) {
+	t.Run("docker config json secret", func(t *testing.T) {
+		secret := &corev1.Secret{
+			Type: corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{
+				DockerJSONName: []byte(`{"auths":{"docker.io":{"username":"user","password":"password","email":"email"}}}`),
+			},
+		}
+		auths, err := parseAuths(secret)
+		assert.NilError(t, err)
+		assert.Equal(t, "user", auths["docker.io"].Username)
+		assert.Equal(t, "email", auths["docker.io"].Email)
+	})
+
+	t.Run("legacy docker cfg secret with auth only", func(t *testing.T) {
+		secret := &corev1.Secret{
+			Type: corev1.SecretTypeDockercfg,
+			Data: map[string][]byte{
+				// base64 of 'user:password'
+				DockerCfgName: []byte(`{"quay.io":{"auth":"dXNlcjpwYXNzd29yZA=="}}`),
+			},
+		}
+		auths, err := parseAuths(secret)
+		assert.NilError(t, err)
+		assert.Equal(t, "user", auths["quay.io"].Username)
+	})
+
+	t.Run("invalid secret data", func(t *testing.T) {
+		secret := &corev1.Secret{
+			Type: corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{
+				DockerJSONName: []byte(`invalid`),
+			},
+		}
+		_, err := parseAuths(secret)
+		assert.ErrorContains(t, err, "failed unmarshal secret data")
+	})
 }
@@ -16,16 +16,18 @@ package registry
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
 
 	"knative.dev/client/pkg/commands"
 	"knative.dev/kn-plugin-admin/pkg"
@@ -36,9 +38,10 @@ import (
 // NewRegistryRmCommand represents the remove command
 func NewRegistryRmCommand(p *pkg.AdminParams) *cobra.Command {
 	var (
-		username       string
-		server         string
-		serviceaccount string
+		username           string
+		server             string
+		serviceaccount     string
+		allServiceAccounts bool
 	)
 
 	var registryRmCmd = &cobra.Command{
@@ -52,7 +55,14 @@ func NewRegistryRmCommand(p *pkg.AdminParams) *cobra.Command {
     --username=[REGISTRY_USER] \
     --server=[REGISTRY_SERVER_URL] \
     --namespace=[NAMESPACE] \
-    --serviceaccount=[SERVICE_ACCOUNT]`,
+    --serviceaccount=[SERVICE_ACCOUNT]
+
+  # To remove registry settings from all service accounts referencing them
+  kn admin registry remove \
+    --username=[REGISTRY_USER] \
+    --server=[REGISTRY_SERVER_URL] \
+    --namespace=[NAMESPACE] \
+    --all-serviceaccounts`,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if username == "" {
 				return errors.New("'registry remove' requires the registry username provided with the --username option")
@@ -60,6 +70,9 @@ func NewRegistryRmCommand(p *pkg.AdminParams) *cobra.Command {
 			if server == "" {
 				return errors.New("'registry remove' requires the registry server url provided with the --server option")
 			}
+			if allServiceAccounts && cmd.Flags().Changed("serviceaccount") {
+				return errors.New("flags '--serviceaccount' and '--all-serviceaccounts' can not be used together")
+			}
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -84,12 +97,11 @@ func NewRegistryRmCommand(p *pkg.AdminParams) *cobra.Command {
 			// filter the secrets with username and server
 			secretsMap := make(map[string]corev1.Secret)
 			for _, secret := range secrets.Items {
-				registry := Registry{}
-				err = json.Unmarshal(secret.Data[DockerJSONName], &registry)
+				auths, err := parseAuths(&secret)
 				if err != nil {
-					return fmt.Errorf("failed unmarshal secret data '.dockerconfigjson': %v", err)
+					return err
 				}
-				for secretServer, secretAuth := range registry.Auths {
+				for secretServer, secretAuth := range auths {
 					if secretServer == server && secretAuth.Username == username {
 						secretsMap[secret.Name] = *secret.DeepCopy()
 					}
@@ -100,50 +112,80 @@ func NewRegistryRmCommand(p *pkg.AdminParams) *cobra.Command {
 				return nil
 			}
 
-			sa, err := client.CoreV1().ServiceAccounts(namespace).Get(context.TODO(), serviceaccount, metav1.GetOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to get serviceaccount '%s' in namespace '%s': %v", serviceaccount, namespace, err)
-			}
+			warnDependentServices(cmd, p, client, namespace, secretsMap)
 
-			desiredSa := sa.DeepCopy()
-			imagePullSecrets := []corev1.LocalObjectReference{}
-			for _, ips := range desiredSa.ImagePullSecrets {
-				if _, ok := secretsMap[ips.Name]; !ok {
-					// only store the secrets that do not exist in the map
-					imagePullSecrets = append(imagePullSecrets, ips)
+			serviceAccounts := []corev1.ServiceAccount{}
+			if allServiceAccounts {
+				saList, err := client.CoreV1().ServiceAccounts(namespace).List(context.TODO(), metav1.ListOptions{})
+				if err != nil {
+					return fmt.Errorf("failed to list serviceaccounts in namespace '%s': %v", namespace, err)
 				}
-
-			}
-
-			desiredSa.ImagePullSecrets = imagePullSecrets
-			_, err = client.CoreV1().ServiceAccounts(namespace).Update(context.TODO(), desiredSa, metav1.UpdateOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to remove registry secret in serviceaccount '%s' in namespace '%s': %v", serviceaccount, namespace, err)
+				for _, sa := range saList.Items {
+					for name := range secretsMap {
+						if hasImagePullSecret(&sa, name) {
+							serviceAccounts = append(serviceAccounts, sa)
+							break
+						}
+					}
+				}
+			} else {
+				sa, err := client.CoreV1().ServiceAccounts(namespace).Get(context.TODO(), serviceaccount, metav1.GetOptions{})
+				if err != nil {
+					return fmt.Errorf("failed to get serviceaccount '%s' in namespace '%s': %v", serviceaccount, namespace, err)
+				}
+				serviceAccounts = append(serviceAccounts, *sa)
 			}
-			cmd.Printf("ImagePullSecrets of serviceaccount '%s' in namespace '%s' is updated\n", desiredSa.Name, desiredSa.Namespace)
 
-			deleteSecretsErrCh := make(chan error, len(secretsMap))
-			deleteSecrets(cmd, client, secretsMap, deleteSecretsErrCh)
+			// detach the secrets from service accounts, the service accounts updated are restored if any failure happens
+			updatedSas := []corev1.ServiceAccount{}
+			for _, sa := range serviceAccounts {
+				desiredSa := sa.DeepCopy()
+				imagePullSecrets := []corev1.LocalObjectReference{}
+				for _, ips := range desiredSa.ImagePullSecrets {
+					if _, ok := secretsMap[ips.Name]; !ok {
+						// only store the secrets that do not exist in the map
+						imagePullSecrets = append(imagePullSecrets, ips)
+					}
+				}
 
-			var deleteSecretsErr error
-			select {
-			case deleteSecretsErr = <-deleteSecretsErrCh:
-				if deleteSecretsErr != nil {
-					break
+				desiredSa.ImagePullSecrets = imagePullSecrets
+				_, err = client.CoreV1().ServiceAccounts(namespace).Update(context.TODO(), desiredSa, metav1.UpdateOptions{})
+				if err != nil {
+					restoreErr := restoreImagePullSecrets(cmd, client, updatedSas, secretsMap)
+					return utilerrors.NewAggregate([]error{
+						fmt.Errorf("failed to remove registry secret in serviceaccount '%s' in namespace '%s': %v", sa.Name, namespace, err),
+						restoreErr,
+					})
 				}
-			default:
+				cmd.Printf("ImagePullSecrets of serviceaccount '%s' in namespace '%s' is updated\n", desiredSa.Name, desiredSa.Namespace)
+				updatedSas = append(updatedSas, sa)
 			}
 
-			if deleteSecretsErr != nil {
-				return fmt.Errorf("failed to delete secrets: %v", deleteSecretsErr)
+			deleteErrs := deleteSecrets(cmd, client, secretsMap)
+			if len(deleteErrs) == 0 {
+				return nil
 			}
 
-			return nil
+			// restore the references to the secrets which are failed to delete
+			failedSecrets := make(map[string]corev1.Secret, len(deleteErrs))
+			errs := make([]error, 0, len(deleteErrs)+1)
+			for name, err := range deleteErrs {
+				failedSecrets[name] = secretsMap[name]
+				errs = append(errs, err)
+			}
+			sort.Slice(errs, func(i, j int) bool {
+				return errs[i].Error() < errs[j].Error()
+			})
+			if err := restoreImagePullSecrets(cmd, client, updatedSas, failedSecrets); err != nil {
+				errs = append(errs, err)
+			}
+			return fmt.Errorf("failed to delete secrets: %v", utilerrors.NewAggregate(errs))
 		},
 	}
 
 	commands.AddNamespaceFlags(registryRmCmd.Flags(), false)
 	registryRmCmd.Flags().StringVar(&serviceaccount, "serviceaccount", "default", "the service account to save imagePullSecrets")
+	registryRmCmd.Flags().BoolVar(&allServiceAccounts, "all-serviceaccounts", false, "remove the registry secrets from all service accounts referencing them")
 	registryRmCmd.Flags().StringVar(&username, "username", "", "registry username")
 	registryRmCmd.MarkFlagRequired("username")
 	registryRmCmd.Flags().StringVar(&server, "server", "", "registry address")
@@ -152,8 +194,13 @@ func NewRegistryRmCommand(p *pkg.AdminParams) *cobra.Command {
 	return registryRmCmd
 }
 
-func deleteSecrets(cmd *cobra.Command, clientset kubernetes.Interface, secretsMap map[string]corev1.Secret, errCh chan<- error) {
-	w := sync.WaitGroup{}
+// deleteSecrets deletes the secrets concurrently and returns the errors indexed by the secret names
+func deleteSecrets(cmd *cobra.Command, clientset kubernetes.Interface, secretsMap map[string]corev1.Secret) map[string]error {
+	var (
+		w    sync.WaitGroup
+		mu   sync.Mutex
+		errs = make(map[string]error)
+	)
 	w.Add(len(secretsMap))
 	for _, s := range secretsMap {
 		go func(secret corev1.Secret) {
@@ -163,7 +210,9 @@ func deleteSecrets(cmd *cobra.Command, clientset kubernetes.Interface, secretsMa
 				if apierrors.IsNotFound(err) {
 					cmd.Printf("Secret '%s' in namespace '%s' is not found, skipped\n", secret.Name, secret.Namespace)
 				} else {
-					errCh <- fmt.Errorf("failed to delete secret '%s' in namespace '%s': %v", secret.Name, secret.Namespace, err)
+					mu.Lock()
+					errs[secret.Name] = fmt.Errorf("failed to delete secret '%s' in namespace '%s': %v", secret.Name, secret.Namespace, err)
+					mu.Unlock()
 				}
 			} else {
 				cmd.Printf("Secret '%s' in namespace '%s' is deleted\n", secret.Name, secret.Namespace)
@@ -171,4 +220,60 @@ func deleteSecrets(cmd *cobra.Command, clientset kubernetes.Interface, secretsMa
 		}(s)
 	}
 	w.Wait()
+	return errs
+}
+
+// restoreImagePullSecrets adds the references to the secrets back to the service accounts
+// if they were referenced by the service accounts originally
+func restoreImagePullSecrets(cmd *cobra.Command, clientset kubernetes.Interface, originalSas []corev1.ServiceAccount, secretsMap map[string]corev1.Secret) error {
+	errs := []error{}
+	for _, original := range originalSas {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			currentSa, err := clientset.CoreV1().ServiceAccounts(original.Namespace).Get(context.TODO(), original.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			desiredSa := currentSa.DeepCopy()
+			for _, ips := range original.ImagePullSecrets {
+				if _, ok := secretsMap[ips.Name]; ok && !hasImagePullSecret(desiredSa, ips.Name) {
+					desiredSa.ImagePullSecrets = append(desiredSa.ImagePullSecrets, ips)
+				}
+			}
+			if len(desiredSa.ImagePullSecrets) == len(currentSa.ImagePullSecrets) {
+				return nil
+			}
+			_, err = clientset.CoreV1().ServiceAccounts(original.Namespace).Update(context.TODO(), desiredSa, metav1.UpdateOptions{})
+			return err
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore ImagePullSecrets of serviceaccount '%s' in namespace '%s': %v", original.Name, original.Namespace, err))
+			continue
+		}
+		cmd.Printf("ImagePullSecrets of serviceaccount '%s' in namespace '%s' is restored\n", original.Name, original.Namespace)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// warnDependentServices warns about the Knative Services which may fail to pull images after the secrets are removed
+func warnDependentServices(cmd *cobra.Command, p *pkg.AdminParams, client kubernetes.Interface, namespace string, secretsMap map[string]corev1.Secret) {
+	servingClient, err := p.NewServingClient()
+	if err != nil {
+		cmd.PrintErrf("Warning: failed to check Knative Services depending on the registry: %v\n", err)
+		return
+	}
+	usage, err := resolveRegistryUsage(client, servingClient, namespace)
+	if err != nil {
+		cmd.PrintErrf("Warning: failed to check Knative Services depending on the registry: %v\n", err)
+		return
+	}
+	names := make([]string, 0, len(secretsMap))
+	for name := range secretsMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, service := range usage.services[name] {
+			cmd.PrintErrf("Warning: Service '%s' in namespace '%s' pulls images with secret '%s', which may fail after the registry is removed\n", service, namespace, name)
+		}
+	}
 }
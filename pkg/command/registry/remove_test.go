@@ -17,6 +17,7 @@ package registry
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -24,6 +25,9 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	servingv1client "knative.dev/serving/pkg/client/clientset/versioned/typed/serving/v1"
 
 	"knative.dev/kn-plugin-admin/pkg"
 	"knative.dev/kn-plugin-admin/pkg/testutil"
@@ -185,4 +189,113 @@ func TestNewRegistryRmCommand(t *testing.T) {
 		assert.Check(t, !isContain, "ImagePullSecrets in the updated serviceaccount should not contain the removed secret")
 	})
 
+	t.Run("flags conflict for registry remove", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams()
+		assert.Check(t, client != nil)
+		cmd := NewRegistryRmCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "--username", "user", "--server", "docker.io", "--serviceaccount", "default", "--all-serviceaccounts")
+		assert.ErrorContains(t, err, "can not be used together")
+	})
+
+	t.Run("registry removed successfully from all serviceaccounts", func(t *testing.T) {
+		secret1 := newRegistryTestSecret(t, "test-secret-1", "default", "user", "docker.io")
+		secret2 := newRegistryTestSecret(t, "test-secret-2", "default", "user", "docker.io")
+		sa1 := createMockServiceAccountWithParams("sa1", "default", []corev1.LocalObjectReference{{Name: "test-secret-1"}, {Name: "other-secret"}})
+		sa2 := createMockServiceAccountWithParams("sa2", "default", []corev1.LocalObjectReference{{Name: "test-secret-2"}})
+		sa3 := createMockServiceAccountWithParams("sa3", "default", []corev1.LocalObjectReference{{Name: "other-secret"}})
+
+		p, client := testutil.NewTestAdminParams(&sa1, &sa2, &sa3, secret1, secret2)
+		assert.Check(t, client != nil)
+		cmd := NewRegistryRmCommand(p)
+		o, err := testutil.ExecuteCommand(cmd, "--username", "user", "--server", "docker.io", "--all-serviceaccounts")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "ImagePullSecrets of serviceaccount 'sa1' in namespace 'default' is updated"), "unexpected output: %s", o)
+		assert.Check(t, strings.Contains(o, "ImagePullSecrets of serviceaccount 'sa2' in namespace 'default' is updated"), "unexpected output: %s", o)
+		assert.Check(t, !strings.Contains(o, "'sa3'"), "unexpected output: %s", o)
+
+		for name, expected := range map[string][]corev1.LocalObjectReference{
+			"sa1": {{Name: "other-secret"}},
+			"sa2": {},
+			"sa3": {{Name: "other-secret"}},
+		} {
+			sa, err := client.CoreV1().ServiceAccounts("default").Get(context.TODO(), name, metav1.GetOptions{})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, expected, sa.ImagePullSecrets)
+		}
+		secrets, err := client.CoreV1().Secrets("default").List(context.TODO(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, 0, len(secrets.Items))
+	})
+
+	t.Run("warn about the services depending on the registry", func(t *testing.T) {
+		secret := newRegistryTestSecret(t, "test-secret", "default", "user", "docker.io")
+		sa := createMockServiceAccountWithParams("default", "default", []corev1.LocalObjectReference{{Name: "test-secret"}})
+
+		p, client := testutil.NewTestAdminParams(&sa, secret)
+		assert.Check(t, client != nil)
+		servingClient := testutil.NewTestServingClient(newTestService("hello", "default", "", "docker.io/team/hello", ""))
+		p.NewServingClient = func() (servingv1client.ServingV1Interface, error) {
+			return servingClient, nil
+		}
+		cmd := NewRegistryRmCommand(p)
+		o, err := testutil.ExecuteCommand(cmd, "--username", "user", "--server", "docker.io")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "Warning: Service 'hello' in namespace 'default' pulls images with secret 'test-secret'"), "unexpected output: %s", o)
+		assert.Check(t, strings.Contains(o, "Secret 'test-secret' in namespace 'default' is deleted"), "unexpected output: %s", o)
+	})
+
+	t.Run("serviceaccounts are restored if secrets deletion failed", func(t *testing.T) {
+		secret1 := newRegistryTestSecret(t, "test-secret-1", "default", "user", "docker.io")
+		secret2 := newRegistryTestSecret(t, "test-secret-2", "default", "user", "docker.io")
+		secret3 := newRegistryTestSecret(t, "test-secret-3", "default", "user", "docker.io")
+		sa := createMockServiceAccountWithParams("default", "default", []corev1.LocalObjectReference{{Name: "test-secret-1"}, {Name: "test-secret-2"}, {Name: "test-secret-3"}})
+
+		p, client := testutil.NewTestAdminParams(&sa, secret1, secret2, secret3)
+		assert.Check(t, client != nil)
+		client.PrependReactor("delete", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			name := action.(k8stesting.DeleteAction).GetName()
+			if name == "test-secret-1" {
+				return false, nil, nil
+			}
+			return true, nil, fmt.Errorf("mock error for %s", name)
+		})
+		cmd := NewRegistryRmCommand(p)
+		o, err := testutil.ExecuteCommand(cmd, "--username", "user", "--server", "docker.io")
+		assert.ErrorContains(t, err, "failed to delete secret 'test-secret-2' in namespace 'default': mock error for test-secret-2")
+		assert.ErrorContains(t, err, "failed to delete secret 'test-secret-3' in namespace 'default': mock error for test-secret-3")
+		assert.Check(t, strings.Contains(o, "ImagePullSecrets of serviceaccount 'default' in namespace 'default' is restored"), "unexpected output: %s", o)
+
+		saUpdated, err := client.CoreV1().ServiceAccounts("default").Get(context.TODO(), "default", metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []corev1.LocalObjectReference{{Name: "test-secret-2"}, {Name: "test-secret-3"}}, saUpdated.ImagePullSecrets)
+	})
+}
+
+func newRegistryTestSecret(t *testing.T, name, namespace, username, server string) *corev1.Secret {
+	dockerCfg := Registry{
+		Auths: Auths{
+			server: registryCred{
+				Username: username,
+				Password: "password",
+				Email:    "email",
+			},
+		},
+	}
+
+	j, err := json.Marshal(dockerCfg)
+	assert.NilError(t, err)
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				pkg.LabelManagedBy: AdminRegistryCmdName,
+			},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			DockerJSONName: j,
+		},
+	}
 }
@@ -0,0 +1,216 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+
+	"knative.dev/kn-plugin-admin/pkg"
+)
+
+// propagatedRegistry identifies a registry added with '--all-namespaces' or '--namespace-selector'
+type propagatedRegistry struct {
+	server            string
+	username          string
+	serviceAccount    string
+	namespaceSelector string
+}
+
+// NewRegistrySyncCommand represents the sync command
+func NewRegistrySyncCommand(p *pkg.AdminParams) *cobra.Command {
+	var registrySyncCmd = &cobra.Command{
+		Use:   "sync",
+		Short: "Sync registry settings to namespaces",
+		Long: `Sync registry settings added with --all-namespaces or --namespace-selector to all matching namespaces,
+so that the namespaces created later get the registry credentials as well`,
+		Example: `
+  # To sync registry settings to all matching namespaces
+  kn admin registry sync`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+
+			// get all credential secrets which have the label managed-by=kn-admin-registry in all namespaces
+			secrets, err := client.CoreV1().Secrets("").List(context.TODO(), metav1.ListOptions{
+				LabelSelector: labels.SelectorFromSet(AdminRegistryLabels).String(),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to list secret: %v", err)
+			}
+
+			registries := make(map[propagatedRegistry][]corev1.Secret)
+			for _, secret := range secrets.Items {
+				key, ok, err := propagatedRegistryOf(&secret)
+				if err != nil {
+					return err
+				}
+				if ok {
+					registries[key] = append(registries[key], secret)
+				}
+			}
+			if len(registries) == 0 {
+				cmd.Println("No registry found to sync")
+				return nil
+			}
+
+			keys := make([]propagatedRegistry, 0, len(registries))
+			for key := range registries {
+				keys = append(keys, key)
+			}
+			sort.Slice(keys, func(i, j int) bool {
+				return fmt.Sprintf("%v", keys[i]) < fmt.Sprintf("%v", keys[j])
+			})
+
+			errs := []error{}
+			for _, key := range keys {
+				if err := syncRegistry(cmd, client, key, registries[key]); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			if len(errs) > 0 {
+				return fmt.Errorf("failed to sync registries: %v", utilerrors.NewAggregate(errs))
+			}
+			return nil
+		},
+	}
+	registrySyncCmd.InitDefaultHelpFlag()
+	return registrySyncCmd
+}
+
+// propagatedRegistryOf returns the propagated registry that the secret belongs to, the secret
+// is skipped if it was not added with '--all-namespaces' or '--namespace-selector'
+func propagatedRegistryOf(secret *corev1.Secret) (propagatedRegistry, bool, error) {
+	selector, ok := secret.Annotations[ImagePullNamespaceSelector]
+	if !ok {
+		return propagatedRegistry{}, false, nil
+	}
+	registry := Registry{}
+	if err := json.Unmarshal(secret.Data[DockerJSONName], &registry); err != nil {
+		return propagatedRegistry{}, false, fmt.Errorf("failed unmarshal data '.dockerconfigjson' of secret '%s' in namespace '%s': %v", secret.Name, secret.Namespace, err)
+	}
+	if len(registry.Auths) != 1 {
+		return propagatedRegistry{}, false, nil
+	}
+	for server, auth := range registry.Auths {
+		return propagatedRegistry{
+			server:            server,
+			username:          auth.Username,
+			serviceAccount:    secret.Labels[ImagePullServiceAccount],
+			namespaceSelector: selector,
+		}, true, nil
+	}
+	return propagatedRegistry{}, false, nil
+}
+
+// syncRegistry makes sure every namespace matching the selector has the registry secret
+// referenced by the service account, the latest created secret is the source of the credentials
+func syncRegistry(cmd *cobra.Command, client kubernetes.Interface, key propagatedRegistry, secrets []corev1.Secret) error {
+	source := secrets[0]
+	secretsByNamespace := make(map[string]corev1.Secret, len(secrets))
+	for _, secret := range secrets {
+		if source.CreationTimestamp.Before(&secret.CreationTimestamp) {
+			source = secret
+		}
+		secretsByNamespace[secret.Namespace] = secret
+	}
+
+	namespaces, err := client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{
+		LabelSelector: key.namespaceSelector,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces for selector '%s': %v", key.namespaceSelector, err)
+	}
+
+	generateName := source.GenerateName
+	if generateName == "" {
+		generateName = fmt.Sprintf("%s-", source.Name)
+	}
+	template := newRegistrySecret(generateName, source.Data[DockerJSONName])
+	template.Annotations = map[string]string{
+		ImagePullNamespaceSelector: key.namespaceSelector,
+	}
+
+	errs := []error{}
+	for _, ns := range namespaces.Items {
+		sa, err := client.CoreV1().ServiceAccounts(ns.Name).Get(context.TODO(), key.serviceAccount, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			cmd.Printf("Serviceaccount '%s' in namespace '%s' is not found, skipped\n", key.serviceAccount, ns.Name)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get serviceaccount '%s' in namespace '%s': %v", key.serviceAccount, ns.Name, err))
+			continue
+		}
+
+		existing, ok := secretsByNamespace[ns.Name]
+		if !ok {
+			if _, err = attachRegistrySecret(client, sa, template); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			cmd.Printf("Private registry '%s' is added for serviceaccount '%s' in namespace '%s'\n", key.server, key.serviceAccount, ns.Name)
+			continue
+		}
+
+		if !bytes.Equal(existing.Data[DockerJSONName], source.Data[DockerJSONName]) {
+			desiredSecret := existing.DeepCopy()
+			desiredSecret.Data[DockerJSONName] = source.Data[DockerJSONName]
+			_, err = client.CoreV1().Secrets(ns.Name).Update(context.TODO(), desiredSecret, metav1.UpdateOptions{})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to update secret '%s' in namespace '%s': %v", existing.Name, ns.Name, err))
+				continue
+			}
+			cmd.Printf("Secret '%s' in namespace '%s' is updated\n", existing.Name, ns.Name)
+		}
+
+		if !hasImagePullSecret(sa, existing.Name) {
+			desiredSa := sa.DeepCopy()
+			desiredSa.ImagePullSecrets = append(desiredSa.ImagePullSecrets, corev1.LocalObjectReference{
+				Name: existing.Name,
+			})
+			_, err = client.CoreV1().ServiceAccounts(ns.Name).Update(context.TODO(), desiredSa, metav1.UpdateOptions{})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to add registry secret in serviceaccount '%s' in namespace '%s': %v", sa.Name, ns.Name, err))
+				continue
+			}
+			cmd.Printf("ImagePullSecrets of serviceaccount '%s' in namespace '%s' is updated\n", sa.Name, ns.Name)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// hasImagePullSecret checks if the secret is referenced in the ImagePullSecrets of the service account
+func hasImagePullSecret(sa *corev1.ServiceAccount, secretName string) bool {
+	for _, ips := range sa.ImagePullSecrets {
+		if ips.Name == secretName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+func newPropagatedSecret(t *testing.T, name, namespace, password, selector string, created time.Time) *corev1.Secret {
+	dockerCfg := Registry{
+		Auths: Auths{
+			"docker.io": registryCred{
+				Username: "user",
+				Password: password,
+				Email:    "email",
+			},
+		},
+	}
+	j, err := json.Marshal(dockerCfg)
+	assert.NilError(t, err)
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			GenerateName:      "registry-secret-",
+			Namespace:         namespace,
+			CreationTimestamp: metav1.NewTime(created),
+			Labels: map[string]string{
+				pkg.LabelManagedBy:      AdminRegistryCmdName,
+				ImagePullServiceAccount: "default",
+			},
+			Annotations: map[string]string{
+				ImagePullNamespaceSelector: selector,
+			},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			DockerJSONName: j,
+		},
+	}
+}
+
+func TestNewRegistrySyncCommand(t *testing.T) {
+	t.Run("kubectl context is not set", func(t *testing.T) {
+		p := testutil.NewTestAdminWithoutKubeConfig()
+		cmd := NewRegistrySyncCommand(p)
+		_, err := testutil.ExecuteCommand(cmd)
+		assert.Error(t, err, testutil.ErrNoKubeConfiguration)
+	})
+
+	t.Run("no registry to sync", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams()
+		assert.Check(t, client != nil)
+		cmd := NewRegistrySyncCommand(p)
+		o, err := testutil.ExecuteCommand(cmd)
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "No registry found to sync"), "unexpected output: %s", o)
+	})
+
+	t.Run("sync registry to namespaces matching selector", func(t *testing.T) {
+		now := time.Now()
+		objects := []runtime.Object{}
+		for name, team := range map[string]string{"ns1": "x", "ns2": "x", "ns3": "x", "ns4": "y"} {
+			objects = append(objects,
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"team": team}}},
+				&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: name}},
+			)
+		}
+		// ns1 has the latest credentials, ns2 has stale credentials which are not referenced by the service account
+		objects = append(objects,
+			newPropagatedSecret(t, "registry-secret-aaaa", "ns1", "new-password", "team=x", now),
+			newPropagatedSecret(t, "registry-secret-bbbb", "ns2", "old-password", "team=x", now.Add(-time.Hour)),
+		)
+
+		p, client := testutil.NewTestAdminParams(objects...)
+		assert.Check(t, client != nil)
+		client.PrependReactor("create", "secrets", generateNameReactor)
+
+		cmd := NewRegistrySyncCommand(p)
+		o, err := testutil.ExecuteCommand(cmd)
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, "Private registry 'docker.io' is added for serviceaccount 'default' in namespace 'ns3'"), "unexpected output: %s", o)
+		assert.Check(t, strings.Contains(o, "Secret 'registry-secret-bbbb' in namespace 'ns2' is updated"), "unexpected output: %s", o)
+
+		for ns, expected := range map[string]int{"ns1": 1, "ns2": 1, "ns3": 1, "ns4": 0} {
+			secrets, err := client.CoreV1().Secrets(ns).List(context.TODO(), metav1.ListOptions{})
+			assert.NilError(t, err)
+			assert.Equal(t, expected, len(secrets.Items), "unexpected secrets in namespace %s: %#v", ns, secrets)
+			if expected == 0 {
+				continue
+			}
+			secret := secrets.Items[0]
+			var r Registry
+			assert.NilError(t, json.Unmarshal(secret.Data[DockerJSONName], &r))
+			assert.Equal(t, "new-password", r.Auths["docker.io"].Password)
+			assert.Equal(t, "team=x", secret.Annotations[ImagePullNamespaceSelector])
+
+			sa, err := client.CoreV1().ServiceAccounts(ns).Get(context.TODO(), "default", metav1.GetOptions{})
+			assert.NilError(t, err)
+			assert.Check(t, hasImagePullSecret(sa, secret.Name), "serviceaccount in namespace %s should reference secret %s", ns, secret.Name)
+		}
+
+		// sync again should not change anything
+		cmd = NewRegistrySyncCommand(p)
+		o, err = testutil.ExecuteCommand(cmd)
+		assert.NilError(t, err)
+		assert.Equal(t, "", o)
+		secrets, err := client.CoreV1().Secrets("").List(context.TODO(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, 3, len(secrets.Items))
+	})
+}
@@ -0,0 +1,320 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1beta1 "k8s.io/apimachinery/pkg/apis/meta/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+
+	"knative.dev/client/pkg/commands/flags"
+	hprinters "knative.dev/client/pkg/printers"
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/command/utils"
+)
+
+var (
+	knativeServing   = "knative-serving"
+	configDeployment = "config-deployment"
+
+	// registriesSkippingTagResolvingKey is the key in config-deployment of the registries which skip tag to digest resolution
+	registriesSkippingTagResolvingKey = "registries-skipping-tag-resolving"
+	// digestResolutionTimeoutKey is the key in config-deployment of the timeout of tag to digest resolution
+	digestResolutionTimeoutKey = "digest-resolution-timeout"
+
+	// defaultRegistriesSkippingTagResolving and defaultDigestResolutionTimeout are
+	// the values used by Knative Serving if the keys are not set in config-deployment
+	defaultRegistriesSkippingTagResolving = "kind.local,ko.local,dev.local"
+	defaultDigestResolutionTimeout        = "10s"
+)
+
+// NewRegistryTagResolutionCommand represents the tag-resolution command
+func NewRegistryTagResolutionCommand(p *pkg.AdminParams) *cobra.Command {
+	var tagResolutionCmd = &cobra.Command{
+		Use:   "tag-resolution",
+		Short: "Manage tag to digest resolution",
+		Long:  `Manage the registries skipping tag to digest resolution and the digest resolution timeout of Knative Serving`,
+	}
+	tagResolutionCmd.AddCommand(newTagResolutionListCommand(p))
+	tagResolutionCmd.AddCommand(newTagResolutionSkipCommand(p))
+	tagResolutionCmd.AddCommand(newTagResolutionUnskipCommand(p))
+	tagResolutionCmd.AddCommand(newTagResolutionTimeoutCommand(p))
+	return tagResolutionCmd
+}
+
+func tagResolutionListHandlers(h hprinters.PrintHandler) {
+	columnDefinitions := []metav1beta1.TableColumnDefinition{
+		{Name: "Registry", Type: "string", Description: "The registry skipping tag to digest resolution.", Priority: 1},
+	}
+	h.TableHandler(columnDefinitions, printRegistriesSkippingTagResolving)
+}
+
+// printRegistriesSkippingTagResolving populates a table row for each registry skipping tag resolving
+func printRegistriesSkippingTagResolving(cm *corev1.ConfigMap, options hprinters.PrintOptions) ([]metav1beta1.TableRow, error) {
+	registries := registriesSkippingTagResolving(cm)
+	rows := make([]metav1beta1.TableRow, 0, len(registries))
+	for _, registry := range registries {
+		row := metav1beta1.TableRow{}
+		row.Cells = append(row.Cells, registry)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func newTagResolutionListCommand(p *pkg.AdminParams) *cobra.Command {
+	listFlags := flags.NewListPrintFlags(tagResolutionListHandlers)
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List registries skipping tag resolution",
+		Long:    `List registries skipping tag to digest resolution`,
+		Example: `
+  # To list registries skipping tag to digest resolution
+  kn admin registry tag-resolution list`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+			cm, err := getConfigDeployment(client)
+			if err != nil {
+				return err
+			}
+			return listFlags.Print(cm, cmd.OutOrStdout())
+		},
+	}
+	listFlags.HumanReadableFlags.AddFlags(listCmd)
+	listFlags.GenericPrintFlags.OutputFlagSpecified = func() bool {
+		return false
+	}
+	return listCmd
+}
+
+func newTagResolutionSkipCommand(p *pkg.AdminParams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "skip REGISTRY...",
+		Short: "Skip tag resolution for registries",
+		Long:  `Skip tag to digest resolution for registries, e.g: the local registries without digest support`,
+		Example: `
+  # To skip tag to digest resolution for registry localhost:5000
+  kn admin registry tag-resolution skip localhost:5000`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("'tag-resolution skip' requires the registry hostname(s) as arguments")
+			}
+			if err := validateRegistryHostnames(args); err != nil {
+				return err
+			}
+			return p.EnsureInstallMethodStandalone()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateRegistriesSkippingTagResolving(cmd, p, func(registries []string) ([]string, []string) {
+				messages := []string{}
+				for _, arg := range args {
+					registry := strings.ToLower(arg)
+					if slices.Contains(registries, registry) {
+						messages = append(messages, fmt.Sprintf("Registry '%s' already skips tag resolution", registry))
+						continue
+					}
+					registries = append(registries, registry)
+					messages = append(messages, fmt.Sprintf("Registry '%s' skips tag resolution", registry))
+				}
+				return registries, messages
+			})
+		},
+	}
+}
+
+func newTagResolutionUnskipCommand(p *pkg.AdminParams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "unskip REGISTRY...",
+		Short: "Resolve tags for registries again",
+		Long:  `Remove registries from the list of registries skipping tag to digest resolution`,
+		Example: `
+  # To resolve tags to digests for registry ko.local again
+  kn admin registry tag-resolution unskip ko.local`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("'tag-resolution unskip' requires the registry hostname(s) as arguments")
+			}
+			return p.EnsureInstallMethodStandalone()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateRegistriesSkippingTagResolving(cmd, p, func(registries []string) ([]string, []string) {
+				messages := []string{}
+				for _, arg := range args {
+					registry := strings.ToLower(arg)
+					if !slices.Contains(registries, registry) {
+						messages = append(messages, fmt.Sprintf("Registry '%s' does not skip tag resolution", registry))
+						continue
+					}
+					desired := make([]string, 0, len(registries))
+					for _, r := range registries {
+						if r != registry {
+							desired = append(desired, r)
+						}
+					}
+					registries = desired
+					messages = append(messages, fmt.Sprintf("Registry '%s' resolves tags again", registry))
+				}
+				return registries, messages
+			})
+		},
+	}
+}
+
+func newTagResolutionTimeoutCommand(p *pkg.AdminParams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "timeout [DURATION]",
+		Short: "Show or set digest resolution timeout",
+		Long:  `Show the digest resolution timeout, or set it if a duration is given`,
+		Example: `
+  # To show the digest resolution timeout
+  kn admin registry tag-resolution timeout
+
+  # To set the digest resolution timeout to 30 seconds
+  kn admin registry tag-resolution timeout 30s`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("'tag-resolution timeout' accepts at most one duration argument")
+			}
+			if len(args) == 0 {
+				return nil
+			}
+			timeout, err := time.ParseDuration(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid timeout '%s': %v", args[0], err)
+			}
+			if timeout <= 0 {
+				return fmt.Errorf("timeout must be positive, got %s", args[0])
+			}
+			return p.EnsureInstallMethodStandalone()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := p.NewKubeClient()
+			if err != nil {
+				return err
+			}
+			currentCm, err := getConfigDeployment(client)
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				timeout, ok := currentCm.Data[digestResolutionTimeoutKey]
+				if !ok {
+					timeout = defaultDigestResolutionTimeout
+				}
+				cmd.Printf("Digest resolution timeout: %s\n", timeout)
+				return nil
+			}
+
+			desiredCm := currentCm.DeepCopy()
+			if desiredCm.Data == nil {
+				desiredCm.Data = map[string]string{}
+			}
+			desiredCm.Data[digestResolutionTimeoutKey] = args[0]
+			if err = utils.UpdateConfigMap(client, desiredCm); err != nil {
+				return fmt.Errorf("failed to update ConfigMap %s in namespace %s: %+v", configDeployment, knativeServing, err)
+			}
+			cmd.Printf("Updated digest resolution timeout to %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+func getConfigDeployment(client kubernetes.Interface) (*corev1.ConfigMap, error) {
+	cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configDeployment, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ConfigMap %s in namespace %s: %+v", configDeployment, knativeServing, err)
+	}
+	return cm, nil
+}
+
+// registriesSkippingTagResolving returns the registries skipping tag resolving, the Knative Serving
+// defaults are returned if the key is not set
+func registriesSkippingTagResolving(cm *corev1.ConfigMap) []string {
+	value, ok := cm.Data[registriesSkippingTagResolvingKey]
+	if !ok {
+		value = defaultRegistriesSkippingTagResolving
+	}
+	registries := []string{}
+	for _, r := range strings.Split(value, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			registries = append(registries, r)
+		}
+	}
+	return registries
+}
+
+// updateRegistriesSkippingTagResolving updates the registries skipping tag resolving with the given function,
+// the messages returned by the function are printed once the ConfigMap is updated
+func updateRegistriesSkippingTagResolving(cmd *cobra.Command, p *pkg.AdminParams, update func([]string) ([]string, []string)) error {
+	client, err := p.NewKubeClient()
+	if err != nil {
+		return err
+	}
+	currentCm, err := getConfigDeployment(client)
+	if err != nil {
+		return err
+	}
+
+	desiredCm := currentCm.DeepCopy()
+	if desiredCm.Data == nil {
+		desiredCm.Data = map[string]string{}
+	}
+	registries, messages := update(registriesSkippingTagResolving(currentCm))
+	desiredCm.Data[registriesSkippingTagResolvingKey] = strings.Join(registries, ",")
+	if err = utils.UpdateConfigMap(client, desiredCm); err != nil {
+		return fmt.Errorf("failed to update ConfigMap %s in namespace %s: %+v", configDeployment, knativeServing, err)
+	}
+	for _, message := range messages {
+		cmd.Println(message)
+	}
+	return nil
+}
+
+// validateRegistryHostnames checks the registries are valid hostnames with optional ports, e.g: 'localhost:5000'
+func validateRegistryHostnames(registries []string) error {
+	for _, registry := range registries {
+		host := strings.ToLower(registry)
+		if h, port, err := net.SplitHostPort(host); err == nil {
+			n, err := strconv.Atoi(port)
+			if err != nil || len(validation.IsValidPortNum(n)) > 0 {
+				return fmt.Errorf("invalid registry '%s': invalid port '%s'", registry, port)
+			}
+			host = h
+		}
+		if net.ParseIP(host) != nil {
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+			return fmt.Errorf("invalid registry '%s': %s", registry, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
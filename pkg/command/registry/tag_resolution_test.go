@@ -0,0 +1,171 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8srt "k8s.io/apimachinery/pkg/runtime"
+	k8sfakecorev1 "k8s.io/client-go/kubernetes/typed/core/v1/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"knative.dev/kn-plugin-admin/pkg"
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+func newConfigDeployment(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configDeployment,
+			Namespace: knativeServing,
+		},
+		Data: data,
+	}
+}
+
+func TestNewRegistryTagResolutionCommand(t *testing.T) {
+	cmd := NewRegistryTagResolutionCommand(nil)
+	assert.Check(t, cmd.HasSubCommands(), "cmd tag-resolution should have subcommands")
+	assert.Equal(t, 4, len(cmd.Commands()), "tag-resolution command should have 4 subcommands")
+	for _, name := range []string{"list", "skip", "unskip", "timeout"} {
+		_, _, err := cmd.Find([]string{name})
+		assert.NilError(t, err, "tag-resolution command should have %s subcommand", name)
+	}
+}
+
+func TestTagResolutionList(t *testing.T) {
+	t.Run("list default registries", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams(newConfigDeployment(map[string]string{}))
+		assert.Check(t, client != nil)
+		cmd := NewRegistryTagResolutionCommand(p)
+		output, err := testutil.ExecuteCommand(cmd, "list")
+		assert.NilError(t, err)
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		assert.DeepEqual(t, []string{"REGISTRY", "kind.local", "ko.local", "dev.local"}, lines)
+	})
+
+	t.Run("config map not exist", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams()
+		cmd := NewRegistryTagResolutionCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "list")
+		assert.ErrorContains(t, err, "failed to get ConfigMap config-deployment")
+	})
+}
+
+func TestTagResolutionSkip(t *testing.T) {
+	t.Run("invalid registry hostname", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newConfigDeployment(map[string]string{}))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		cmd := NewRegistryTagResolutionCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "skip", "local_registry")
+		assert.ErrorContains(t, err, "invalid registry 'local_registry'")
+
+		_, err = testutil.ExecuteCommand(cmd, "skip", "localhost:99999")
+		assert.ErrorContains(t, err, "invalid port '99999'")
+
+		_, err = testutil.ExecuteCommand(cmd, "skip")
+		assert.ErrorContains(t, err, "requires the registry hostname(s)")
+	})
+
+	t.Run("operator mode should not be supported", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newConfigDeployment(map[string]string{}))
+		p.InstallationMethod = pkg.InstallationMethodOperator
+		cmd := NewRegistryTagResolutionCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "skip", "localhost:5000")
+		assert.ErrorContains(t, err, "Knative managed by operator is not supported yet")
+	})
+
+	t.Run("skip registries keeping the default ones", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams(newConfigDeployment(map[string]string{}))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		cmd := NewRegistryTagResolutionCommand(p)
+		output, err := testutil.ExecuteCommand(cmd, "skip", "localhost:5000", "10.0.0.1", "ko.local")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Registry 'localhost:5000' skips tag resolution"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(output, "Registry 'ko.local' already skips tag resolution"), "unexpected output: %s", output)
+
+		cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configDeployment, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "kind.local,ko.local,dev.local,localhost:5000,10.0.0.1", cm.Data[registriesSkippingTagResolvingKey])
+	})
+
+	t.Run("no success message if the update fails", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams(newConfigDeployment(map[string]string{}))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		client.CoreV1().(*k8sfakecorev1.FakeCoreV1).PrependReactor("update", "configmaps",
+			func(action k8stesting.Action) (handled bool, ret k8srt.Object, err error) {
+				return true, nil, errors.New("error updating configmap")
+			})
+		cmd := NewRegistryTagResolutionCommand(p)
+		output, err := testutil.ExecuteCommand(cmd, "skip", "localhost:5000")
+		assert.ErrorContains(t, err, "error updating configmap")
+		assert.Check(t, !strings.Contains(output, "skips tag resolution"), "unexpected output: %s", output)
+	})
+}
+
+func TestTagResolutionUnskip(t *testing.T) {
+	p, client := testutil.NewTestAdminParams(newConfigDeployment(map[string]string{
+		registriesSkippingTagResolvingKey: "ko.local, registry.local ,dev.local",
+	}))
+	p.InstallationMethod = pkg.InstallationMethodStandalone
+	cmd := NewRegistryTagResolutionCommand(p)
+	output, err := testutil.ExecuteCommand(cmd, "unskip", "registry.local", "kind.local")
+	assert.NilError(t, err)
+	assert.Check(t, strings.Contains(output, "Registry 'registry.local' resolves tags again"), "unexpected output: %s", output)
+	assert.Check(t, strings.Contains(output, "Registry 'kind.local' does not skip tag resolution"), "unexpected output: %s", output)
+
+	cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configDeployment, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, "ko.local,dev.local", cm.Data[registriesSkippingTagResolvingKey])
+}
+
+func TestTagResolutionTimeout(t *testing.T) {
+	t.Run("show default timeout", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newConfigDeployment(map[string]string{}))
+		cmd := NewRegistryTagResolutionCommand(p)
+		output, err := testutil.ExecuteCommand(cmd, "timeout")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(output, "Digest resolution timeout: 10s"), "unexpected output: %s", output)
+	})
+
+	t.Run("invalid timeout", func(t *testing.T) {
+		p, _ := testutil.NewTestAdminParams(newConfigDeployment(map[string]string{}))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		cmd := NewRegistryTagResolutionCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "timeout", "abc")
+		assert.ErrorContains(t, err, "invalid timeout 'abc'")
+
+		_, err = testutil.ExecuteCommand(cmd, "timeout", "--", "-1s")
+		assert.ErrorContains(t, err, "timeout must be positive")
+	})
+
+	t.Run("set timeout", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams(newConfigDeployment(map[string]string{}))
+		p.InstallationMethod = pkg.InstallationMethodStandalone
+		cmd := NewRegistryTagResolutionCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "timeout", "30s")
+		assert.NilError(t, err)
+
+		cm, err := client.CoreV1().ConfigMaps(knativeServing).Get(context.TODO(), configDeployment, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, "30s", cm.Data[digestResolutionTimeoutKey])
+	})
+}
@@ -0,0 +1,195 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	servingv1client "knative.dev/serving/pkg/client/clientset/versioned/typed/serving/v1"
+)
+
+// registryUsage records the Knative Services depending on the image pull secrets in a namespace
+type registryUsage struct {
+	// services are the names of Knative Services indexed by the secret names
+	services map[string][]string
+	// missing are the warnings of images pulled from registries without credentials
+	missing []string
+}
+
+// resolveRegistryUsage resolves the images of the latest Revision of every Knative Service in the namespace,
+// a Service depends on a secret if its ServiceAccount or its PodSpec references the secret and the secret has
+// credentials for the registry of any image
+func resolveRegistryUsage(client kubernetes.Interface, servingClient servingv1client.ServingV1Interface, namespace string) (*registryUsage, error) {
+	serviceAccounts, err := client.CoreV1().ServiceAccounts(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list serviceaccounts in namespace '%s': %v", namespace, err)
+	}
+	imagePullSecrets := make(map[string][]string, len(serviceAccounts.Items))
+	for _, sa := range serviceAccounts.Items {
+		for _, ips := range sa.ImagePullSecrets {
+			imagePullSecrets[sa.Name] = append(imagePullSecrets[sa.Name], ips.Name)
+		}
+	}
+
+	secrets, err := client.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets in namespace '%s': %v", namespace, err)
+	}
+	registries := make(map[string]sets.Set[string], len(secrets.Items))
+	for _, secret := range secrets.Items {
+		if !isImagePullSecret(&secret) {
+			continue
+		}
+		auths, err := parseAuths(&secret)
+		if err != nil {
+			return nil, err
+		}
+		registries[secret.Name] = sets.New[string]()
+		for server := range auths {
+			registries[secret.Name].Insert(registryHost(server))
+		}
+	}
+
+	services, err := servingClient.Services(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services in namespace '%s': %v", namespace, err)
+	}
+
+	usage := &registryUsage{services: map[string][]string{}}
+	for _, service := range services.Items {
+		podSpec, err := latestPodSpec(servingClient, &service)
+		if err != nil {
+			return nil, err
+		}
+		pullSecrets := podImagePullSecrets(podSpec.ServiceAccountName, podSpec, imagePullSecrets)
+
+		dependencies := sets.New[string]()
+		for _, c := range podSpec.Containers {
+			host := imageRegistry(c.Image)
+			covered := false
+			for _, secret := range pullSecrets {
+				if registries[secret].Has(host) {
+					dependencies.Insert(secret)
+					covered = true
+				}
+			}
+			if !covered {
+				usage.missing = append(usage.missing, fmt.Sprintf("Service '%s' in namespace '%s' pulls image '%s' from registry '%s' without credentials",
+					service.Name, namespace, c.Image, host))
+			}
+		}
+		for _, secret := range sets.List(dependencies) {
+			usage.services[secret] = append(usage.services[secret], service.Name)
+		}
+	}
+	for secret := range usage.services {
+		sort.Strings(usage.services[secret])
+	}
+	sort.Strings(usage.missing)
+	return usage, nil
+}
+
+// latestPodSpec returns the PodSpec of the latest created Revision of the Service, the PodSpec
+// in the Service template is returned if the Revision is not found
+func latestPodSpec(servingClient servingv1client.ServingV1Interface, service *servingv1.Service) (*corev1.PodSpec, error) {
+	podSpec := service.Spec.Template.Spec.PodSpec
+	if name := service.Status.LatestCreatedRevisionName; name != "" {
+		revision, err := servingClient.Revisions(service.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			podSpec = revision.Spec.PodSpec
+		case !apierrors.IsNotFound(err):
+			return nil, fmt.Errorf("failed to get revision '%s' in namespace '%s': %v", name, service.Namespace, err)
+		}
+	}
+	return &podSpec, nil
+}
+
+// podImagePullSecrets returns the image pull secrets used by the pods of the PodSpec running as the ServiceAccount,
+// i.e: the ones in the PodSpec and the ones of the ServiceAccount, serviceAccounts are the secret names indexed by
+// ServiceAccount names
+func podImagePullSecrets(sa string, podSpec *corev1.PodSpec, serviceAccounts map[string][]string) []string {
+	if sa == "" {
+		sa = "default"
+	}
+	secrets := sets.New[string](serviceAccounts[sa]...)
+	for _, ips := range podSpec.ImagePullSecrets {
+		secrets.Insert(ips.Name)
+	}
+	return sets.List(secrets)
+}
+
+// servingImagePullSecrets returns the image pull secrets referenced in the PodSpecs of the Knative Services and
+// Revisions in the namespace, or all namespaces if namespace is empty, as 'namespace/name'
+func servingImagePullSecrets(servingClient servingv1client.ServingV1Interface, namespace string) (sets.Set[string], error) {
+	services, err := servingClient.Services(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services in namespace '%s': %v", namespace, err)
+	}
+	revisions, err := servingClient.Revisions(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions in namespace '%s': %v", namespace, err)
+	}
+
+	secrets := sets.New[string]()
+	for _, service := range services.Items {
+		for _, secret := range podImagePullSecrets(service.Spec.Template.Spec.ServiceAccountName, &service.Spec.Template.Spec.PodSpec, nil) {
+			secrets.Insert(service.Namespace + "/" + secret)
+		}
+	}
+	for _, revision := range revisions.Items {
+		for _, secret := range podImagePullSecrets(revision.Spec.ServiceAccountName, &revision.Spec.PodSpec, nil) {
+			secrets.Insert(revision.Namespace + "/" + secret)
+		}
+	}
+	return secrets, nil
+}
+
+// imageRegistry returns the registry host of the image, e.g: 'index.docker.io' for 'nginx'
+func imageRegistry(image string) string {
+	ref, err := name.ParseReference(image, name.WeakValidation)
+	if err != nil {
+		return strings.SplitN(image, "/", 2)[0]
+	}
+	return ref.Context().RegistryStr()
+}
+
+// registryHost returns the registry host of the server in the registry secret, the scheme and path are
+// stripped and Docker Hub aliases are normalized, e.g: 'index.docker.io' for 'https://index.docker.io/v1/'
+func registryHost(server string) string {
+	host := server
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	host = strings.SplitN(host, "/", 2)[0]
+	if host == dockerHubRegistry {
+		host = name.DefaultRegistry
+	}
+	registry, err := name.NewRegistry(host, name.WeakValidation)
+	if err != nil {
+		return host
+	}
+	return registry.RegistryStr()
+}
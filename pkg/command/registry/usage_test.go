@@ -0,0 +1,119 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+// newTestService returns a Knative Service using the serviceaccount and image, the latest
+// created Revision is set if revision is not empty
+func newTestService(name, namespace, serviceAccount, image, revision string) *servingv1.Service {
+	service := &servingv1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+	service.Spec.Template.Spec.ServiceAccountName = serviceAccount
+	service.Spec.Template.Spec.Containers = []corev1.Container{{Image: image}}
+	service.Status.LatestCreatedRevisionName = revision
+	return service
+}
+
+// addImagePullSecrets adds the image pull secrets to the PodSpec
+func addImagePullSecrets(podSpec *corev1.PodSpec, secrets ...string) {
+	for _, secret := range secrets {
+		podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, corev1.LocalObjectReference{Name: secret})
+	}
+}
+
+// newTestRevision returns a Knative Revision using the serviceaccount and image
+func newTestRevision(name, namespace, serviceAccount, image string) *servingv1.Revision {
+	revision := &servingv1.Revision{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+	revision.Spec.ServiceAccountName = serviceAccount
+	revision.Spec.Containers = []corev1.Container{{Image: image}}
+	return revision
+}
+
+func TestResolveRegistryUsage(t *testing.T) {
+	quaySecret := newRegistryTestSecret(t, "quay-secret", "default", "user", "quay.io")
+	dockerSecret := newRegistryTestSecret(t, "docker-secret", "default", "user", "https://index.docker.io/v1/")
+	gcrSecret := newRegistryTestSecret(t, "gcr-secret", "default", "user", "gcr.io")
+	builder := &corev1.ServiceAccount{
+		ObjectMeta:       metav1.ObjectMeta{Name: "builder", Namespace: "default"},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "quay-secret"}, {Name: "docker-secret"}},
+	}
+	sa := &corev1.ServiceAccount{
+		ObjectMeta:       metav1.ObjectMeta{Name: "default", Namespace: "default"},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "quay-secret"}},
+	}
+	// the secret referenced in the PodSpec is used besides the ones of the serviceaccount
+	mixed := newTestService("mixed", "default", "", "gcr.io/team/mixed", "mixed-00001")
+	addImagePullSecrets(&mixed.Spec.Template.Spec.PodSpec, "gcr-secret")
+	mixedRevision := newTestRevision("mixed-00001", "default", "", "gcr.io/team/mixed")
+	addImagePullSecrets(&mixedRevision.Spec.PodSpec, "gcr-secret")
+	sidecar := newTestService("sidecar", "default", "", "quay.io/team/sidecar", "")
+	addImagePullSecrets(&sidecar.Spec.Template.Spec.PodSpec, "gcr-secret")
+	client := testutil.NewTestServingClient(
+		// the serviceaccount and image of the latest revision are used instead of the ones in the template
+		newTestService("hello", "default", "", "quay.io/team/hello:v2", "hello-00001"),
+		newTestRevision("hello-00001", "default", "builder", "nginx:1.25"),
+		newTestService("world", "default", "", "quay.io/team/world", ""),
+		newTestService("unknown", "default", "", "gcr.io/team/unknown", "unknown-00001"),
+		mixed, mixedRevision, sidecar,
+	)
+	p, _ := testutil.NewTestAdminParams(quaySecret, dockerSecret, gcrSecret, builder, sa)
+	kubeClient, err := p.NewKubeClient()
+	assert.NilError(t, err)
+
+	usage, err := resolveRegistryUsage(kubeClient, client, "default")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[string][]string{
+		"docker-secret": {"hello"},
+		"gcr-secret":    {"mixed"},
+		"quay-secret":   {"sidecar", "world"},
+	}, usage.services)
+	assert.DeepEqual(t, []string{
+		"Service 'unknown' in namespace 'default' pulls image 'gcr.io/team/unknown' from registry 'gcr.io' without credentials",
+	}, usage.missing)
+}
+
+func TestRegistryHost(t *testing.T) {
+	for server, expected := range map[string]string{
+		"docker.io":                   "index.docker.io",
+		"registry-1.docker.io":        "index.docker.io",
+		"https://index.docker.io/v1/": "index.docker.io",
+		"localhost:5000":              "localhost:5000",
+		"http://quay.io":              "quay.io",
+	} {
+		assert.Equal(t, expected, registryHost(server), "unexpected host of server %s", server)
+	}
+
+	for image, expected := range map[string]string{
+		"nginx":              "index.docker.io",
+		"library/nginx:1.25": "index.docker.io",
+		"quay.io/team/hello@sha256:" + "0123456789012345678901234567890123456789012345678901234567890123": "quay.io",
+		"localhost:5000/hello": "localhost:5000",
+	} {
+		assert.Equal(t, expected, imageRegistry(image), "unexpected registry of image %s", image)
+	}
+}
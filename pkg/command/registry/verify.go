@@ -0,0 +1,286 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"knative.dev/kn-plugin-admin/pkg"
+)
+
+const (
+	// dockerHubRegistry is the registry serving the Docker Registry v2 API for Docker Hub
+	dockerHubRegistry = "registry-1.docker.io"
+	verifyTimeout     = 30 * time.Second
+	insecureFlagUsage = "allow plain HTTP and skip TLS certificate verification when connecting to the registry"
+)
+
+// errInvalidCredentials indicates that the registry rejected the credentials
+var errInvalidCredentials = errors.New("the registry rejected the username or password")
+
+// NewRegistryVerifyCommand represents the verify command
+func NewRegistryVerifyCommand(p *pkg.AdminParams) *cobra.Command {
+	var (
+		server   string
+		username string
+		password string
+		insecure bool
+	)
+
+	var registryVerifyCmd = &cobra.Command{
+		Use:   "verify",
+		Short: "Verify registry credentials",
+		Long:  `Verify registry credentials by performing the Docker Registry v2 authentication against the registry server`,
+		Example: `
+  # To verify registry credentials
+  kn admin registry verify \
+    --server=[REGISTRY_SERVER_URL] \
+    --username=[REGISTRY_USER] \
+    --password=[REGISTRY_PASSWORD]
+
+  # To verify registry credentials of a local registry using plain HTTP or a self-signed certificate
+  kn admin registry verify \
+    --server=localhost:5000 \
+    --username=[REGISTRY_USER] \
+    --password=[REGISTRY_PASSWORD] \
+    --insecure`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if username == "" {
+				return errors.New("'registry verify' requires the registry username provided with the --username option")
+			}
+			if password == "" {
+				return errors.New("'registry verify' requires the registry password provided with the --password option")
+			}
+			if server == "" {
+				return errors.New("'registry verify' requires the registry server provided with the --server option")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := verifyRegistry(server, username, password, insecure); err != nil {
+				return fmt.Errorf("failed to verify registry '%s': %v", server, err)
+			}
+			cmd.Printf("Credentials of user '%s' for registry '%s' are verified\n", username, server)
+			return nil
+		},
+	}
+
+	registryVerifyCmd.Flags().StringVar(&server, "server", "", "registry address")
+	registryVerifyCmd.MarkFlagRequired("server")
+	registryVerifyCmd.Flags().StringVar(&username, "username", "", "registry username")
+	registryVerifyCmd.MarkFlagRequired("username")
+	registryVerifyCmd.Flags().StringVar(&password, "password", "", "registry password")
+	registryVerifyCmd.MarkFlagRequired("password")
+	registryVerifyCmd.Flags().BoolVar(&insecure, "insecure", false, insecureFlagUsage)
+	registryVerifyCmd.InitDefaultHelpFlag()
+	return registryVerifyCmd
+}
+
+// verifyRegistry performs the Docker Registry v2 authentication handshake against the server:
+// ping '/v2/', then answer the basic auth or bearer token challenge with the credentials
+func verifyRegistry(server, username, password string, insecure bool) error {
+	client := &http.Client{Timeout: verifyTimeout}
+	if insecure {
+		client.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
+
+	base, err := registryBaseURL(server)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Get(base.String() + "/v2/")
+	// fallback to plain HTTP for insecure registries if no scheme is given
+	if err != nil && insecure && !strings.Contains(server, "://") {
+		base.Scheme = "http"
+		resp, err = client.Get(base.String() + "/v2/")
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// the registry does not require authentication
+		return nil
+	case http.StatusUnauthorized:
+	default:
+		return fmt.Errorf("unexpected status code %d from %s/v2/", resp.StatusCode, base)
+	}
+
+	scheme, params := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	switch strings.ToLower(scheme) {
+	case "basic":
+		return pingRegistry(client, base, func(req *http.Request) {
+			req.SetBasicAuth(username, password)
+		})
+	case "bearer":
+		token, err := fetchToken(client, params, username, password)
+		if err != nil {
+			return err
+		}
+		return pingRegistry(client, base, func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+token)
+		})
+	default:
+		return fmt.Errorf("unsupported authentication challenge '%s'", resp.Header.Get("WWW-Authenticate"))
+	}
+}
+
+// registryBaseURL returns the base URL of the registry, https is used if no scheme is given
+func registryBaseURL(server string) (*url.URL, error) {
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry server '%s': %v", server, err)
+	}
+	switch u.Host {
+	case "docker.io", "index.docker.io":
+		u.Host = dockerHubRegistry
+	}
+	return &url.URL{Scheme: u.Scheme, Host: u.Host}, nil
+}
+
+// pingRegistry sends an authorized request to '/v2/' and checks the credentials are accepted
+func pingRegistry(client *http.Client, base *url.URL, authorize func(*http.Request)) error {
+	req, err := http.NewRequest(http.MethodGet, base.String()+"/v2/", nil)
+	if err != nil {
+		return err
+	}
+	authorize(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errInvalidCredentials
+	default:
+		return fmt.Errorf("unexpected status code %d from %s/v2/", resp.StatusCode, base)
+	}
+}
+
+// fetchToken requests a bearer token from the token service given in the challenge
+func fetchToken(client *http.Client, params map[string]string, username, password string) (string, error) {
+	realm, ok := params["realm"]
+	if !ok {
+		return "", errors.New("bearer challenge without realm")
+	}
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("invalid token realm '%s': %v", realm, err)
+	}
+	query := u.Query()
+	for _, key := range []string{"service", "scope"} {
+		if v, ok := params[key]; ok {
+			query.Set(key, v)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(username, password)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "", errInvalidCredentials
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("unexpected status code %d from token service: %s", resp.StatusCode, string(body))
+	}
+
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %v", err)
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	if token.AccessToken != "" {
+		return token.AccessToken, nil
+	}
+	return "", errors.New("no token found in token service response")
+}
+
+// parseChallenge parses the WWW-Authenticate header, e.g:
+// 'Bearer realm="https://auth.docker.io/token",service="registry.docker.io"'
+func parseChallenge(header string) (string, map[string]string) {
+	params := map[string]string{}
+	header = strings.TrimSpace(header)
+	parts := strings.SplitN(header, " ", 2)
+	scheme := parts[0]
+	if len(parts) < 2 {
+		return scheme, params
+	}
+
+	rest := parts[1]
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.Index(rest, ",")
+			if end < 0 {
+				value, rest = rest, ""
+			} else {
+				value, rest = rest[:end], rest[end+1:]
+			}
+		}
+		params[key] = value
+	}
+	return scheme, params
+}
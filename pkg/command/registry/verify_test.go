@@ -0,0 +1,146 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"knative.dev/kn-plugin-admin/pkg/testutil"
+)
+
+// newBasicAuthRegistry returns a handler of a registry using basic auth
+func newBasicAuthRegistry(username, password string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		if !ok || u != username || p != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Registry Realm"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// newTokenAuthRegistry returns a handler of a registry using bearer token auth, the token service is served in '/token'
+func newTokenAuthRegistry(username, password string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			u, p, ok := r.BasicAuth()
+			if !ok || u != username || p != password || r.URL.Query().Get("service") != "test-registry" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"token": "valid-token"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer valid-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="https://%s/token",service="test-registry"`, r.Host))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestNewRegistryVerifyCommand(t *testing.T) {
+	t.Run("incompleted args for registry verify", func(t *testing.T) {
+		p, client := testutil.NewTestAdminParams()
+		assert.Check(t, client != nil)
+		cmd := NewRegistryVerifyCommand(p)
+
+		_, err := testutil.ExecuteCommand(cmd, "--username", "")
+		assert.ErrorContains(t, err, "requires the registry username")
+
+		_, err = testutil.ExecuteCommand(cmd, "--username", "test")
+		assert.ErrorContains(t, err, "requires the registry password")
+
+		_, err = testutil.ExecuteCommand(cmd, "--username", "test", "--password", "test")
+		assert.ErrorContains(t, err, "requires the registry server")
+	})
+
+	t.Run("verify basic auth registry over plain HTTP", func(t *testing.T) {
+		server := httptest.NewServer(newBasicAuthRegistry("user", "password"))
+		defer server.Close()
+		host := strings.TrimPrefix(server.URL, "http://")
+
+		p, _ := testutil.NewTestAdminParams()
+		cmd := NewRegistryVerifyCommand(p)
+		o, err := testutil.ExecuteCommand(cmd, "--username", "user", "--password", "password", "--server", host, "--insecure")
+		assert.NilError(t, err)
+		assert.Check(t, strings.Contains(o, fmt.Sprintf("Credentials of user 'user' for registry '%s' are verified", host)), "unexpected output: %s", o)
+
+		cmd = NewRegistryVerifyCommand(p)
+		_, err = testutil.ExecuteCommand(cmd, "--username", "user", "--password", "wrong", "--server", host, "--insecure")
+		assert.ErrorContains(t, err, errInvalidCredentials.Error())
+
+		cmd = NewRegistryVerifyCommand(p)
+		_, err = testutil.ExecuteCommand(cmd, "--username", "user", "--password", "password", "--server", server.URL)
+		assert.NilError(t, err)
+	})
+
+	t.Run("verify token auth registry with self-signed certificate", func(t *testing.T) {
+		server := httptest.NewTLSServer(newTokenAuthRegistry("user", "password"))
+		defer server.Close()
+		host := strings.TrimPrefix(server.URL, "https://")
+
+		p, _ := testutil.NewTestAdminParams()
+		cmd := NewRegistryVerifyCommand(p)
+		_, err := testutil.ExecuteCommand(cmd, "--username", "user", "--password", "password", "--server", host, "--insecure")
+		assert.NilError(t, err)
+
+		cmd = NewRegistryVerifyCommand(p)
+		_, err = testutil.ExecuteCommand(cmd, "--username", "user", "--password", "wrong", "--server", host, "--insecure")
+		assert.ErrorContains(t, err, errInvalidCredentials.Error())
+
+		cmd = NewRegistryVerifyCommand(p)
+		_, err = testutil.ExecuteCommand(cmd, "--username", "user", "--password", "password", "--server", host)
+		assert.ErrorContains(t, err, "certificate")
+	})
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:samalba/my-app:pull,push"`)
+	assert.Equal(t, "Bearer", scheme)
+	assert.Equal(t, "https://auth.docker.io/token", params["realm"])
+	assert.Equal(t, "registry.docker.io", params["service"])
+	assert.Equal(t, "repository:samalba/my-app:pull,push", params["scope"])
+
+	scheme, params = parseChallenge(`Basic realm=registry`)
+	assert.Equal(t, "Basic", scheme)
+	assert.Equal(t, "registry", params["realm"])
+
+	scheme, params = parseChallenge("")
+	assert.Equal(t, "", scheme)
+	assert.Equal(t, 0, len(params))
+}
+
+func TestRegistryBaseURL(t *testing.T) {
+	for server, expected := range map[string]string{
+		"docker.io":                   "https://registry-1.docker.io",
+		"https://index.docker.io/v1/": "https://registry-1.docker.io",
+		"localhost:5000":              "https://localhost:5000",
+		"http://localhost:5000":       "http://localhost:5000",
+	} {
+		u, err := registryBaseURL(server)
+		assert.NilError(t, err)
+		assert.Equal(t, expected, u.String())
+	}
+}
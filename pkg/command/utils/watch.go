@@ -0,0 +1,173 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// WatchOutputTable re-renders the list whenever the watched resources change
+	WatchOutputTable = "table"
+	// WatchOutputJSON emits a JSON event per change of the watched resources
+	WatchOutputJSON = "json"
+)
+
+// watchRestartDelay is the delay before a closed watch is restarted
+var watchRestartDelay = time.Second
+
+// WatchFlags are the flags of the list commands supporting watch mode
+type WatchFlags struct {
+	Watch  bool
+	Output string
+}
+
+// AddFlags adds --watch and --watch-output to the list command
+func (f *WatchFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&f.Watch, "watch", "w", false, "watch for changes and re-render the list, or emit the changes as JSON events with --watch-output json")
+	cmd.Flags().StringVar(&f.Output, "watch-output", WatchOutputTable, "output of the changes in watch mode, either 'table' or 'json'")
+}
+
+// Validate returns an error if the flags are invalid
+func (f *WatchFlags) Validate() error {
+	if f.Output != WatchOutputTable && f.Output != WatchOutputJSON {
+		return fmt.Errorf("invalid --watch-output '%s', expected '%s' or '%s'", f.Output, WatchOutputTable, WatchOutputJSON)
+	}
+	if !f.Watch && f.Output != WatchOutputTable {
+		return fmt.Errorf("--watch-output requires --watch")
+	}
+	return nil
+}
+
+// WatchEvent is a change of a watched resource emitted in JSON
+type WatchEvent struct {
+	Type   watch.EventType `json:"type"`
+	Kind   string          `json:"kind"`
+	Object runtime.Object  `json:"object"`
+}
+
+// ListWatch is a list command in watch mode
+type ListWatch struct {
+	// Kind is the kind of the watched resources
+	Kind string
+	// List prints the list to out, and returns the resource version to watch from
+	List func(out io.Writer) (string, error)
+	// Watch starts a watch of the listed resources from the resource version, the current
+	// resources are sent as ADDED events if the resource version is empty
+	Watch func(ctx context.Context, resourceVersion string) (watch.Interface, error)
+	// Redact removes the sensitive data from the object of a JSON event, it's optional
+	Redact func(obj runtime.Object) runtime.Object
+}
+
+// Run prints the list, then either re-renders the list or emits a JSON event whenever the watched resources change,
+// until the context is done. The watch is restarted from the last seen resource version if it's closed by the server
+func (lw ListWatch) Run(ctx context.Context, out io.Writer, output string) error {
+	resourceVersion := ""
+	if output == WatchOutputTable {
+		var err error
+		if resourceVersion, err = lw.List(out); err != nil {
+			return err
+		}
+	}
+
+	for {
+		w, err := lw.Watch(ctx, resourceVersion)
+		if err != nil {
+			return fmt.Errorf("failed to watch %s: %v", lw.Kind, err)
+		}
+		var expired bool
+		resourceVersion, expired, err = lw.handleEvents(ctx, w, out, output, resourceVersion)
+		w.Stop()
+		if err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(watchRestartDelay):
+		}
+		// the resource version is too old, so the watch is restarted from the current resources,
+		// which are listed again in table or sent as ADDED events in JSON
+		if expired && output == WatchOutputTable {
+			fmt.Fprintln(out)
+			if resourceVersion, err = lw.List(out); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handleEvents handles the events until the watch is closed or the context is done, and returns the resource
+// version to restart the watch from, or true if the resource version is expired
+func (lw ListWatch) handleEvents(ctx context.Context, w watch.Interface, out io.Writer, output string, resourceVersion string) (string, bool, error) {
+	encoder := json.NewEncoder(out)
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion, false, nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return resourceVersion, false, nil
+			}
+			switch event.Type {
+			case watch.Bookmark:
+				if accessor, err := meta.Accessor(event.Object); err == nil {
+					resourceVersion = accessor.GetResourceVersion()
+				}
+				continue
+			case watch.Error:
+				err := apierrors.FromObject(event.Object)
+				if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+					return "", true, nil
+				}
+				return resourceVersion, false, fmt.Errorf("failed to watch %s: %v", lw.Kind, err)
+			}
+
+			name := ""
+			if accessor, err := meta.Accessor(event.Object); err == nil {
+				resourceVersion = accessor.GetResourceVersion()
+				name = accessor.GetName()
+				if accessor.GetNamespace() != "" {
+					name = accessor.GetNamespace() + "/" + name
+				}
+			}
+
+			if output == WatchOutputJSON {
+				obj := event.Object
+				if lw.Redact != nil {
+					obj = lw.Redact(obj.DeepCopyObject())
+				}
+				if err := encoder.Encode(WatchEvent{Type: event.Type, Kind: lw.Kind, Object: obj}); err != nil {
+					return resourceVersion, false, err
+				}
+				continue
+			}
+			fmt.Fprintf(out, "\n%s %s %s %s\n", time.Now().Format(time.RFC3339), event.Type, lw.Kind, name)
+			if _, err := lw.List(out); err != nil {
+				return resourceVersion, false, err
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2020 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// testListWatch records the lists and the resource versions of the watches
+type testListWatch struct {
+	lists    int
+	versions []string
+	watchers chan *watch.FakeWatcher
+}
+
+func newTestListWatch() *testListWatch {
+	return &testListWatch{watchers: make(chan *watch.FakeWatcher, 10)}
+}
+
+func (t *testListWatch) listWatch() ListWatch {
+	return ListWatch{
+		Kind: "ConfigMap",
+		List: func(out io.Writer) (string, error) {
+			t.lists++
+			fmt.Fprintf(out, "list %d\n", t.lists)
+			return fmt.Sprintf("%d", t.lists*10), nil
+		},
+		Watch: func(ctx context.Context, resourceVersion string) (watch.Interface, error) {
+			t.versions = append(t.versions, resourceVersion)
+			w := watch.NewFake()
+			t.watchers <- w
+			return w, nil
+		},
+		Redact: func(obj runtime.Object) runtime.Object {
+			obj.(*corev1.ConfigMap).Data = nil
+			return obj
+		},
+	}
+}
+
+// runListWatch runs the ListWatch in the output until send returns
+func runListWatch(lw ListWatch, output string, send func()) (string, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := &bytes.Buffer{}
+	done := make(chan error)
+	go func() {
+		done <- lw.Run(ctx, out, output)
+	}()
+	send()
+	cancel()
+	err := <-done
+	return out.String(), err
+}
+
+func newTestConfigMap(resourceVersion string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config-domain", Namespace: "knative-serving", ResourceVersion: resourceVersion},
+		Data:       map[string]string{"secret": "value"},
+	}
+}
+
+func TestWatchFlags(t *testing.T) {
+	assert.NilError(t, (&WatchFlags{Output: WatchOutputTable}).Validate())
+	assert.NilError(t, (&WatchFlags{Watch: true, Output: WatchOutputJSON}).Validate())
+	assert.ErrorContains(t, (&WatchFlags{Watch: true, Output: "yaml"}).Validate(), "invalid --watch-output 'yaml'")
+	assert.ErrorContains(t, (&WatchFlags{Output: WatchOutputJSON}).Validate(), "--watch-output requires --watch")
+}
+
+func TestListWatchRun(t *testing.T) {
+	t.Run("re-render the list on changes", func(t *testing.T) {
+		tlw := newTestListWatch()
+		output, err := runListWatch(tlw.listWatch(), WatchOutputTable, func() {
+			w := <-tlw.watchers
+			w.Modify(newTestConfigMap("11"))
+			w.Delete(newTestConfigMap("12"))
+		})
+		assert.NilError(t, err)
+		assert.Equal(t, 3, tlw.lists)
+		assert.DeepEqual(t, []string{"10"}, tlw.versions)
+		assert.Check(t, strings.Contains(output, "MODIFIED ConfigMap knative-serving/config-domain\nlist 2\n"), "unexpected output: %s", output)
+		assert.Check(t, strings.Contains(output, "DELETED ConfigMap knative-serving/config-domain\nlist 3\n"), "unexpected output: %s", output)
+	})
+
+	t.Run("emit JSON events", func(t *testing.T) {
+		tlw := newTestListWatch()
+		output, err := runListWatch(tlw.listWatch(), WatchOutputJSON, func() {
+			w := <-tlw.watchers
+			w.Add(newTestConfigMap("11"))
+			w.Modify(newTestConfigMap("12"))
+		})
+		assert.NilError(t, err)
+		assert.Equal(t, 0, tlw.lists)
+		assert.DeepEqual(t, []string{""}, tlw.versions)
+
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		assert.Equal(t, 2, len(lines), "unexpected output: %s", output)
+		event := map[string]interface{}{}
+		assert.NilError(t, json.Unmarshal([]byte(lines[1]), &event))
+		assert.Equal(t, "MODIFIED", event["type"])
+		assert.Equal(t, "ConfigMap", event["kind"])
+		object := event["object"].(map[string]interface{})
+		assert.Equal(t, "config-domain", object["metadata"].(map[string]interface{})["name"])
+		assert.Check(t, object["data"] == nil, "data should be redacted: %s", output)
+	})
+
+	t.Run("restart the watch from the last resource version", func(t *testing.T) {
+		defer func(delay time.Duration) { watchRestartDelay = delay }(watchRestartDelay)
+		watchRestartDelay = time.Millisecond
+		tlw := newTestListWatch()
+		_, err := runListWatch(tlw.listWatch(), WatchOutputTable, func() {
+			w := <-tlw.watchers
+			w.Modify(newTestConfigMap("11"))
+			w.Stop()
+			w = <-tlw.watchers
+			w.Error(&apierrors.NewResourceExpired("too old resource version").ErrStatus)
+			w = <-tlw.watchers
+			w.Modify(newTestConfigMap("31"))
+		})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []string{"10", "11", "30"}, tlw.versions)
+	})
+
+	t.Run("return error of the watch", func(t *testing.T) {
+		tlw := newTestListWatch()
+		lw := tlw.listWatch()
+		_, err := runListWatch(lw, WatchOutputTable, func() {
+			w := <-tlw.watchers
+			w.Error(&apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "config-domain", fmt.Errorf("denied")).ErrStatus)
+		})
+		assert.ErrorContains(t, err, "failed to watch ConfigMap")
+	})
+}
@@ -16,17 +16,27 @@ package testutil
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"sync"
 
 	"knative.dev/networking/pkg/client/clientset/versioned"
 
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/clientcmd"
 
 	nwfake "knative.dev/networking/pkg/client/clientset/versioned/fake"
+	servingscheme "knative.dev/serving/pkg/client/clientset/versioned/scheme"
+	servingv1client "knative.dev/serving/pkg/client/clientset/versioned/typed/serving/v1"
+	servingv1fake "knative.dev/serving/pkg/client/clientset/versioned/typed/serving/v1/fake"
 
 	"knative.dev/kn-plugin-admin/pkg"
 )
@@ -51,10 +61,47 @@ func ExecuteCommand(root *cobra.Command, args ...string) (output string, err err
 	return o, err
 }
 
+// ExecuteWatchCommand executes the command in watch mode, the watch of the resource on the fake client is replaced
+// by a fake watcher which is passed to send once the command starts watching, and the command is stopped after send returns
+func ExecuteWatchCommand(root *cobra.Command, fake *k8stesting.Fake, resource string, send func(w *watch.FakeWatcher), args ...string) (output string, err error) {
+	w := watch.NewFake()
+	watching := make(chan struct{})
+	var once sync.Once
+	fake.PrependWatchReactor(resource, func(action k8stesting.Action) (bool, watch.Interface, error) {
+		once.Do(func() { close(watching) })
+		return true, w, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	root.SetContext(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		output, err = ExecuteCommand(root, args...)
+		close(done)
+	}()
+	// the events are sent to an unbuffered channel, so send returns only after the command received them
+	sent := make(chan struct{})
+	go func() {
+		<-watching
+		send(w)
+		close(sent)
+	}()
+	select {
+	case <-sent:
+		cancel()
+		<-done
+	case <-done:
+	}
+	return output, err
+}
+
 // NewTestAdminParams creates an AdminParams and kubernetes clientset for testing
 func NewTestAdminParams(objects ...runtime.Object) (*pkg.AdminParams, *k8sfake.Clientset) {
 	client := k8sfake.NewSimpleClientset(objects...)
 	networkingClient := nwfake.NewSimpleClientset()
+	dynamicClient := NewTestDynamicClient()
+	servingClient := NewTestServingClient()
 	return &pkg.AdminParams{
 		NewNetworkingClient: func() (versioned.Interface, error) {
 			return networkingClient, nil
@@ -62,9 +109,36 @@ func NewTestAdminParams(objects ...runtime.Object) (*pkg.AdminParams, *k8sfake.C
 		NewKubeClient: func() (kubernetes.Interface, error) {
 			return client, nil
 		},
+		NewDynamicClient: func() (dynamic.Interface, error) {
+			return dynamicClient, nil
+		},
+		NewServingClient: func() (servingv1client.ServingV1Interface, error) {
+			return servingClient, nil
+		},
 	}, client
 }
 
+// NewTestServingClient creates a Knative Serving client for testing with the given Services and Revisions
+func NewTestServingClient(objects ...runtime.Object) *servingv1fake.FakeServingV1 {
+	tracker := k8stesting.NewObjectTracker(servingscheme.Scheme, servingscheme.Codecs.UniversalDecoder())
+	for _, obj := range objects {
+		if err := tracker.Add(obj); err != nil {
+			panic(err)
+		}
+	}
+	fake := &k8stesting.Fake{}
+	fake.AddReactor("*", "*", k8stesting.ObjectReaction(tracker))
+	return &servingv1fake.FakeServingV1{Fake: fake}
+}
+
+// NewTestDynamicClient creates a dynamic client for testing, which knows how to list KnativeServing and PodAutoscaler
+func NewTestDynamicClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		pkg.KnativeServingResource: "KnativeServingList",
+		pkg.PodAutoscalerResource:  "PodAutoscalerList",
+	}, objects...)
+}
+
 func NewTestAdminParamsWithNetworkingObjects(objects ...runtime.Object) *pkg.AdminParams {
 	client := k8sfake.NewSimpleClientset()
 	networkingClient := nwfake.NewSimpleClientset(objects...)
@@ -93,6 +167,12 @@ func NewTestAdminWithoutKubeConfig() *pkg.AdminParams {
 		NewKubeClient: func() (kubernetes.Interface, error) {
 			return nil, errors.New(ErrNoKubeConfiguration)
 		},
+		NewDynamicClient: func() (dynamic.Interface, error) {
+			return nil, errors.New(ErrNoKubeConfiguration)
+		},
+		NewServingClient: func() (servingv1client.ServingV1Interface, error) {
+			return nil, errors.New(ErrNoKubeConfiguration)
+		},
 		InstallationMethod: 0,
 	}
 }
@@ -23,8 +23,11 @@ import (
 	"strings"
 
 	"knative.dev/networking/pkg/client/clientset/versioned"
+	servingv1client "knative.dev/serving/pkg/client/clientset/versioned/typed/serving/v1"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -33,12 +36,28 @@ import (
 // LabelManagedBy is a label name to indicate who is managing this resource
 var LabelManagedBy = "app.kubernetes.io/managed-by"
 
+// KnativeServingResource is the resource of KnativeServing managed by Knative Operator
+var KnativeServingResource = schema.GroupVersionResource{
+	Group:    "operator.knative.dev",
+	Version:  "v1beta1",
+	Resource: "knativeservings",
+}
+
+// PodAutoscalerResource is the resource of PodAutoscaler created by Knative Serving for each Revision
+var PodAutoscalerResource = schema.GroupVersionResource{
+	Group:    "autoscaling.internal.knative.dev",
+	Version:  "v1alpha1",
+	Resource: "podautoscalers",
+}
+
 // AdminParams stores the configs for interacting with kube api
 type AdminParams struct {
 	KubeCfgPath         string
 	ClientConfig        clientcmd.ClientConfig
 	NewNetworkingClient func() (versioned.Interface, error)
 	NewKubeClient       func() (kubernetes.Interface, error)
+	NewDynamicClient    func() (dynamic.Interface, error)
+	NewServingClient    func() (servingv1client.ServingV1Interface, error)
 	InstallationMethod  InstallationMethod
 }
 
@@ -68,6 +87,12 @@ func (params *AdminParams) Initialize() error {
 	if params.NewNetworkingClient == nil {
 		params.NewNetworkingClient = params.newNetworkingClient
 	}
+	if params.NewDynamicClient == nil {
+		params.NewDynamicClient = params.newDynamicClient
+	}
+	if params.NewServingClient == nil {
+		params.NewServingClient = params.newServingClient
+	}
 	if params.InstallationMethod == InstallationMethodUnknown {
 		im, err := params.installationMethod()
 		if err != nil {
@@ -170,3 +195,21 @@ func (params *AdminParams) newNetworkingClient() (versioned.Interface, error) {
 	}
 	return versioned.NewForConfig(restConfig)
 }
+
+// newDynamicClient creates a dynamic client from kubenetes config, which is used to access custom resources, e.g: KnativeServing
+func (params *AdminParams) newDynamicClient() (dynamic.Interface, error) {
+	restConfig, err := params.RestConfig()
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(restConfig)
+}
+
+// newServingClient creates a Knative Serving client from kubenetes config
+func (params *AdminParams) newServingClient() (servingv1client.ServingV1Interface, error) {
+	restConfig, err := params.RestConfig()
+	if err != nil {
+		return nil, err
+	}
+	return servingv1client.NewForConfig(restConfig)
+}